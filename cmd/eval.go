@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/w-h-a/interpreter/internal/loader"
+	"github.com/w-h-a/interpreter/internal/object"
+)
+
+const EXPRESSION_NAME = "<expression>"
+
+// Eval prints the value of the program in file, or of expression when there
+// is no file, which may be empty
+func Eval(in io.Reader, out io.Writer, file, expression string, searchPath []string) error {
+	result, err := programResult(evalProgram(in, file, expression, loader.NewWithOutput(out, searchPath...)))
+	if err != nil || result == nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, result.Inspect())
+
+	return err
+}
+
+func evalProgram(in io.Reader, file, expression string, l *loader.Loader) (object.Object, error) {
+	if len(file) == 0 {
+		return runSource(l, EXPRESSION_NAME, expression)
+	}
+
+	if file != STDIN {
+		return l.Run(file)
+	}
+
+	src, err := readSource(in, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return runSource(l, STDIN_NAME, src)
+}
+
+func runSource(l *loader.Loader, name, src string) (object.Object, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	return l.RunSource(name, src, dir)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/w-h-a/interpreter/internal/infer"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/loader"
+	"github.com/w-h-a/interpreter/internal/parser"
+)
+
+func Infer(in io.Reader, out io.Writer, file string) error {
+	src, err := readSource(in, file)
+	if err != nil {
+		return err
+	}
+
+	name := sourceName(file)
+
+	p := parser.New(lexer.New(src))
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return &loader.ParseError{File: name, Errors: p.Errors()}
+	}
+
+	bindings, err := infer.Infer(program)
+	if err != nil {
+		return fmt.Errorf("%s:%w", name, err)
+	}
+
+	for _, b := range bindings {
+		if _, err := fmt.Fprintln(out, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
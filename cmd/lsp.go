@@ -0,0 +1,11 @@
+package cmd
+
+import (
+	"io"
+
+	"github.com/w-h-a/interpreter/internal/lsp"
+)
+
+func Lsp(in io.Reader, out io.Writer) error {
+	return lsp.Serve(in, out)
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/loader"
+	"github.com/w-h-a/interpreter/internal/object"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	errorobject "github.com/w-h-a/interpreter/internal/object/error"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/pretty"
+	"github.com/w-h-a/interpreter/internal/resolver"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+const META_PREFIX = ":"
+
+type session struct {
+	out       io.Writer
+	loader    *loader.Loader
+	dir       string
+	env       *environment.Environment
+	operators token.Operators
+	printer   pretty.Printer
+}
+
+func (s *session) reset() {
+	s.env = s.loader.Environment(s.dir)
+	s.operators = token.Operators{}
+}
+
+func (s *session) print(obj object.Object) error {
+	formatted, truncated := s.printer.Format(obj)
+
+	if _, err := fmt.Fprintln(s.out, formatted); err != nil {
+		return err
+	}
+
+	if truncated {
+		if _, err := fmt.Fprintf(s.out, "(truncated, %sfull shows all of %s)\n", META_PREFIX, LAST_RESULT); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *session) completions() []string {
+	words := []string{}
+
+	words = append(words, token.Keywords()...)
+	words = append(words, evaluator.Builtins()...)
+	words = append(words, s.env.Names()...)
+
+	for name := range metaCommands {
+		words = append(words, META_PREFIX+name)
+	}
+
+	return words
+}
+
+type metaCommand struct {
+	usage string
+	help  string
+	run   func(s *session, arg string) error
+}
+
+var metaCommands map[string]metaCommand
+
+func init() {
+	metaCommands = map[string]metaCommand{
+		"tokens": {usage: ":tokens <src>", help: "show the tokens the lexer produces for src", run: metaTokens},
+		"ast":    {usage: ":ast <src>", help: "show the syntax tree the parser builds for src", run: metaAst},
+		"env":    {usage: ":env", help: "list the bindings in the session", run: metaEnv},
+		"type":   {usage: ":type <expr>", help: "evaluate expr and show the type of its value", run: metaType},
+		"load":   {usage: ":load <file>", help: "evaluate file into the session", run: metaLoad},
+		"reset":  {usage: ":reset", help: "discard every binding in the session", run: metaReset},
+		"time":   {usage: ":time <expr>", help: "evaluate expr and show how long it took", run: metaTime},
+		"full":   {usage: ":full", help: "show the last result without truncation", run: metaFull},
+		"help":   {usage: ":help", help: "show this message", run: metaHelp},
+	}
+}
+
+func isMetaCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), META_PREFIX)
+}
+
+func runMetaCommand(s *session, line string) error {
+	line = strings.TrimPrefix(strings.TrimSpace(line), META_PREFIX)
+
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	command, ok := metaCommands[name]
+	if !ok {
+		_, err := fmt.Fprintf(s.out, "unknown command %s%s, try :help\n", META_PREFIX, name)
+		return err
+	}
+
+	return command.run(s, arg)
+}
+
+func metaTokens(s *session, arg string) error {
+	diagnostics, err := printTokens(s.out, arg, maps.Clone(s.operators))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range diagnostics {
+		if _, err := fmt.Fprintln(s.out, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func metaAst(s *session, arg string) error {
+	p := parser.New(lexer.NewWithOperators(arg, maps.Clone(s.operators)))
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return printParserErrors(s.out, p.Errors())
+	}
+
+	return tree.Print(s.out, program)
+}
+
+func metaEnv(s *session, _ string) error {
+	for _, name := range s.env.Names() {
+		obj, _ := s.env.Get(name)
+
+		if _, err := fmt.Fprintf(s.out, "%s: %s\n", name, obj.Type()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func metaType(s *session, arg string) error {
+	evaluated, ok, err := evalSource(s.out, s.env, s.operators, arg)
+	if !ok || err != nil {
+		return err
+	}
+
+	if evaluated == nil {
+		return nil
+	}
+
+	_, err = fmt.Fprintln(s.out, evaluated.Type())
+
+	return err
+}
+
+func metaLoad(s *session, arg string) error {
+	if len(arg) == 0 {
+		_, err := fmt.Fprintf(s.out, "usage: %s\n", metaCommands["load"].usage)
+		return err
+	}
+
+	src, err := os.ReadFile(arg)
+	if err != nil {
+		return printRuntimeError(s.out, err.Error())
+	}
+
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return printRuntimeError(s.out, err.Error())
+	}
+
+	// the file's imports are relative to it, as when it is run
+	previous := s.env.SetImporter(s.loader.Environment(filepath.Dir(abs)).Importer())
+	defer s.env.SetImporter(previous)
+
+	if _, _, err := evalSource(s.out, s.env, s.operators, string(src)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func metaReset(s *session, _ string) error {
+	s.reset()
+	return nil
+}
+
+func metaTime(s *session, arg string) error {
+	start := time.Now()
+
+	evaluated, ok, err := evalSource(s.out, s.env, s.operators, arg)
+	if !ok || err != nil {
+		return err
+	}
+
+	elapsed := time.Since(start)
+
+	if evaluated != nil {
+		if err := s.print(evaluated); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(s.out, "elapsed: %s\n", elapsed)
+
+	return err
+}
+
+func metaFull(s *session, _ string) error {
+	last, ok := s.env.Get(LAST_RESULT)
+	if !ok {
+		return nil
+	}
+
+	full := s.printer
+	full.MaxItems = 0
+	full.MaxString = 0
+
+	formatted, _ := full.Format(last)
+
+	_, err := fmt.Fprintln(s.out, formatted)
+
+	return err
+}
+
+func metaHelp(s *session, _ string) error {
+	names := make([]string, 0, len(metaCommands))
+	for name := range metaCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		command := metaCommands[name]
+		if _, err := fmt.Fprintf(s.out, "%-16s %s\n", command.usage, command.help); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evalSource reports ok=false when it already printed a parser or runtime
+// error; operators declared in src are added to operators
+func evalSource(out io.Writer, env *environment.Environment, operators token.Operators, src string) (object.Object, bool, error) {
+	p := parser.New(lexer.NewWithOperators(src, operators))
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return nil, false, printParserErrors(out, p.Errors())
+	}
+
+	resolver.Resolve(program)
+
+	evaluated := evaluator.Eval(program, env)
+
+	if errObj, ok := evaluated.(*errorobject.Error); ok {
+		return nil, false, printRuntimeError(out, errObj.Message)
+	}
+
+	return evaluated, true, nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/w-h-a/interpreter/internal/ast/codec"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/cst"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/loader"
+	"github.com/w-h-a/interpreter/internal/parser"
+)
+
+const (
+	FORMAT_TREE = "tree"
+	FORMAT_JSON = "json"
+	FORMAT_DOT  = "dot"
+	FORMAT_CST  = "cst"
+)
+
+func Parse(in io.Reader, out io.Writer, file, format string) error {
+	src, err := readSource(in, file)
+	if err != nil {
+		return err
+	}
+
+	p := parser.New(lexer.New(src))
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return &loader.ParseError{File: sourceName(file), Errors: p.Errors()}
+	}
+
+	switch format {
+	case FORMAT_TREE:
+		return tree.Print(out, program)
+	case FORMAT_JSON:
+		encoded, err := codec.Encode(program)
+		if err != nil {
+			return err
+		}
+
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, encoded, "", "  "); err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(out, indented.String())
+
+		return err
+	case FORMAT_DOT:
+		return tree.Dot(out, program)
+	case FORMAT_CST:
+		root, _ := cst.Parse(src)
+		return cst.Print(out, root)
+	default:
+		return fmt.Errorf("unknown format %q, want %s, %s, %s or %s", format, FORMAT_TREE, FORMAT_JSON, FORMAT_DOT, FORMAT_CST)
+	}
+}
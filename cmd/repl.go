@@ -2,48 +2,242 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/loader"
 	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/pretty"
+	"github.com/w-h-a/interpreter/internal/readline"
+	"github.com/w-h-a/interpreter/internal/token"
 )
 
-const PROMPT = ">> "
+const (
+	PROMPT              = ">> "
+	CONTINUATION_PROMPT = ".. "
+	LAST_RESULT         = "_"
+	HISTORY_FILE        = ".monkey_history"
+)
+
+type Option func(*options)
+
+type options struct {
+	searchPath []string
+	color      bool
+}
+
+func WithSearchPath(dirs ...string) Option {
+	return func(o *options) {
+		o.searchPath = append(o.searchPath, dirs...)
+	}
+}
+
+func WithColor(enabled bool) Option {
+	return func(o *options) {
+		o.color = enabled
+	}
+}
+
+func StartRepl(in io.Reader, out io.Writer, opts ...Option) error {
+	o := &options{color: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	color := o.color && colorEnabled(out)
+
+	s := &session{out: out, loader: loader.NewWithOutput(out, o.searchPath...), dir: dir, printer: pretty.New(color)}
+	s.reset()
 
-func StartRepl(in io.Reader, out io.Writer) error {
-	scanner := bufio.NewScanner(in)
+	reader := newLineReader(in, out, s, color)
+
+	lines := []string{}
 
 	for {
-		fmt.Fprint(out, PROMPT)
+		prompt := PROMPT
+		if len(lines) > 0 {
+			prompt = CONTINUATION_PROMPT
+		}
+
+		line, err := reader.ReadLine(prompt)
 
-		if !scanner.Scan() {
-			return scanner.Err()
+		if errors.Is(err, readline.ErrInterrupted) {
+			lines = []string{}
+			continue
 		}
 
-		line := scanner.Text()
-		tks := lexer.Lex(line)
-		p := parser.New(tks)
+		if errors.Is(err, io.EOF) {
+			if len(lines) == 0 {
+				return nil
+			}
 
-		program := p.ParseProgram()
+			// Ctrl-D submits what we have; a terminal can keep reading after it
+			fmt.Fprintln(out)
 
-		if len(p.Errors()) > 0 {
-			if err := printParserErrors(out, p.Errors()); err != nil {
+			if err := evalInput(s, strings.Join(lines, "\n")); err != nil {
 				return err
 			}
+
+			lines = []string{}
+
 			continue
 		}
 
-		if _, err := io.WriteString(out, program.String()); err != nil {
+		if err != nil {
 			return err
 		}
 
-		if _, err := io.WriteString(out, "\n"); err != nil {
+		if len(lines) > 0 && len(strings.TrimSpace(line)) == 0 {
+			if err := evalInput(s, strings.Join(lines, "\n")); err != nil {
+				return err
+			}
+			lines = []string{}
+			continue
+		}
+
+		if len(lines) == 0 && isMetaCommand(line) {
+			if err := runMetaCommand(s, line); err != nil {
+				return err
+			}
+			continue
+		}
+
+		lines = append(lines, line)
+
+		src := strings.Join(lines, "\n")
+
+		if isIncomplete(src, s.operators) {
+			continue
+		}
+
+		if err := evalInput(s, src); err != nil {
 			return err
 		}
+
+		lines = []string{}
 	}
 }
 
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+type scanReader struct {
+	in      io.Reader
+	out     io.Writer
+	scanner *bufio.Scanner
+}
+
+func (r *scanReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+
+		r.scanner = bufio.NewScanner(r.in)
+
+		return "", io.EOF
+	}
+
+	return r.scanner.Text(), nil
+}
+
+func colorEnabled(out io.Writer) bool {
+	if len(os.Getenv("NO_COLOR")) > 0 {
+		return false
+	}
+
+	f, ok := out.(*os.File)
+
+	return ok && readline.IsTerminal(f)
+}
+
+func newLineReader(in io.Reader, out io.Writer, s *session, color bool) lineReader {
+	inFile, inOk := in.(*os.File)
+	outFile, outOk := out.(*os.File)
+
+	if !inOk || !outOk || !readline.IsTerminal(inFile) || !readline.IsTerminal(outFile) {
+		return &scanReader{in: in, out: out, scanner: bufio.NewScanner(in)}
+	}
+
+	history := readline.NewHistory()
+
+	if home, err := os.UserHomeDir(); err == nil {
+		if loaded, err := readline.LoadHistory(filepath.Join(home, HISTORY_FILE)); err == nil {
+			history = loaded
+		}
+	}
+
+	editor := readline.NewTerminal(inFile, out, history, s.completions)
+
+	if color {
+		editor.SetHighlighter(pretty.Highlight)
+	}
+
+	return editor
+}
+
+func evalInput(s *session, src string) error {
+	evaluated, ok, err := evalSource(s.out, s.env, s.operators, src)
+	if !ok || err != nil || evaluated == nil {
+		return err
+	}
+
+	s.env.Set(LAST_RESULT, evaluated)
+
+	return s.print(evaluated)
+}
+
+func isIncomplete(src string, operators token.Operators) bool {
+	depth := 0
+
+	l := lexer.NewWithOperators(src, maps.Clone(operators))
+
+	for tk := range l.All() {
+		switch tk.Type {
+		case token.ParenLeft, token.BraceLeft, token.BracketLeft, token.StringStart:
+			depth += 1
+		case token.ParenRight, token.BraceRight, token.BracketRight, token.StringEnd:
+			depth -= 1
+		}
+	}
+
+	for _, d := range l.Diagnostics() {
+		if d.Message == lexer.UNTERMINATED_STRING {
+			depth += 1
+		}
+	}
+
+	if depth > 0 {
+		return true
+	}
+
+	p := parser.New(lexer.NewWithOperators(src, maps.Clone(operators)))
+
+	p.ParseProgram()
+
+	for _, msg := range p.Errors() {
+		if strings.HasSuffix(msg, "got "+string(token.EOF)) || strings.HasSuffix(msg, "for "+string(token.EOF)+" found") {
+			return true
+		}
+	}
+
+	return false
+}
+
 func printParserErrors(out io.Writer, errors []string) error {
 	if _, err := io.WriteString(out, "Whoops! We ran into some monkey business here!\n"); err != nil {
 		return err
@@ -61,3 +255,15 @@ func printParserErrors(out io.Writer, errors []string) error {
 
 	return nil
 }
+
+func printRuntimeError(out io.Writer, msg string) error {
+	if _, err := io.WriteString(out, "Uh oh! The monkey tripped while running that!\n"); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(out, " runtime error:\n\t%s\n", msg); err != nil {
+		return err
+	}
+
+	return nil
+}
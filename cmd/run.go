@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/w-h-a/interpreter/internal/loader"
+	"github.com/w-h-a/interpreter/internal/object"
+	errorobject "github.com/w-h-a/interpreter/internal/object/error"
+	"github.com/w-h-a/interpreter/internal/readline"
+)
+
+// SEARCH_PATH_ENV lists the directories to search when no --path is given,
+// separated as PATH is
+const SEARCH_PATH_ENV = "MONKEY_PATH"
+
+// SearchPath is the directories given with --path, or else those in
+// MONKEY_PATH; unlike a flag's environment variable, it is not split on
+// commas, which directory names may contain
+func SearchPath(dirs []string) []string {
+	if len(dirs) > 0 {
+		return dirs
+	}
+	return filepath.SplitList(os.Getenv(SEARCH_PATH_ENV))
+}
+
+func Run(file string, searchPath []string) error {
+	_, err := programResult(loader.New(searchPath...).Run(file))
+	return err
+}
+
+func Batch(in io.Reader, searchPath []string) error {
+	_, err := programResult(evalProgram(in, STDIN, "", loader.New(searchPath...)))
+	return err
+}
+
+func IsInteractive(in *os.File) bool {
+	return readline.IsTerminal(in)
+}
+
+func programResult(result object.Object, err error) (object.Object, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if errObj, ok := result.(*errorobject.Error); ok {
+		return nil, errors.New(errObj.Message)
+	}
+
+	return result, nil
+}
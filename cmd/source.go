@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"io"
+	"os"
+)
+
+const (
+	STDIN      = "-"
+	STDIN_NAME = "<stdin>"
+)
+
+func readSource(in io.Reader, file string) (string, error) {
+	if file == STDIN {
+		src, err := io.ReadAll(in)
+		return string(src), err
+	}
+
+	src, err := os.ReadFile(file)
+
+	return string(src), err
+}
+
+func sourceName(file string) string {
+	if file == STDIN {
+		return STDIN_NAME
+	}
+	return file
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+type LexError struct {
+	File     string
+	Problems int
+}
+
+func (e *LexError) Error() string {
+	return fmt.Sprintf("tokens found %d problem(s) in %s", e.Problems, e.File)
+}
+
+// Tokens prints the tokens of a program to out and what the lexer could not
+// read to errOut
+func Tokens(in io.Reader, out, errOut io.Writer, file string) error {
+	src, err := readSource(in, file)
+	if err != nil {
+		return err
+	}
+
+	name := sourceName(file)
+
+	diagnostics, err := printTokens(out, src, token.Operators{})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range diagnostics {
+		if _, err := fmt.Fprintf(errOut, "%s:%s: %s\n", name, d.Pos, d.Message); err != nil {
+			return err
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		return &LexError{File: name, Problems: len(diagnostics)}
+	}
+
+	return nil
+}
+
+func printTokens(out io.Writer, src string, operators token.Operators) ([]lexer.Diagnostic, error) {
+	l := lexer.NewWithOperators(src, operators)
+
+	for tk := range l.All() {
+		if tk.Type == token.EOF {
+			break
+		}
+
+		if _, err := fmt.Fprintf(out, "%s %s %q\n", tk.Position(), tk.Type, tk.Literal()); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.Diagnostics(), nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/loader"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/typecheck"
+)
+
+type TypeError struct {
+	File     string
+	Problems int
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("typecheck found %d problem(s) in %s", e.Problems, e.File)
+}
+
+func Typecheck(in io.Reader, out io.Writer, file string) error {
+	src, err := readSource(in, file)
+	if err != nil {
+		return err
+	}
+
+	name := sourceName(file)
+
+	p := parser.New(lexer.New(src))
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return &loader.ParseError{File: name, Errors: p.Errors()}
+	}
+
+	diagnostics := typecheck.Check(program)
+
+	for _, d := range diagnostics {
+		if _, err := fmt.Fprintf(out, "%s:%s\n", name, d); err != nil {
+			return err
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		return &TypeError{File: name, Problems: len(diagnostics)}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/loader"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/vet"
+)
+
+const FORMAT_TEXT = "text"
+
+type vetFinding struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Offset  int    `json:"offset"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+type VetError struct {
+	File     string
+	Problems int
+}
+
+func (e *VetError) Error() string {
+	return fmt.Sprintf("vet found %d problem(s) in %s", e.Problems, e.File)
+}
+
+func Vet(in io.Reader, out io.Writer, file, format string) error {
+	if format != FORMAT_TEXT && format != FORMAT_JSON {
+		return fmt.Errorf("unknown format %q, want %s or %s", format, FORMAT_TEXT, FORMAT_JSON)
+	}
+
+	src, err := readSource(in, file)
+	if err != nil {
+		return err
+	}
+
+	name := sourceName(file)
+
+	p := parser.New(lexer.New(src))
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return &loader.ParseError{File: name, Errors: p.Errors()}
+	}
+
+	diagnostics := vet.Vet(program)
+
+	switch format {
+	case FORMAT_JSON:
+		findings := make([]vetFinding, 0, len(diagnostics))
+		for _, d := range diagnostics {
+			findings = append(findings, vetFinding{File: name, Line: d.Pos.Line, Column: d.Pos.Column, Offset: d.Pos.Offset, Check: d.Check, Message: d.Message})
+		}
+
+		encoder := json.NewEncoder(out)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(findings); err != nil {
+			return err
+		}
+	default:
+		for _, d := range diagnostics {
+			if _, err := fmt.Fprintf(out, "%s:%s\n", name, d); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		return &VetError{File: name, Problems: len(diagnostics)}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package annotation
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+)
+
+const (
+	INT    = "int"
+	STRING = "string"
+	BOOL   = "bool"
+	NULL   = "null"
+	ANY    = "any"
+)
+
+var names = map[string]bool{INT: true, STRING: true, BOOL: true, NULL: true, ANY: true}
+
+func IsName(name string) bool {
+	return names[name]
+}
+
+// Annotation is a type written in the source, after `:` or `->`
+type Annotation interface {
+	ast.Node
+	AnnotationNode()
+}
+
+type Named struct {
+	Token ast.Token
+	Name  string
+}
+
+func (a *Named) TokenLiteral() string {
+	return a.Token.Literal()
+}
+
+func (a *Named) String() string {
+	return a.Name
+}
+
+func (a *Named) AnnotationNode() {}
+
+type Array struct {
+	Token   ast.Token
+	Element Annotation
+}
+
+func (a *Array) TokenLiteral() string {
+	return a.Token.Literal()
+}
+
+func (a *Array) String() string {
+	return "[" + a.Element.String() + "]"
+}
+
+func (a *Array) AnnotationNode() {}
+
+type Hash struct {
+	Token ast.Token
+	Key   Annotation
+	Value Annotation
+}
+
+func (a *Hash) TokenLiteral() string {
+	return a.Token.Literal()
+}
+
+func (a *Hash) String() string {
+	return "{" + a.Key.String() + ": " + a.Value.String() + "}"
+}
+
+func (a *Hash) AnnotationNode() {}
+
+type Function struct {
+	Token      ast.Token
+	Parameters []Annotation
+	Result     Annotation
+}
+
+func (a *Function) TokenLiteral() string {
+	return a.Token.Literal()
+}
+
+func (a *Function) String() string {
+	params := []string{}
+	for _, p := range a.Parameters {
+		params = append(params, p.String())
+	}
+
+	return a.TokenLiteral() + "(" + strings.Join(params, ", ") + ") -> " + a.Result.String()
+}
+
+func (a *Function) AnnotationNode() {}
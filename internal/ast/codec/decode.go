@@ -0,0 +1,352 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
+	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	"github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
+	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/symbol"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+type fields map[string]json.RawMessage
+
+type encodedToken struct {
+	Type     string `json:"type"`
+	Literal  string `json:"literal"`
+	Position struct {
+		Offset int `json:"offset"`
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"position"`
+}
+
+func Decode(data []byte) (*statement.Program, error) {
+	var doc Document
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Version < MIN_VERSION || doc.Version > VERSION {
+		return nil, fmt.Errorf("unsupported AST schema version %d, want %d to %d", doc.Version, MIN_VERSION, VERSION)
+	}
+
+	node, err := decodeNode(doc.Program)
+	if err != nil {
+		return nil, err
+	}
+
+	program, ok := node.(*statement.Program)
+	if !ok {
+		return nil, fmt.Errorf("expected Program at the root, got %T", node)
+	}
+
+	if err := checkVersion(program, doc.Version); err != nil {
+		return nil, err
+	}
+
+	return program, nil
+}
+
+// checkVersion rejects nodes that the document's version did not have yet
+func checkVersion(node ast.Node, version int) error {
+	kind := tree.Kind(node)
+
+	if since, ok := introduced[kind]; ok && version < since {
+		return fmt.Errorf("%s needs AST schema version %d, document is version %d", kind, since, version)
+	}
+
+	for _, child := range tree.Children(node) {
+		if tree.IsNil(child.Node) {
+			continue
+		}
+		if err := checkVersion(child.Node, version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeNode(raw json.RawMessage) (ast.Node, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+
+	var f fields
+
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+
+	var kind string
+
+	if err := f.value("type", &kind); err != nil {
+		return nil, err
+	}
+
+	node, err := f.decode(kind)
+	if err != nil {
+		return nil, fmt.Errorf("in %s: %w", kind, err)
+	}
+
+	return node, nil
+}
+
+func (f fields) decode(kind string) (ast.Node, error) {
+	if kind == "Program" {
+		statements, err := decodeList[statement.Statement](f["statements"])
+		return &statement.Program{Statements: statements}, err
+	}
+
+	tk, err := f.token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "Block":
+		node := &block.Block{Token: tk}
+		node.Statements, err = decodeList[statement.Statement](f["statements"])
+		return node, err
+	case "ExpressionStatement":
+		node := &expressionstatement.Expression{Token: tk}
+		err = decodeInto(f["expression"], &node.Expression)
+		return node, err
+	case "Let":
+		node := &let.Let{Token: tk}
+		err = errors.Join(decodeInto(f["name"], &node.Name), decodeInto(f["annotation"], &node.Type), decodeInto(f["value"], &node.Value))
+		return node, err
+	case "Operator":
+		node := &operator.Operator{Token: tk}
+		err = errors.Join(decodeInto(f["precedence"], &node.Precedence), decodeInto(f["name"], &node.Name), decodeInto(f["value"], &node.Value))
+		return node, err
+	case "Return":
+		node := &returnstatement.Return{Token: tk}
+		err = decodeInto(f["value"], &node.Value)
+		return node, err
+	case "Import":
+		node := &importstatement.Import{Token: tk}
+		err = errors.Join(decodeInto(f["path"], &node.Path), decodeInto(f["alias"], &node.Alias))
+		return node, err
+	case "Export":
+		node := &export.Export{Token: tk}
+		err = decodeInto(f["let"], &node.Let)
+		return node, err
+	case "Identifier":
+		node := &identifier.Identifier{Token: tk}
+		err = f.value("value", &node.Value)
+		return node, err
+	case "Integer":
+		node := &integer.Integer{Token: tk}
+		err = f.value("value", &node.Value)
+		return node, err
+	case "Boolean":
+		node := &boolean.Boolean{Token: tk}
+		err = f.value("value", &node.Value)
+		return node, err
+	case "String":
+		node := &stringexpression.String{Token: tk}
+		err = f.value("value", &node.Value)
+		return node, err
+	case "Null":
+		return &null.Null{Token: tk}, nil
+	case "Array":
+		node := &array.Array{Token: tk}
+		node.Elements, err = decodeList[expression.Expression](f["elements"])
+		return node, err
+	case "Hash":
+		node := &hash.Hash{Token: tk}
+		pairs := []fields{}
+		if err := json.Unmarshal(f["pairs"], &pairs); err != nil {
+			return nil, err
+		}
+		for _, p := range pairs {
+			pair := hash.Pair{}
+			if err := errors.Join(decodeInto(p["key"], &pair.Key), decodeInto(p["value"], &pair.Value)); err != nil {
+				return nil, err
+			}
+			node.Pairs = append(node.Pairs, pair)
+		}
+		return node, nil
+	case "PrefixOperator":
+		node := &prefixoperator.PrefixOperator{Token: tk}
+		err = errors.Join(f.value("operator", &node.Operator), decodeInto(f["right"], &node.Right))
+		return node, err
+	case "InfixOperator":
+		node := &infixoperator.InfixOperator{Token: tk}
+		err = errors.Join(f.value("operator", &node.Operator), decodeInto(f["left"], &node.Left), decodeInto(f["right"], &node.Right))
+		return node, err
+	case "Pipeline":
+		node := &pipeline.Pipeline{Token: tk}
+		err = errors.Join(decodeInto(f["left"], &node.Left), decodeInto(f["right"], &node.Right))
+		return node, err
+	case "If":
+		node := &ifexpression.If{Token: tk}
+		err = errors.Join(decodeInto(f["condition"], &node.Condition), decodeInto(f["consequence"], &node.Consequence), decodeInto(f["alternative"], &node.Alternative))
+		return node, err
+	case "Function":
+		node := &function.Function{Token: tk}
+		if err := f.value("name", &node.Name); err != nil {
+			return nil, err
+		}
+		if node.Parameters, err = decodeList[*identifier.Identifier](f["parameters"]); err != nil {
+			return nil, err
+		}
+		if node.Types, err = decodeList[annotation.Annotation](f["annotations"]); err != nil {
+			return nil, err
+		}
+		if node.Defaults, err = decodeList[expression.Expression](f["defaults"]); err != nil {
+			return nil, err
+		}
+		err = errors.Join(decodeInto(f["rest"], &node.Rest), decodeInto(f["restAnnotation"], &node.RestType), decodeInto(f["result"], &node.Result), decodeInto(f["body"], &node.Body))
+		return node, err
+	case "Call":
+		node := &call.Call{Token: tk}
+		if err := decodeInto(f["function"], &node.Function); err != nil {
+			return nil, err
+		}
+		node.Arguments, err = decodeList[expression.Expression](f["arguments"])
+		return node, err
+	case "Index":
+		node := &index.Index{Token: tk}
+		err = errors.Join(f.value("optional", &node.Optional), decodeInto(f["left"], &node.Left), decodeInto(f["index"], &node.Index))
+		return node, err
+	case "Field":
+		node := &field.Field{Token: tk}
+		err = errors.Join(f.value("optional", &node.Optional), decodeInto(f["left"], &node.Left), decodeInto(f["field"], &node.Field))
+		return node, err
+	case "Interpolation":
+		node := &interpolation.Interpolation{Token: tk}
+		node.Parts, err = decodeList[expression.Expression](f["parts"])
+		return node, err
+	case "Embedded":
+		node := &interpolation.Embedded{Token: tk}
+		err = decodeInto(f["expression"], &node.Expression)
+		return node, err
+	case "NamedType":
+		node := &annotation.Named{Token: tk}
+		err = f.value("name", &node.Name)
+		return node, err
+	case "ArrayType":
+		node := &annotation.Array{Token: tk}
+		err = decodeInto(f["element"], &node.Element)
+		return node, err
+	case "HashType":
+		node := &annotation.Hash{Token: tk}
+		err = errors.Join(decodeInto(f["key"], &node.Key), decodeInto(f["value"], &node.Value))
+		return node, err
+	case "FunctionType":
+		node := &annotation.Function{Token: tk}
+		if node.Parameters, err = decodeList[annotation.Annotation](f["parameters"]); err != nil {
+			return nil, err
+		}
+		err = decodeInto(f["result"], &node.Result)
+		return node, err
+	default:
+		return nil, fmt.Errorf("unknown node type %q", kind)
+	}
+}
+
+func (f fields) value(name string, target any) error {
+	raw, ok := f[name]
+	if !ok {
+		return fmt.Errorf("missing field %q", name)
+	}
+
+	if err := json.Unmarshal(raw, target); err != nil {
+		return fmt.Errorf("field %q: %w", name, err)
+	}
+
+	return nil
+}
+
+func (f fields) token() (token.Token, error) {
+	var tk encodedToken
+
+	if err := f.value("token", &tk); err != nil {
+		return token.Token{}, err
+	}
+
+	pos := token.Position{Offset: tk.Position.Offset, Line: tk.Position.Line, Column: tk.Position.Column}
+
+	decoded := token.FactoryAt(token.TokenType(tk.Type), tk.Literal, pos)
+	if decoded.Type == token.Ident || decoded.Type == token.Operator {
+		decoded.Symbol = symbol.Intern(decoded.Literal())
+	}
+
+	return decoded, nil
+}
+
+func decodeInto[T ast.Node](raw json.RawMessage, target *T) error {
+	node, err := decodeNode(raw)
+	if err != nil || node == nil {
+		return err
+	}
+
+	typed, ok := node.(T)
+	if !ok {
+		return fmt.Errorf("unexpected %T", node)
+	}
+
+	*target = typed
+
+	return nil
+}
+
+func decodeList[T ast.Node](raw json.RawMessage) ([]T, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+
+	items := []json.RawMessage{}
+
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+
+	nodes := []T{}
+
+	for _, item := range items {
+		var node T
+		if err := decodeInto(item, &node); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
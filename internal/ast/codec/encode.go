@@ -0,0 +1,260 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
+	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	"github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
+	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+type Document struct {
+	Version int             `json:"version"`
+	Program json.RawMessage `json:"program"`
+}
+
+type object map[string]any
+
+func Encode(program *statement.Program) ([]byte, error) {
+	encoded, err := encodeNode(program)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(Document{Version: VERSION, Program: raw})
+}
+
+func encodeNode(node ast.Node) (any, error) {
+	if tree.IsNil(node) {
+		return nil, nil
+	}
+
+	obj := object{"type": tree.Kind(node)}
+
+	var err error
+
+	switch node := node.(type) {
+	case *statement.Program:
+		obj["statements"], err = encodeList(node.Statements)
+	case *block.Block:
+		obj["token"] = encodeToken(node.Token)
+		obj["statements"], err = encodeList(node.Statements)
+	case *expressionstatement.Expression:
+		obj["token"] = encodeToken(node.Token)
+		err = obj.set("expression", node.Expression)
+	case *let.Let:
+		obj["token"] = encodeToken(node.Token)
+		err = errors.Join(obj.set("name", node.Name), obj.setOptional("annotation", node.Type), obj.set("value", node.Value))
+	case *operator.Operator:
+		obj["token"] = encodeToken(node.Token)
+		err = errors.Join(obj.set("precedence", node.Precedence), obj.set("name", node.Name), obj.set("value", node.Value))
+	case *returnstatement.Return:
+		obj["token"] = encodeToken(node.Token)
+		err = obj.set("value", node.Value)
+	case *importstatement.Import:
+		obj["token"] = encodeToken(node.Token)
+		err = errors.Join(obj.set("path", node.Path), obj.set("alias", node.Alias))
+	case *export.Export:
+		obj["token"] = encodeToken(node.Token)
+		err = obj.set("let", node.Let)
+	case *identifier.Identifier:
+		obj["token"] = encodeToken(node.Token)
+		obj["value"] = node.Value
+	case *integer.Integer:
+		obj["token"] = encodeToken(node.Token)
+		obj["value"] = node.Value
+	case *boolean.Boolean:
+		obj["token"] = encodeToken(node.Token)
+		obj["value"] = node.Value
+	case *stringexpression.String:
+		obj["token"] = encodeToken(node.Token)
+		obj["value"] = node.Value
+	case *null.Null:
+		obj["token"] = encodeToken(node.Token)
+	case *array.Array:
+		obj["token"] = encodeToken(node.Token)
+		obj["elements"], err = encodeList(node.Elements)
+	case *hash.Hash:
+		obj["token"] = encodeToken(node.Token)
+		pairs := []any{}
+		for _, pair := range node.Pairs {
+			encoded := object{}
+			if err := errors.Join(encoded.set("key", pair.Key), encoded.set("value", pair.Value)); err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, encoded)
+		}
+		obj["pairs"] = pairs
+	case *prefixoperator.PrefixOperator:
+		obj["token"] = encodeToken(node.Token)
+		obj["operator"] = node.Operator
+		err = obj.set("right", node.Right)
+	case *infixoperator.InfixOperator:
+		obj["token"] = encodeToken(node.Token)
+		obj["operator"] = node.Operator
+		err = errors.Join(obj.set("left", node.Left), obj.set("right", node.Right))
+	case *pipeline.Pipeline:
+		obj["token"] = encodeToken(node.Token)
+		err = errors.Join(obj.set("left", node.Left), obj.set("right", node.Right))
+	case *ifexpression.If:
+		obj["token"] = encodeToken(node.Token)
+		err = errors.Join(obj.set("condition", node.Condition), obj.set("consequence", node.Consequence), obj.set("alternative", node.Alternative))
+	case *function.Function:
+		obj["token"] = encodeToken(node.Token)
+		obj["name"] = node.Name
+		if obj["parameters"], err = encodeList(node.Parameters); err != nil {
+			return nil, err
+		}
+		if annotated(node.Types) {
+			if obj["annotations"], err = encodeList(node.Types); err != nil {
+				return nil, err
+			}
+		}
+		if obj["defaults"], err = encodeList(node.Defaults); err != nil {
+			return nil, err
+		}
+		err = errors.Join(obj.set("rest", node.Rest), obj.setOptional("restAnnotation", node.RestType), obj.setOptional("result", node.Result), obj.set("body", node.Body))
+	case *call.Call:
+		obj["token"] = encodeToken(node.Token)
+		if err = obj.set("function", node.Function); err != nil {
+			return nil, err
+		}
+		obj["arguments"], err = encodeList(node.Arguments)
+	case *index.Index:
+		obj["token"] = encodeToken(node.Token)
+		obj["optional"] = node.Optional
+		err = errors.Join(obj.set("left", node.Left), obj.set("index", node.Index))
+	case *field.Field:
+		obj["token"] = encodeToken(node.Token)
+		obj["optional"] = node.Optional
+		err = errors.Join(obj.set("left", node.Left), obj.set("field", node.Field))
+	case *interpolation.Interpolation:
+		obj["token"] = encodeToken(node.Token)
+		obj["parts"], err = encodeList(node.Parts)
+	case *interpolation.Embedded:
+		obj["token"] = encodeToken(node.Token)
+		err = obj.set("expression", node.Expression)
+	case *annotation.Named:
+		obj["token"] = encodeToken(node.Token)
+		obj["name"] = node.Name
+	case *annotation.Array:
+		obj["token"] = encodeToken(node.Token)
+		err = obj.set("element", node.Element)
+	case *annotation.Hash:
+		obj["token"] = encodeToken(node.Token)
+		err = errors.Join(obj.set("key", node.Key), obj.set("value", node.Value))
+	case *annotation.Function:
+		obj["token"] = encodeToken(node.Token)
+		if obj["parameters"], err = encodeList(node.Parameters); err != nil {
+			return nil, err
+		}
+		err = obj.set("result", node.Result)
+	default:
+		return nil, fmt.Errorf("cannot encode node of type %T", node)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (o object) set(name string, node ast.Node) error {
+	encoded, err := encodeNode(node)
+	if err != nil {
+		return err
+	}
+
+	o[name] = encoded
+
+	return nil
+}
+
+// setOptional leaves out a field added after version 1 when it is empty, so
+// programs that do not use it encode as they did
+func (o object) setOptional(name string, node ast.Node) error {
+	if tree.IsNil(node) {
+		return nil
+	}
+
+	return o.set(name, node)
+}
+
+func annotated(types []annotation.Annotation) bool {
+	for _, t := range types {
+		if !tree.IsNil(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeList[T ast.Node](nodes []T) ([]any, error) {
+	encoded := []any{}
+
+	for _, node := range nodes {
+		e, err := encodeNode(node)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, e)
+	}
+
+	return encoded, nil
+}
+
+func encodeToken(tk ast.Token) object {
+	if tk == nil {
+		return nil
+	}
+
+	var tokenType token.TokenType
+	if t, ok := tk.(token.Token); ok {
+		tokenType = t.Type
+	}
+
+	pos := tk.Position()
+
+	return object{
+		"type":    string(tokenType),
+		"literal": tk.Literal(),
+		"position": object{
+			"offset": pos.Offset,
+			"line":   pos.Line,
+			"column": pos.Column,
+		},
+	}
+}
@@ -0,0 +1,977 @@
+package codec
+
+// VERSION is the version Encode writes; version 2 added operator
+// declarations and type annotations, and Decode still reads version 1
+// documents, which have neither
+const VERSION = 2
+
+const MIN_VERSION = 1
+
+// the version each node type first appeared in, for those after the first;
+// the annotation fields of Let and Function are left out when empty, so only
+// the annotations themselves need checking
+var introduced = map[string]int{
+	"Operator":     2,
+	"NamedType":    2,
+	"ArrayType":    2,
+	"HashType":     2,
+	"FunctionType": 2,
+}
+
+// Schema describes every version Decode reads
+const Schema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://github.com/w-h-a/interpreter/ast/v2.json",
+  "title": "Monkey AST",
+  "description": "Documents of versions 1 and 2; a version 1 document has no operator declarations or type annotations",
+  "type": "object",
+  "properties": {
+    "version": {
+      "type": "integer",
+      "minimum": 1,
+      "maximum": 2
+    },
+    "program": {
+      "$ref": "#/$defs/Program"
+    }
+  },
+  "required": [
+    "version",
+    "program"
+  ],
+  "additionalProperties": false,
+  "$defs": {
+    "token": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "type": "string"
+        },
+        "literal": {
+          "type": "string"
+        },
+        "position": {
+          "type": "object",
+          "properties": {
+            "offset": {
+              "type": "integer",
+              "minimum": 0
+            },
+            "line": {
+              "type": "integer",
+              "minimum": 0
+            },
+            "column": {
+              "type": "integer",
+              "minimum": 0
+            }
+          },
+          "required": [
+            "offset",
+            "line",
+            "column"
+          ],
+          "additionalProperties": false
+        }
+      },
+      "required": [
+        "type",
+        "literal",
+        "position"
+      ],
+      "additionalProperties": false
+    },
+    "Program": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Program"
+        },
+        "statements": {
+          "type": "array",
+          "items": {
+            "$ref": "#/$defs/statement"
+          }
+        }
+      },
+      "required": [
+        "type",
+        "statements"
+      ],
+      "additionalProperties": false
+    },
+    "Block": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Block"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "statements": {
+          "type": "array",
+          "items": {
+            "$ref": "#/$defs/statement"
+          }
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "statements"
+      ],
+      "additionalProperties": false
+    },
+    "ExpressionStatement": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "ExpressionStatement"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "expression": {
+          "oneOf": [
+            {
+              "$ref": "#/$defs/expression"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "expression"
+      ],
+      "additionalProperties": false
+    },
+    "Let": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Let"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "name": {
+          "$ref": "#/$defs/Identifier"
+        },
+        "annotation": {
+          "oneOf": [
+            {
+              "$ref": "#/$defs/annotation"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        },
+        "value": {
+          "oneOf": [
+            {
+              "$ref": "#/$defs/expression"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "name",
+        "value"
+      ],
+      "additionalProperties": false
+    },
+    "Operator": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Operator"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "precedence": {
+          "$ref": "#/$defs/Integer"
+        },
+        "name": {
+          "$ref": "#/$defs/Identifier"
+        },
+        "value": {
+          "oneOf": [
+            {
+              "$ref": "#/$defs/expression"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "precedence",
+        "name",
+        "value"
+      ],
+      "additionalProperties": false
+    },
+    "Return": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Return"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "value": {
+          "oneOf": [
+            {
+              "$ref": "#/$defs/expression"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "value"
+      ],
+      "additionalProperties": false
+    },
+    "Import": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Import"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "path": {
+          "$ref": "#/$defs/String"
+        },
+        "alias": {
+          "oneOf": [
+            {
+              "$ref": "#/$defs/Identifier"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "path",
+        "alias"
+      ],
+      "additionalProperties": false
+    },
+    "Export": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Export"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "let": {
+          "$ref": "#/$defs/Let"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "let"
+      ],
+      "additionalProperties": false
+    },
+    "Identifier": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Identifier"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "value": {
+          "type": "string"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "value"
+      ],
+      "additionalProperties": false
+    },
+    "Integer": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Integer"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "value": {
+          "type": "integer"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "value"
+      ],
+      "additionalProperties": false
+    },
+    "Boolean": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Boolean"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "value": {
+          "type": "boolean"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "value"
+      ],
+      "additionalProperties": false
+    },
+    "String": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "String"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "value": {
+          "type": "string"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "value"
+      ],
+      "additionalProperties": false
+    },
+    "Null": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Null"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        }
+      },
+      "required": [
+        "type",
+        "token"
+      ],
+      "additionalProperties": false
+    },
+    "Array": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Array"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "elements": {
+          "type": "array",
+          "items": {
+            "$ref": "#/$defs/expression"
+          }
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "elements"
+      ],
+      "additionalProperties": false
+    },
+    "Hash": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Hash"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "pairs": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "properties": {
+              "key": {
+                "$ref": "#/$defs/expression"
+              },
+              "value": {
+                "$ref": "#/$defs/expression"
+              }
+            },
+            "required": [
+              "key",
+              "value"
+            ],
+            "additionalProperties": false
+          }
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "pairs"
+      ],
+      "additionalProperties": false
+    },
+    "PrefixOperator": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "PrefixOperator"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "operator": {
+          "type": "string"
+        },
+        "right": {
+          "$ref": "#/$defs/expression"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "operator",
+        "right"
+      ],
+      "additionalProperties": false
+    },
+    "InfixOperator": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "InfixOperator"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "operator": {
+          "type": "string"
+        },
+        "left": {
+          "$ref": "#/$defs/expression"
+        },
+        "right": {
+          "$ref": "#/$defs/expression"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "operator",
+        "left",
+        "right"
+      ],
+      "additionalProperties": false
+    },
+    "Pipeline": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Pipeline"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "left": {
+          "$ref": "#/$defs/expression"
+        },
+        "right": {
+          "$ref": "#/$defs/expression"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "left",
+        "right"
+      ],
+      "additionalProperties": false
+    },
+    "If": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "If"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "condition": {
+          "$ref": "#/$defs/expression"
+        },
+        "consequence": {
+          "$ref": "#/$defs/Block"
+        },
+        "alternative": {
+          "oneOf": [
+            {
+              "$ref": "#/$defs/Block"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "condition",
+        "consequence",
+        "alternative"
+      ],
+      "additionalProperties": false
+    },
+    "Function": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Function"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "name": {
+          "type": "string"
+        },
+        "parameters": {
+          "type": "array",
+          "items": {
+            "$ref": "#/$defs/Identifier"
+          }
+        },
+        "annotations": {
+          "type": "array",
+          "items": {
+            "oneOf": [
+              {
+                "$ref": "#/$defs/annotation"
+              },
+              {
+                "type": "null"
+              }
+            ]
+          }
+        },
+        "defaults": {
+          "type": "array",
+          "items": {
+            "oneOf": [
+              {
+                "$ref": "#/$defs/expression"
+              },
+              {
+                "type": "null"
+              }
+            ]
+          }
+        },
+        "rest": {
+          "oneOf": [
+            {
+              "$ref": "#/$defs/Identifier"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        },
+        "restAnnotation": {
+          "$ref": "#/$defs/annotation"
+        },
+        "result": {
+          "oneOf": [
+            {
+              "$ref": "#/$defs/annotation"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        },
+        "body": {
+          "$ref": "#/$defs/Block"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "name",
+        "parameters",
+        "defaults",
+        "rest",
+        "body"
+      ],
+      "additionalProperties": false
+    },
+    "Call": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Call"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "function": {
+          "$ref": "#/$defs/expression"
+        },
+        "arguments": {
+          "type": "array",
+          "items": {
+            "$ref": "#/$defs/expression"
+          }
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "function",
+        "arguments"
+      ],
+      "additionalProperties": false
+    },
+    "Index": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Index"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "optional": {
+          "type": "boolean"
+        },
+        "left": {
+          "$ref": "#/$defs/expression"
+        },
+        "index": {
+          "$ref": "#/$defs/expression"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "optional",
+        "left",
+        "index"
+      ],
+      "additionalProperties": false
+    },
+    "Field": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Field"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "optional": {
+          "type": "boolean"
+        },
+        "left": {
+          "$ref": "#/$defs/expression"
+        },
+        "field": {
+          "$ref": "#/$defs/Identifier"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "optional",
+        "left",
+        "field"
+      ],
+      "additionalProperties": false
+    },
+    "Interpolation": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Interpolation"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "parts": {
+          "type": "array",
+          "items": {
+            "oneOf": [
+              {
+                "$ref": "#/$defs/String"
+              },
+              {
+                "$ref": "#/$defs/Embedded"
+              }
+            ]
+          }
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "parts"
+      ],
+      "additionalProperties": false
+    },
+    "Embedded": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "Embedded"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "expression": {
+          "$ref": "#/$defs/expression"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "expression"
+      ],
+      "additionalProperties": false
+    },
+    "NamedType": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "NamedType"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "name": {
+          "enum": [
+            "int",
+            "string",
+            "bool",
+            "null",
+            "any"
+          ]
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "name"
+      ],
+      "additionalProperties": false
+    },
+    "ArrayType": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "ArrayType"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "element": {
+          "$ref": "#/$defs/annotation"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "element"
+      ],
+      "additionalProperties": false
+    },
+    "HashType": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "HashType"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "key": {
+          "$ref": "#/$defs/annotation"
+        },
+        "value": {
+          "$ref": "#/$defs/annotation"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "key",
+        "value"
+      ],
+      "additionalProperties": false
+    },
+    "FunctionType": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "const": "FunctionType"
+        },
+        "token": {
+          "$ref": "#/$defs/token"
+        },
+        "parameters": {
+          "type": "array",
+          "items": {
+            "$ref": "#/$defs/annotation"
+          }
+        },
+        "result": {
+          "$ref": "#/$defs/annotation"
+        }
+      },
+      "required": [
+        "type",
+        "token",
+        "parameters",
+        "result"
+      ],
+      "additionalProperties": false
+    },
+    "statement": {
+      "oneOf": [
+        {
+          "$ref": "#/$defs/ExpressionStatement"
+        },
+        {
+          "$ref": "#/$defs/Let"
+        },
+        {
+          "$ref": "#/$defs/Operator"
+        },
+        {
+          "$ref": "#/$defs/Return"
+        },
+        {
+          "$ref": "#/$defs/Import"
+        },
+        {
+          "$ref": "#/$defs/Export"
+        }
+      ]
+    },
+    "expression": {
+      "oneOf": [
+        {
+          "$ref": "#/$defs/Identifier"
+        },
+        {
+          "$ref": "#/$defs/Integer"
+        },
+        {
+          "$ref": "#/$defs/Boolean"
+        },
+        {
+          "$ref": "#/$defs/String"
+        },
+        {
+          "$ref": "#/$defs/Null"
+        },
+        {
+          "$ref": "#/$defs/Array"
+        },
+        {
+          "$ref": "#/$defs/Hash"
+        },
+        {
+          "$ref": "#/$defs/PrefixOperator"
+        },
+        {
+          "$ref": "#/$defs/InfixOperator"
+        },
+        {
+          "$ref": "#/$defs/Pipeline"
+        },
+        {
+          "$ref": "#/$defs/If"
+        },
+        {
+          "$ref": "#/$defs/Function"
+        },
+        {
+          "$ref": "#/$defs/Call"
+        },
+        {
+          "$ref": "#/$defs/Index"
+        },
+        {
+          "$ref": "#/$defs/Field"
+        },
+        {
+          "$ref": "#/$defs/Interpolation"
+        }
+      ]
+    },
+    "annotation": {
+      "oneOf": [
+        {
+          "$ref": "#/$defs/NamedType"
+        },
+        {
+          "$ref": "#/$defs/ArrayType"
+        },
+        {
+          "$ref": "#/$defs/HashType"
+        },
+        {
+          "$ref": "#/$defs/FunctionType"
+        }
+      ]
+    }
+  }
+}
+`
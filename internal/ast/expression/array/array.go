@@ -0,0 +1,35 @@
+package array
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+)
+
+type Array struct {
+	Token    ast.Token
+	Elements []expression.Expression
+}
+
+func (e *Array) TokenLiteral() string {
+	return e.Token.Literal()
+}
+
+func (e *Array) String() string {
+	var out strings.Builder
+
+	elements := []string{}
+
+	for _, el := range e.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+func (e *Array) ExpressionNode() {}
@@ -0,0 +1,38 @@
+package field
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+)
+
+type Field struct {
+	Token    ast.Token
+	Left     expression.Expression
+	Field    *identifier.Identifier
+	Optional bool
+}
+
+func (e *Field) TokenLiteral() string {
+	return e.Token.Literal()
+}
+
+func (e *Field) String() string {
+	var out strings.Builder
+
+	out.WriteString("(")
+	out.WriteString(e.Left.String())
+	if e.Optional {
+		out.WriteString("?.")
+	} else {
+		out.WriteString(".")
+	}
+	out.WriteString(e.Field.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+func (e *Field) ExpressionNode() {}
@@ -4,14 +4,28 @@ import (
 	"strings"
 
 	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
 	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
 	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/symbol"
 )
 
 type Function struct {
 	Token      ast.Token
+	Name       string
 	Parameters []*identifier.Identifier
+	Types      []annotation.Annotation
+	Defaults   []expression.Expression
+	Rest       *identifier.Identifier
+	RestType   annotation.Annotation
+	Result     annotation.Annotation
 	Body       *block.Block
+	// set by the resolver: the names of the frame's slots, and the names the
+	// function uses from the frames of the functions around it, with their
+	// coordinates from where the function is defined
+	Frame    []symbol.Symbol
+	Captures []*identifier.Identifier
 }
 
 func (e *Function) TokenLiteral() string {
@@ -23,14 +37,34 @@ func (e *Function) String() string {
 
 	params := []string{}
 
-	for _, p := range e.Parameters {
-		params = append(params, p.String())
+	for i, p := range e.Parameters {
+		param := p.String()
+		if i < len(e.Types) && e.Types[i] != nil {
+			param += ": " + e.Types[i].String()
+		}
+		if i < len(e.Defaults) && e.Defaults[i] != nil {
+			param += " = " + e.Defaults[i].String()
+		}
+		params = append(params, param)
+	}
+
+	if e.Rest != nil {
+		rest := ".." + e.Rest.String()
+		if e.RestType != nil {
+			rest += ": " + e.RestType.String()
+		}
+		params = append(params, rest)
 	}
 
 	out.WriteString(e.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")")
+	if e.Result != nil {
+		out.WriteString(" -> ")
+		out.WriteString(e.Result.String())
+		out.WriteString(" ")
+	}
 	out.WriteString(e.Body.String())
 
 	return out.String()
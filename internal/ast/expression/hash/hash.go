@@ -0,0 +1,40 @@
+package hash
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+)
+
+type Pair struct {
+	Key   expression.Expression
+	Value expression.Expression
+}
+
+type Hash struct {
+	Token ast.Token
+	Pairs []Pair
+}
+
+func (e *Hash) TokenLiteral() string {
+	return e.Token.Literal()
+}
+
+func (e *Hash) String() string {
+	var out strings.Builder
+
+	pairs := []string{}
+
+	for _, pair := range e.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (e *Hash) ExpressionNode() {}
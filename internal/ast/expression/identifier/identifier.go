@@ -2,11 +2,21 @@ package identifier
 
 import (
 	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/symbol"
+	"github.com/w-h-a/interpreter/internal/token"
 )
 
+// the slot of a name that no function binds
+const GLOBAL = -1
+
+// the resolver sets Depth, the number of function frames out from the use to
+// the one that binds the name, and Slot, its index in that frame's slots
 type Identifier struct {
-	Token ast.Token
-	Value string
+	Token    ast.Token
+	Value    string
+	Resolved bool
+	Depth    int
+	Slot     int
 }
 
 func (e *Identifier) TokenLiteral() string {
@@ -18,3 +28,12 @@ func (e *Identifier) String() string {
 }
 
 func (e *Identifier) ExpressionNode() {}
+
+// identifiers from the lexer carry the symbol it interned in their token;
+// ones built by hand do not
+func (e *Identifier) Symbol() symbol.Symbol {
+	if tk, ok := e.Token.(token.Token); ok && tk.Symbol != symbol.None {
+		return tk.Symbol
+	}
+	return symbol.Intern(e.Value)
+}
@@ -0,0 +1,36 @@
+package index
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+)
+
+type Index struct {
+	Token    ast.Token
+	Left     expression.Expression
+	Index    expression.Expression
+	Optional bool
+}
+
+func (e *Index) TokenLiteral() string {
+	return e.Token.Literal()
+}
+
+func (e *Index) String() string {
+	var out strings.Builder
+
+	out.WriteString("(")
+	out.WriteString(e.Left.String())
+	if e.Optional {
+		out.WriteString("?.")
+	}
+	out.WriteString("[")
+	out.WriteString(e.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+func (e *Index) ExpressionNode() {}
@@ -0,0 +1,53 @@
+package interpolation
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+)
+
+type Embedded struct {
+	Token      ast.Token
+	Expression expression.Expression
+}
+
+func (e *Embedded) TokenLiteral() string {
+	return e.Token.Literal()
+}
+
+func (e *Embedded) String() string {
+	return "${" + e.Expression.String() + "}"
+}
+
+func (e *Embedded) ExpressionNode() {}
+
+type Interpolation struct {
+	Token ast.Token
+	Parts []expression.Expression
+}
+
+func (e *Interpolation) TokenLiteral() string {
+	return e.Token.Literal()
+}
+
+func (e *Interpolation) String() string {
+	var out strings.Builder
+
+	out.WriteString("\"")
+
+	for _, part := range e.Parts {
+		if s, ok := part.(*stringexpression.String); ok {
+			out.WriteString(s.TokenLiteral())
+			continue
+		}
+		out.WriteString(part.String())
+	}
+
+	out.WriteString("\"")
+
+	return out.String()
+}
+
+func (e *Interpolation) ExpressionNode() {}
@@ -0,0 +1,19 @@
+package null
+
+import (
+	"github.com/w-h-a/interpreter/internal/ast"
+)
+
+type Null struct {
+	Token ast.Token
+}
+
+func (e *Null) TokenLiteral() string {
+	return e.Token.Literal()
+}
+
+func (e *Null) String() string {
+	return e.Token.Literal()
+}
+
+func (e *Null) ExpressionNode() {}
@@ -0,0 +1,32 @@
+package pipeline
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+)
+
+type Pipeline struct {
+	Token ast.Token
+	Left  expression.Expression
+	Right expression.Expression
+}
+
+func (e *Pipeline) TokenLiteral() string {
+	return e.Token.Literal()
+}
+
+func (e *Pipeline) String() string {
+	var out strings.Builder
+
+	out.WriteString("(")
+	out.WriteString(e.Left.String())
+	out.WriteString(" |> ")
+	out.WriteString(e.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+func (e *Pipeline) ExpressionNode() {}
@@ -0,0 +1,20 @@
+package stringexpression
+
+import (
+	"github.com/w-h-a/interpreter/internal/ast"
+)
+
+type String struct {
+	Token ast.Token
+	Value string
+}
+
+func (e *String) TokenLiteral() string {
+	return e.Token.Literal()
+}
+
+func (e *String) String() string {
+	return "\"" + e.Token.Literal() + "\""
+}
+
+func (e *String) ExpressionNode() {}
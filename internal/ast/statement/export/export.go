@@ -0,0 +1,21 @@
+package export
+
+import (
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+)
+
+type Export struct {
+	Token ast.Token
+	Let   *let.Let
+}
+
+func (s *Export) TokenLiteral() string {
+	return s.Token.Literal()
+}
+
+func (s *Export) String() string {
+	return s.TokenLiteral() + " " + s.Let.String()
+}
+
+func (s *Export) StatementNode() {}
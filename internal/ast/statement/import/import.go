@@ -0,0 +1,34 @@
+package importstatement
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+)
+
+type Import struct {
+	Token ast.Token
+	Path  *stringexpression.String
+	Alias *identifier.Identifier
+}
+
+func (s *Import) TokenLiteral() string {
+	return s.Token.Literal()
+}
+
+func (s *Import) String() string {
+	var out strings.Builder
+
+	out.WriteString(s.TokenLiteral())
+	out.WriteString(" ")
+	out.WriteString(s.Path.String())
+	out.WriteString(" as ")
+	out.WriteString(s.Alias.String())
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (s *Import) StatementNode() {}
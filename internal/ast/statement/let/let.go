@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
 	"github.com/w-h-a/interpreter/internal/ast/expression"
 	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
 )
@@ -11,6 +12,7 @@ import (
 type Let struct {
 	Token ast.Token
 	Name  *identifier.Identifier
+	Type  annotation.Annotation
 	Value expression.Expression
 }
 
@@ -24,6 +26,12 @@ func (s *Let) String() string {
 	out.WriteString(s.TokenLiteral())
 	out.WriteString(" ")
 	out.WriteString(s.Name.String())
+
+	if s.Type != nil {
+		out.WriteString(": ")
+		out.WriteString(s.Type.String())
+	}
+
 	out.WriteString(" = ")
 
 	if s.Value != nil {
@@ -0,0 +1,42 @@
+package operator
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+)
+
+type Operator struct {
+	Token      ast.Token
+	Precedence *integer.Integer
+	Name       *identifier.Identifier
+	Value      expression.Expression
+}
+
+func (s *Operator) TokenLiteral() string {
+	return s.Token.Literal()
+}
+
+func (s *Operator) String() string {
+	var out strings.Builder
+
+	out.WriteString(s.TokenLiteral())
+	out.WriteString(" ")
+	out.WriteString(s.Precedence.String())
+	out.WriteString(" ")
+	out.WriteString(s.Name.String())
+	out.WriteString(" = ")
+
+	if s.Value != nil {
+		out.WriteString(s.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (s *Operator) StatementNode() {}
@@ -1,5 +1,8 @@
 package ast
 
+import "github.com/w-h-a/interpreter/internal/token"
+
 type Token interface {
 	Literal() string
+	Position() token.Position
 }
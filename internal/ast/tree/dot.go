@@ -0,0 +1,62 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+)
+
+func Dot(out io.Writer, node ast.Node) error {
+	d := &dot{out: out}
+
+	d.printf("digraph AST {\n")
+	d.printf("  node [shape=box, fontname=\"monospace\"];\n")
+	d.printf("  edge [fontname=\"monospace\", fontsize=10];\n")
+
+	d.node(node)
+
+	d.printf("}\n")
+
+	return d.err
+}
+
+type dot struct {
+	out  io.Writer
+	next int
+	err  error
+}
+
+func (d *dot) node(node ast.Node) string {
+	id := fmt.Sprintf("n%d", d.next)
+	d.next++
+
+	label := Kind(node)
+	if detail := strings.TrimPrefix(Label(node), label); len(detail) > 0 {
+		label += "\n" + strings.TrimSpace(detail)
+	}
+
+	d.printf("  %s [label=%s];\n", id, quote(label))
+
+	for _, child := range Children(node) {
+		childID := d.node(child.Node)
+		d.printf("  %s -> %s [label=%s];\n", id, childID, quote(child.Field))
+	}
+
+	return id
+}
+
+func (d *dot) printf(format string, args ...any) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.out, format, args...)
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,356 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
+	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	"github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
+	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+)
+
+type Child struct {
+	Field string
+	Node  ast.Node
+}
+
+func Kind(node ast.Node) string {
+	switch node.(type) {
+	case *statement.Program:
+		return "Program"
+	case *block.Block:
+		return "Block"
+	case *expressionstatement.Expression:
+		return "ExpressionStatement"
+	case *let.Let:
+		return "Let"
+	case *operator.Operator:
+		return "Operator"
+	case *returnstatement.Return:
+		return "Return"
+	case *importstatement.Import:
+		return "Import"
+	case *export.Export:
+		return "Export"
+	case *identifier.Identifier:
+		return "Identifier"
+	case *integer.Integer:
+		return "Integer"
+	case *boolean.Boolean:
+		return "Boolean"
+	case *stringexpression.String:
+		return "String"
+	case *null.Null:
+		return "Null"
+	case *array.Array:
+		return "Array"
+	case *hash.Hash:
+		return "Hash"
+	case *prefixoperator.PrefixOperator:
+		return "PrefixOperator"
+	case *infixoperator.InfixOperator:
+		return "InfixOperator"
+	case *pipeline.Pipeline:
+		return "Pipeline"
+	case *ifexpression.If:
+		return "If"
+	case *function.Function:
+		return "Function"
+	case *call.Call:
+		return "Call"
+	case *index.Index:
+		return "Index"
+	case *field.Field:
+		return "Field"
+	case *interpolation.Interpolation:
+		return "Interpolation"
+	case *interpolation.Embedded:
+		return "Embedded"
+	case *annotation.Named:
+		return "NamedType"
+	case *annotation.Array:
+		return "ArrayType"
+	case *annotation.Hash:
+		return "HashType"
+	case *annotation.Function:
+		return "FunctionType"
+	default:
+		return fmt.Sprintf("%T", node)
+	}
+}
+
+func Label(node ast.Node) string {
+	switch node := node.(type) {
+	case *identifier.Identifier:
+		return Kind(node) + " " + node.Value
+	case *integer.Integer:
+		return Kind(node) + " " + node.TokenLiteral()
+	case *boolean.Boolean:
+		return Kind(node) + " " + node.TokenLiteral()
+	case *stringexpression.String:
+		return Kind(node) + " " + fmt.Sprintf("%q", node.Value)
+	case *prefixoperator.PrefixOperator:
+		return Kind(node) + " " + node.Operator
+	case *infixoperator.InfixOperator:
+		return Kind(node) + " " + node.Operator
+	case *operator.Operator:
+		return Kind(node) + " " + node.TokenLiteral()
+	case *function.Function:
+		if len(node.Name) > 0 {
+			return Kind(node) + " " + node.Name
+		}
+		return Kind(node)
+	case *index.Index:
+		if node.Optional {
+			return Kind(node) + " ?."
+		}
+		return Kind(node)
+	case *field.Field:
+		if node.Optional {
+			return Kind(node) + " ?." + node.Field.Value
+		}
+		return Kind(node) + " ." + node.Field.Value
+	case *annotation.Named:
+		return Kind(node) + " " + node.Name
+	default:
+		return Kind(node)
+	}
+}
+
+func Children(node ast.Node) []Child {
+	children := []Child{}
+
+	add := func(name string, n ast.Node) {
+		if IsNil(n) {
+			return
+		}
+		children = append(children, Child{Field: name, Node: n})
+	}
+
+	switch node := node.(type) {
+	case *statement.Program:
+		for i, s := range node.Statements {
+			add(fmt.Sprintf("Statements[%d]", i), s)
+		}
+	case *block.Block:
+		for i, s := range node.Statements {
+			add(fmt.Sprintf("Statements[%d]", i), s)
+		}
+	case *expressionstatement.Expression:
+		add("Expression", node.Expression)
+	case *let.Let:
+		add("Name", node.Name)
+		add("Type", node.Type)
+		add("Value", node.Value)
+	case *operator.Operator:
+		add("Precedence", node.Precedence)
+		add("Name", node.Name)
+		add("Value", node.Value)
+	case *returnstatement.Return:
+		add("Value", node.Value)
+	case *importstatement.Import:
+		add("Path", node.Path)
+		add("Alias", node.Alias)
+	case *export.Export:
+		add("Let", node.Let)
+	case *array.Array:
+		for i, e := range node.Elements {
+			add(fmt.Sprintf("Elements[%d]", i), e)
+		}
+	case *hash.Hash:
+		for i, pair := range node.Pairs {
+			add(fmt.Sprintf("Keys[%d]", i), pair.Key)
+			add(fmt.Sprintf("Values[%d]", i), pair.Value)
+		}
+	case *prefixoperator.PrefixOperator:
+		add("Right", node.Right)
+	case *infixoperator.InfixOperator:
+		add("Left", node.Left)
+		add("Right", node.Right)
+	case *pipeline.Pipeline:
+		add("Left", node.Left)
+		add("Right", node.Right)
+	case *ifexpression.If:
+		add("Condition", node.Condition)
+		add("Consequence", node.Consequence)
+		add("Alternative", node.Alternative)
+	case *function.Function:
+		for i, p := range node.Parameters {
+			add(fmt.Sprintf("Parameters[%d]", i), p)
+		}
+		for i, t := range node.Types {
+			add(fmt.Sprintf("Types[%d]", i), t)
+		}
+		for i, d := range node.Defaults {
+			add(fmt.Sprintf("Defaults[%d]", i), d)
+		}
+		add("Rest", node.Rest)
+		add("RestType", node.RestType)
+		add("Result", node.Result)
+		add("Body", node.Body)
+	case *call.Call:
+		add("Function", node.Function)
+		for i, a := range node.Arguments {
+			add(fmt.Sprintf("Arguments[%d]", i), a)
+		}
+	case *index.Index:
+		add("Left", node.Left)
+		add("Index", node.Index)
+	case *field.Field:
+		add("Left", node.Left)
+		add("Field", node.Field)
+	case *interpolation.Interpolation:
+		for i, p := range node.Parts {
+			add(fmt.Sprintf("Parts[%d]", i), p)
+		}
+	case *interpolation.Embedded:
+		add("Expression", node.Expression)
+	case *annotation.Array:
+		add("Element", node.Element)
+	case *annotation.Hash:
+		add("Key", node.Key)
+		add("Value", node.Value)
+	case *annotation.Function:
+		for i, p := range node.Parameters {
+			add(fmt.Sprintf("Parameters[%d]", i), p)
+		}
+		add("Result", node.Result)
+	}
+
+	return children
+}
+
+func Print(out io.Writer, node ast.Node) error {
+	return print(out, "", "", node)
+}
+
+func String(node ast.Node) string {
+	var out strings.Builder
+	_ = Print(&out, node)
+	return out.String()
+}
+
+func print(out io.Writer, indent, field string, node ast.Node) error {
+	line := Label(node)
+
+	if len(field) > 0 {
+		line = field + ": " + line
+	}
+
+	if _, err := fmt.Fprintf(out, "%s%s\n", indent, line); err != nil {
+		return err
+	}
+
+	for _, child := range Children(node) {
+		if err := print(out, indent+"  ", child.Field, child.Node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Token is the token a node was made from; a Program has none
+func Token(node ast.Node) ast.Token {
+	switch n := node.(type) {
+	case *block.Block:
+		return n.Token
+	case *expressionstatement.Expression:
+		return n.Token
+	case *let.Let:
+		return n.Token
+	case *operator.Operator:
+		return n.Token
+	case *returnstatement.Return:
+		return n.Token
+	case *importstatement.Import:
+		return n.Token
+	case *export.Export:
+		return n.Token
+	case *identifier.Identifier:
+		return n.Token
+	case *integer.Integer:
+		return n.Token
+	case *boolean.Boolean:
+		return n.Token
+	case *stringexpression.String:
+		return n.Token
+	case *null.Null:
+		return n.Token
+	case *array.Array:
+		return n.Token
+	case *hash.Hash:
+		return n.Token
+	case *prefixoperator.PrefixOperator:
+		return n.Token
+	case *infixoperator.InfixOperator:
+		return n.Token
+	case *pipeline.Pipeline:
+		return n.Token
+	case *ifexpression.If:
+		return n.Token
+	case *function.Function:
+		return n.Token
+	case *call.Call:
+		return n.Token
+	case *index.Index:
+		return n.Token
+	case *field.Field:
+		return n.Token
+	case *interpolation.Interpolation:
+		return n.Token
+	case *interpolation.Embedded:
+		return n.Token
+	case *annotation.Named:
+		return n.Token
+	case *annotation.Array:
+		return n.Token
+	case *annotation.Hash:
+		return n.Token
+	case *annotation.Function:
+		return n.Token
+	default:
+		return nil
+	}
+}
+
+func IsNil(node ast.Node) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case *block.Block:
+		return n == nil
+	case *identifier.Identifier:
+		return n == nil
+	case *stringexpression.String:
+		return n == nil
+	case *let.Let:
+		return n == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,111 @@
+package cst
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+const GROUP = "Group"
+
+type Element interface {
+	write(out *strings.Builder)
+}
+
+type Token struct {
+	token.Token
+	Leading string // the whitespace before the token
+	Text    string // the token as written, with quotes and ${ } for strings
+}
+
+func (t *Token) write(out *strings.Builder) {
+	out.WriteString(t.Leading)
+	out.WriteString(t.Text)
+}
+
+// Node is Kind from tree.Kind over the tokens Node was parsed from; a
+// parenthesized expression is a Group around the expression's own node
+type Node struct {
+	Kind     string
+	Node     ast.Node
+	Children []Element
+}
+
+func (n *Node) write(out *strings.Builder) {
+	for _, child := range n.Children {
+		child.write(out)
+	}
+}
+
+// String is the node's source text, including the whitespace before it
+func (n *Node) String() string {
+	var out strings.Builder
+	n.write(&out)
+	return out.String()
+}
+
+func (n *Node) Tokens() []*Token {
+	tokens := []*Token{}
+
+	for _, child := range n.Children {
+		switch child := child.(type) {
+		case *Token:
+			tokens = append(tokens, child)
+		case *Node:
+			tokens = append(tokens, child.Tokens()...)
+		}
+	}
+
+	return tokens
+}
+
+// Find returns the outermost concrete node of an abstract node
+func (n *Node) Find(node ast.Node) *Node {
+	if n.Node == node {
+		return n
+	}
+
+	for _, child := range n.Children {
+		if child, ok := child.(*Node); ok {
+			if found := child.Find(node); found != nil {
+				return found
+			}
+		}
+	}
+
+	return nil
+}
+
+func Print(out io.Writer, n *Node) error {
+	return print(out, n, 0)
+}
+
+func print(out io.Writer, n *Node, depth int) error {
+	indent := strings.Repeat("  ", depth)
+
+	if _, err := fmt.Fprintf(out, "%s%s\n", indent, n.Kind); err != nil {
+		return err
+	}
+
+	for _, child := range n.Children {
+		switch child := child.(type) {
+		case *Node:
+			if err := print(out, child, depth+1); err != nil {
+				return err
+			}
+		case *Token:
+			line := fmt.Sprintf("%s  %s %q", indent, child.Type, child.Text)
+			if len(child.Leading) > 0 {
+				line += fmt.Sprintf(" after %q", child.Leading)
+			}
+			if _, err := fmt.Fprintln(out, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package cst
+
+import (
+	"sort"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+// recorder keeps every token the parser pulls; embedding the lexer keeps its
+// diagnostics and declared operators visible to the parser
+type recorder struct {
+	*lexer.Lexer
+	tokens []token.Token
+}
+
+func (r *recorder) NextToken() token.Token {
+	tk := r.Lexer.NextToken()
+	r.tokens = append(r.tokens, tk)
+	return tk
+}
+
+// Parse builds the concrete tree of src along with the parser's errors;
+// String on the result gives back src exactly
+func Parse(src string) (*Node, []string) {
+	r := &recorder{Lexer: lexer.New(src)}
+
+	p := parser.New(r)
+	p.RecordSpans()
+
+	program := p.ParseProgram()
+
+	tks := tokens(src, r.tokens)
+
+	b := &builder{tokens: tks, spans: reachable(program, tks, p.Spans())}
+
+	root := b.node(parser.Span{Node: program, First: 0, Last: len(b.tokens) - 1})
+
+	return root, p.Errors()
+}
+
+func tokens(src string, pulled []token.Token) []*Token {
+	tokens := []*Token{}
+
+	end := 0
+
+	for _, tk := range pulled {
+		start := max(tk.Start().Offset, end)
+		stop := min(start+width(tk), len(src))
+
+		tokens = append(tokens, &Token{Token: tk, Leading: src[end:start], Text: src[start:stop]})
+
+		end = stop
+
+		if tk.Type == token.EOF {
+			break
+		}
+	}
+
+	return tokens
+}
+
+func width(tk token.Token) int {
+	switch tk.Type {
+	case token.String, token.StringEnd:
+		return len(tk.Literal()) + 2 // '"' and '"', or '}' and '"'
+	case token.StringStart, token.StringMiddle:
+		return len(tk.Literal()) + 3 // '"' or '}', and '${'
+	default:
+		return len(tk.Literal())
+	}
+}
+
+// reachable drops spans of nodes parsed on the way to an error, which are not
+// in the program, and adds spans for nodes the parser did not record, such as
+// names and parameters, covering their own token and their children
+func reachable(program ast.Node, tokens []*Token, recorded []parser.Span) []parser.Span {
+	byNode := map[ast.Node][]parser.Span{}
+	for _, span := range recorded {
+		byNode[span.Node] = append(byNode[span.Node], span)
+	}
+
+	byOffset := map[int]int{}
+	for i, tk := range tokens {
+		byOffset[tk.Start().Offset] = i
+	}
+
+	spans := []parser.Span{}
+
+	var visit func(node ast.Node) (int, int, bool)
+
+	visit = func(node ast.Node) (int, int, bool) {
+		first, last, ok := len(tokens), -1, false
+
+		for _, child := range tree.Children(node) {
+			if f, l, found := visit(child.Node); found {
+				first, last, ok = min(first, f), max(last, l), true
+			}
+		}
+
+		if known := byNode[node]; len(known) > 0 {
+			spans = append(spans, known...)
+			outer := known[len(known)-1]
+			return outer.First, outer.Last, true
+		}
+
+		if tk, isToken := tree.Token(node).(token.Token); isToken {
+			if i, found := byOffset[tk.Start().Offset]; found {
+				first, last, ok = min(first, i), max(last, i), true
+			}
+		}
+
+		if ok {
+			spans = append(spans, parser.Span{Node: node, First: first, Last: last})
+		}
+
+		return first, last, ok
+	}
+
+	for _, child := range tree.Children(program) {
+		visit(child.Node)
+	}
+
+	// children are added before their parents, so of two equal spans the
+	// later one is the parent and goes first
+	order := make([]int, len(spans))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.Slice(order, func(a, b int) bool {
+		sa, sb := spans[order[a]], spans[order[b]]
+		if sa.First != sb.First {
+			return sa.First < sb.First
+		}
+		if sa.Last != sb.Last {
+			return sa.Last > sb.Last
+		}
+		return order[a] > order[b]
+	})
+
+	sorted := make([]parser.Span, len(spans))
+	for i, j := range order {
+		sorted[i] = spans[j]
+	}
+
+	return sorted
+}
+
+type builder struct {
+	tokens []*Token
+	spans  []parser.Span
+	next   int
+}
+
+func (b *builder) node(span parser.Span) *Node {
+	n := &Node{Kind: tree.Kind(span.Node), Node: span.Node}
+
+	for i := span.First; i <= span.Last && i < len(b.tokens); {
+		// a span that starts inside a sibling or runs past its parent cannot
+		// nest, so its tokens stay with the parent
+		for b.next < len(b.spans) && (b.spans[b.next].First < i || b.spans[b.next].First == i && b.spans[b.next].Last > span.Last) {
+			b.next++
+		}
+
+		if b.next < len(b.spans) && b.spans[b.next].First == i {
+			child := b.spans[b.next]
+			b.next++
+
+			c := b.node(child)
+			if c.Node == n.Node {
+				n.Kind = GROUP
+			}
+
+			n.Children = append(n.Children, c)
+			i = child.Last + 1
+
+			continue
+		}
+
+		n.Children = append(n.Children, b.tokens[i])
+		i++
+	}
+
+	return n
+}
@@ -0,0 +1,285 @@
+package document
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+type Diagnostic struct {
+	Start   token.Position
+	End     token.Position
+	Message string
+}
+
+// operators is the table of declared operators after the chunk, shared with
+// the chunks before it when it declares none
+type chunk struct {
+	start     token.Position
+	statement statement.Statement
+	errors    []string
+	fresh     bool
+	operators token.Operators
+}
+
+func (c chunk) clean() bool {
+	return len(c.errors) == 0
+}
+
+// a statement that parsed cleanly from a fresh lexer closed everything it
+// opened, so the lexer is fresh again where the next one starts
+func freshAfter(chunks []chunk) bool {
+	if len(chunks) == 0 {
+		return true
+	}
+
+	last := chunks[len(chunks)-1]
+
+	return last.fresh && last.clean()
+}
+
+// declared is the table of operators a statement after chunks is read with
+func declared(chunks []chunk) token.Operators {
+	if len(chunks) == 0 {
+		return token.Operators{}
+	}
+
+	return chunks[len(chunks)-1].operators
+}
+
+// parsed makes the chunk of a statement the lexer has just read past
+func parsed(chunks []chunk, p parser.Parsed, l *lexer.Lexer) chunk {
+	operators := declared(chunks)
+	if !maps.Equal(operators, l.Operators()) {
+		operators = maps.Clone(l.Operators())
+	}
+
+	return chunk{start: p.Start, statement: p.Statement, errors: p.Errors, fresh: freshAfter(chunks), operators: operators}
+}
+
+type Document struct {
+	text   string
+	chunks []chunk
+}
+
+func (d *Document) Text() string {
+	return d.text
+}
+
+func (d *Document) Program() *statement.Program {
+	program := &statement.Program{Statements: []statement.Statement{}}
+
+	for _, c := range d.chunks {
+		if c.statement != nil {
+			program.Statements = append(program.Statements, c.statement)
+		}
+	}
+
+	return program
+}
+
+func (d *Document) Errors() []string {
+	errors := []string{}
+
+	for _, c := range d.chunks {
+		errors = append(errors, c.errors...)
+	}
+
+	return errors
+}
+
+func (d *Document) Diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+
+	for i, c := range d.chunks {
+		if c.clean() {
+			continue
+		}
+
+		end := len(d.text)
+		if i+1 < len(d.chunks) {
+			end = d.chunks[i+1].start.Offset
+		}
+
+		end = c.start.Offset + len(strings.TrimRight(d.text[c.start.Offset:end], " \t\r\n"))
+
+		for _, msg := range c.errors {
+			diagnostics = append(diagnostics, Diagnostic{Start: c.start, End: d.Position(end), Message: msg})
+		}
+	}
+
+	return diagnostics
+}
+
+func (d *Document) Apply(edits ...Edit) error {
+	for _, edit := range edits {
+		if err := d.apply(edit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Document) Position(offset int) token.Position {
+	offset = max(0, min(offset, len(d.text)))
+
+	before := d.text[:offset]
+
+	return token.Position{
+		Offset: offset,
+		Line:   strings.Count(before, "\n") + 1,
+		Column: offset - (strings.LastIndexByte(before, '\n') + 1) + 1,
+	}
+}
+
+func (d *Document) Offset(line, column int) (int, error) {
+	offset := 0
+
+	for l := 1; l < line; l++ {
+		next := strings.IndexByte(d.text[offset:], '\n')
+		if next < 0 {
+			return 0, fmt.Errorf("line %d is past the end of the document", line)
+		}
+		offset += next + 1
+	}
+
+	end := len(d.text)
+	if next := strings.IndexByte(d.text[offset:], '\n'); next >= 0 {
+		end = offset + next
+	}
+
+	if column < 1 || offset+column-1 > end {
+		return 0, fmt.Errorf("column %d is outside line %d", column, line)
+	}
+
+	return offset + column - 1, nil
+}
+
+func (d *Document) apply(edit Edit) error {
+	if edit.Start < 0 || edit.End < edit.Start || edit.End > len(d.text) {
+		return fmt.Errorf("edit range %d-%d is outside the document (length %d)", edit.Start, edit.End, len(d.text))
+	}
+
+	oldEnd := d.Position(edit.End)
+
+	d.text = d.text[:edit.Start] + edit.Text + d.text[edit.End:]
+
+	newEnd := d.Position(edit.Start + len(edit.Text))
+
+	old := d.chunks
+
+	// an edit can join a statement with the one before it, and parsing can
+	// only restart where the lexer was not inside an unfinished construct
+	r := 0
+	for i := range old {
+		if old[i].start.Offset > edit.Start {
+			break
+		}
+		r = i
+	}
+	r -= 1
+	for r > 0 && !old[r].fresh {
+		r -= 1
+	}
+	r = max(r, 0)
+
+	start := token.Position{Offset: 0, Line: 1, Column: 1}
+	if r > 0 {
+		start = old[r].start
+	}
+
+	chunks := append([]chunk{}, old[:r]...)
+
+	s := shift{
+		offset: newEnd.Offset - oldEnd.Offset,
+		lines:  newEnd.Line - oldEnd.Line,
+		line:   oldEnd.Line,
+		column: newEnd.Column - oldEnd.Column,
+	}
+
+	// old chunks that start after the edit are candidates for reuse
+	j := len(old)
+	for i := range old {
+		if old[i].start.Offset >= edit.End {
+			j = i
+			break
+		}
+	}
+
+	l := lexer.NewAt(d.text, start, maps.Clone(declared(chunks)))
+	p := parser.New(l)
+
+	for {
+		next, ok := p.Next()
+		if !ok {
+			break
+		}
+
+		for j < len(old) && old[j].start.Offset+s.offset < next.Start.Offset {
+			j++
+		}
+
+		fresh := freshAfter(chunks)
+
+		// declared operators change how everything after them is read, so an
+		// old statement is only kept if it is read with the same ones
+		if j < len(old) && old[j].start.Offset+s.offset == next.Start.Offset && fresh && old[j].fresh && maps.Equal(declared(chunks), declared(old[:j])) {
+			// error messages quote positions, so a statement with errors is
+			// parsed again rather than shifted
+			for j < len(old) && (old[j].clean() || s.unchanged()) {
+				c := old[j]
+				c.start = s.position(c.start)
+				if c.statement != nil {
+					s.node(c.statement)
+				}
+				chunks = append(chunks, c)
+				j++
+			}
+
+			if j == len(old) {
+				break
+			}
+
+			l = lexer.NewAt(d.text, s.position(old[j].start), maps.Clone(declared(chunks)))
+			p = parser.New(l)
+			j++
+
+			continue
+		}
+
+		chunks = append(chunks, parsed(chunks, next, l))
+	}
+
+	d.chunks = chunks
+
+	return nil
+}
+
+func New(text string) *Document {
+	d := &Document{text: text}
+
+	l := lexer.New(text)
+	p := parser.New(l)
+
+	for {
+		next, ok := p.Next()
+		if !ok {
+			break
+		}
+		d.chunks = append(d.chunks, parsed(d.chunks, next, l))
+	}
+
+	return d
+}
@@ -0,0 +1,135 @@
+package document
+
+import (
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
+	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	"github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
+	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+// shift moves positions that follow an edit; only the line the edit ended
+// on changes columns
+type shift struct {
+	offset int
+	lines  int
+	line   int
+	column int
+}
+
+func (s shift) position(pos token.Position) token.Position {
+	if pos.Line == s.line {
+		pos.Column += s.column
+	}
+
+	pos.Offset += s.offset
+	pos.Line += s.lines
+
+	return pos
+}
+
+func (s shift) token(tk ast.Token) ast.Token {
+	t, ok := tk.(token.Token)
+	if !ok {
+		return tk
+	}
+
+	t.Pos = s.position(t.Pos)
+
+	return t
+}
+
+func (s shift) unchanged() bool {
+	return s == shift{line: s.line}
+}
+
+func (s shift) node(node ast.Node) {
+	if s.unchanged() {
+		return
+	}
+
+	switch n := node.(type) {
+	case *block.Block:
+		n.Token = s.token(n.Token)
+	case *expressionstatement.Expression:
+		n.Token = s.token(n.Token)
+	case *let.Let:
+		n.Token = s.token(n.Token)
+	case *operator.Operator:
+		n.Token = s.token(n.Token)
+	case *returnstatement.Return:
+		n.Token = s.token(n.Token)
+	case *importstatement.Import:
+		n.Token = s.token(n.Token)
+	case *export.Export:
+		n.Token = s.token(n.Token)
+	case *identifier.Identifier:
+		n.Token = s.token(n.Token)
+	case *integer.Integer:
+		n.Token = s.token(n.Token)
+	case *boolean.Boolean:
+		n.Token = s.token(n.Token)
+	case *stringexpression.String:
+		n.Token = s.token(n.Token)
+	case *null.Null:
+		n.Token = s.token(n.Token)
+	case *array.Array:
+		n.Token = s.token(n.Token)
+	case *hash.Hash:
+		n.Token = s.token(n.Token)
+	case *prefixoperator.PrefixOperator:
+		n.Token = s.token(n.Token)
+	case *infixoperator.InfixOperator:
+		n.Token = s.token(n.Token)
+	case *pipeline.Pipeline:
+		n.Token = s.token(n.Token)
+	case *ifexpression.If:
+		n.Token = s.token(n.Token)
+	case *function.Function:
+		n.Token = s.token(n.Token)
+	case *call.Call:
+		n.Token = s.token(n.Token)
+	case *index.Index:
+		n.Token = s.token(n.Token)
+	case *field.Field:
+		n.Token = s.token(n.Token)
+	case *interpolation.Interpolation:
+		n.Token = s.token(n.Token)
+	case *interpolation.Embedded:
+		n.Token = s.token(n.Token)
+	case *annotation.Named:
+		n.Token = s.token(n.Token)
+	case *annotation.Array:
+		n.Token = s.token(n.Token)
+	case *annotation.Hash:
+		n.Token = s.token(n.Token)
+	case *annotation.Function:
+		n.Token = s.token(n.Token)
+	}
+
+	for _, child := range tree.Children(node) {
+		s.node(child.Node)
+	}
+}
@@ -0,0 +1,142 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/w-h-a/interpreter/internal/object"
+	arrayobj "github.com/w-h-a/interpreter/internal/object/array"
+	"github.com/w-h-a/interpreter/internal/object/builtin"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	hashobj "github.com/w-h-a/interpreter/internal/object/hash"
+	intobj "github.com/w-h-a/interpreter/internal/object/integer"
+	stringobject "github.com/w-h-a/interpreter/internal/object/string"
+	"github.com/w-h-a/interpreter/internal/symbol"
+)
+
+var builtins = map[string]*builtin.Builtin{
+	"len": {
+		Name: "len",
+		Fn: func(_ *environment.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments to len: want 1, got %d", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *stringobject.String:
+				return &intobj.Integer{Value: int64(len(arg.Value))}
+			case *arrayobj.Array:
+				return &intobj.Integer{Value: int64(len(arg.Elements))}
+			case *hashobj.Hash:
+				return &intobj.Integer{Value: int64(len(arg.Keys))}
+			default:
+				return newError("argument to len not supported: %s", args[0].Type())
+			}
+		},
+	},
+	"first": {
+		Name: "first",
+		Fn: func(_ *environment.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments to first: want 1, got %d", len(args))
+			}
+
+			arr, ok := args[0].(*arrayobj.Array)
+			if !ok {
+				return newError("argument to first must be ARRAY, got %s", args[0].Type())
+			}
+
+			if len(arr.Elements) == 0 {
+				return NULL
+			}
+
+			return arr.Elements[0]
+		},
+	},
+	"last": {
+		Name: "last",
+		Fn: func(_ *environment.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments to last: want 1, got %d", len(args))
+			}
+
+			arr, ok := args[0].(*arrayobj.Array)
+			if !ok {
+				return newError("argument to last must be ARRAY, got %s", args[0].Type())
+			}
+
+			if len(arr.Elements) == 0 {
+				return NULL
+			}
+
+			return arr.Elements[len(arr.Elements)-1]
+		},
+	},
+	"rest": {
+		Name: "rest",
+		Fn: func(_ *environment.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments to rest: want 1, got %d", len(args))
+			}
+
+			arr, ok := args[0].(*arrayobj.Array)
+			if !ok {
+				return newError("argument to rest must be ARRAY, got %s", args[0].Type())
+			}
+
+			if len(arr.Elements) == 0 {
+				return NULL
+			}
+
+			elements := make([]object.Object, len(arr.Elements)-1)
+			copy(elements, arr.Elements[1:])
+
+			return &arrayobj.Array{Elements: elements}
+		},
+	},
+	"push": {
+		Name: "push",
+		Fn: func(_ *environment.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments to push: want 2, got %d", len(args))
+			}
+
+			arr, ok := args[0].(*arrayobj.Array)
+			if !ok {
+				return newError("argument to push must be ARRAY, got %s", args[0].Type())
+			}
+
+			elements := make([]object.Object, len(arr.Elements), len(arr.Elements)+1)
+			copy(elements, arr.Elements)
+
+			return &arrayobj.Array{Elements: append(elements, args[1])}
+		},
+	},
+	"puts": {
+		Name: "puts",
+		Fn: func(env *environment.Environment, args ...object.Object) object.Object {
+			for _, arg := range args {
+				fmt.Fprintln(env.Output(), arg.Inspect())
+			}
+
+			return NULL
+		},
+	},
+}
+
+var builtinSymbols = map[symbol.Symbol]*builtin.Builtin{}
+
+func init() {
+	for name, b := range builtins {
+		builtinSymbols[symbol.Intern(name)] = b
+	}
+}
+
+func Builtins() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,33 +1,603 @@
 package evaluator
 
 import (
+	"strings"
+
 	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	arrayexp "github.com/w-h-a/interpreter/internal/ast/expression/array"
+	boolexp "github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	hashexp "github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
 	intexp "github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	nullexp "github.com/w-h-a/interpreter/internal/ast/expression/null"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
+	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
 	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
 	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
 	"github.com/w-h-a/interpreter/internal/object"
+	arrayobj "github.com/w-h-a/interpreter/internal/object/array"
+	boolobj "github.com/w-h-a/interpreter/internal/object/boolean"
+	"github.com/w-h-a/interpreter/internal/object/builtin"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	errorobject "github.com/w-h-a/interpreter/internal/object/error"
+	fnobj "github.com/w-h-a/interpreter/internal/object/function"
+	hashobj "github.com/w-h-a/interpreter/internal/object/hash"
 	intobj "github.com/w-h-a/interpreter/internal/object/integer"
+	moduleobj "github.com/w-h-a/interpreter/internal/object/module"
+	nullobj "github.com/w-h-a/interpreter/internal/object/null"
+	returnvalue "github.com/w-h-a/interpreter/internal/object/return_value"
+	stringobject "github.com/w-h-a/interpreter/internal/object/string"
+	"github.com/w-h-a/interpreter/internal/symbol"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+var (
+	NULL  = &nullobj.Null{}
+	TRUE  = &boolobj.Boolean{Value: true}
+	FALSE = &boolobj.Boolean{Value: false}
 )
 
-func Eval(node ast.Node) object.Object {
+func Eval(node ast.Node, env *environment.Environment) object.Object {
 	switch node := node.(type) {
 	case *statement.Program:
-		return evalStatements(node.Statements)
+		return evalProgram(node.Statements, env)
+	case *block.Block:
+		return evalBlockStatement(node.Statements, env)
 	case *expressionstatement.Expression:
-		return Eval(node.Expression)
+		return Eval(node.Expression, env)
+	case *returnstatement.Return:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return &returnvalue.ReturnValue{Value: val}
+	case *let.Let:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		bind(node.Name, val, env)
+	case *operator.Operator:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		bind(node.Name, val, env)
+	case *export.Export:
+		return Eval(node.Let, env)
+	case *importstatement.Import:
+		return evalImportStatement(node, env)
 	case *intexp.Integer:
 		return &intobj.Integer{Value: node.Value}
-	default:
-		return nil
+	case *boolexp.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *stringexpression.String:
+		return &stringobject.String{Value: node.Value}
+	case *interpolation.Interpolation:
+		return evalInterpolation(node, env)
+	case *interpolation.Embedded:
+		val := Eval(node.Expression, env)
+		if err, ok := val.(*errorobject.Error); ok {
+			return newError("%s at %s", err.Message, node.Token.Position())
+		}
+		return val
+	case *nullexp.Null:
+		return NULL
+	case *arrayexp.Array:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &arrayobj.Array{Elements: elements}
+	case *hashexp.Hash:
+		return evalHashLiteral(node, env)
+	case *prefixoperator.PrefixOperator:
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+	case *infixoperator.InfixOperator:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		if node.Operator == "??" {
+			return evalCoalesceExpression(left, node.Right, env)
+		}
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		if _, builtin := token.LookupOperator(node.Operator); !builtin {
+			return evalDeclaredOperator(node.Operator, left, right, env)
+		}
+		return evalInfixExpression(node.Operator, left, right)
+	case *ifexpression.If:
+		return evalIfExpression(node, env)
+	case *identifier.Identifier:
+		return evalIdentifier(node, env)
+	case *fnexp.Function:
+		return &fnobj.Function{
+			Name:       node.Name,
+			Parameters: node.Parameters,
+			Defaults:   node.Defaults,
+			Rest:       node.Rest,
+			Body:       node.Body,
+			Frame:      node.Frame,
+			Env:        env,
+		}
+	case *call.Call:
+		function := Eval(node.Function, env)
+		if isError(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		return applyFunction(function, args, env)
+	case *pipeline.Pipeline:
+		return evalPipelineExpression(node, env)
+	case *index.Index:
+		result, _ := evalChain(node, env)
+		return result
+	case *field.Field:
+		result, _ := evalChain(node, env)
+		return result
+	}
+
+	return nil
+}
+
+func evalProgram(stmts []statement.Statement, env *environment.Environment) object.Object {
+	var result object.Object
+
+	for _, stmt := range stmts {
+		result = Eval(stmt, env)
+
+		switch result := result.(type) {
+		case *returnvalue.ReturnValue:
+			return result.Value
+		case *errorobject.Error:
+			return result
+		}
 	}
+
+	return result
 }
 
-func evalStatements(stmts []statement.Statement) object.Object {
+func evalBlockStatement(stmts []statement.Statement, env *environment.Environment) object.Object {
 	var result object.Object
 
 	for _, stmt := range stmts {
-		result = Eval(stmt)
+		result = Eval(stmt, env)
+
+		if result != nil {
+			rt := result.Type()
+			if rt == object.RETURN_VALUE || rt == object.ERROR {
+				return result
+			}
+		}
 	}
 
 	return result
 }
+
+func evalImportStatement(node *importstatement.Import, env *environment.Environment) object.Object {
+	importer := env.Importer()
+	if importer == nil {
+		return newError("cannot import %q: imports are not supported here", node.Path.Value)
+	}
+
+	mod := importer.Import(node.Path.Value)
+	if isError(mod) {
+		return mod
+	}
+
+	bind(node.Alias, mod, env)
+
+	return nil
+}
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalBangOperatorExpression(right)
+	case "-":
+		return evalMinusPrefixOperatorExpression(right)
+	default:
+		return newError("unknown operator: %s%s", operator, right.Type())
+	}
+}
+
+func evalBangOperatorExpression(right object.Object) object.Object {
+	switch right {
+	case TRUE:
+		return FALSE
+	case FALSE:
+		return TRUE
+	case NULL:
+		return TRUE
+	default:
+		return FALSE
+	}
+}
+
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	if right.Type() != object.INTEGER {
+		return newError("unknown operator: -%s", right.Type())
+	}
+
+	value := right.(*intobj.Integer).Value
+
+	return &intobj.Integer{Value: -value}
+}
+
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER && right.Type() == object.INTEGER:
+		return evalIntegerInfixExpression(operator, left, right)
+	case left.Type() == object.STRING && right.Type() == object.STRING:
+		return evalStringInfixExpression(operator, left, right)
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*intobj.Integer).Value
+	rightVal := right.(*intobj.Integer).Value
+
+	switch operator {
+	case "+":
+		return &intobj.Integer{Value: leftVal + rightVal}
+	case "-":
+		return &intobj.Integer{Value: leftVal - rightVal}
+	case "*":
+		return &intobj.Integer{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %d / %d", leftVal, rightVal)
+		}
+		return &intobj.Integer{Value: leftVal / rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*stringobject.String).Value
+	rightVal := right.(*stringobject.String).Value
+
+	switch operator {
+	case "+":
+		return &stringobject.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalCoalesceExpression(left object.Object, right expression.Expression, env *environment.Environment) object.Object {
+	if left != NULL {
+		return left
+	}
+
+	return Eval(right, env)
+}
+
+func evalInterpolation(node *interpolation.Interpolation, env *environment.Environment) object.Object {
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		val := Eval(part, env)
+		if isError(val) {
+			return val
+		}
+
+		out.WriteString(val.Inspect())
+	}
+
+	return &stringobject.String{Value: out.String()}
+}
+
+func evalIfExpression(ie *ifexpression.If, env *environment.Environment) object.Object {
+	condition := Eval(ie.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return Eval(ie.Consequence, env)
+	} else if ie.Alternative != nil {
+		return Eval(ie.Alternative, env)
+	} else {
+		return NULL
+	}
+}
+
+func evalDeclaredOperator(operator string, left, right object.Object, env *environment.Environment) object.Object {
+	fn, ok := env.Get(operator)
+	if !ok {
+		return newError("operator not found: %s", operator)
+	}
+
+	return applyFunction(fn, []object.Object{left, right}, env)
+}
+
+func evalIdentifier(node *identifier.Identifier, env *environment.Environment) object.Object {
+	s := node.Symbol()
+
+	if val, ok := lookup(node, s, env); ok {
+		return val
+	}
+
+	if b, ok := builtinSymbols[s]; ok {
+		return b
+	}
+
+	return newError("identifier not found: %s", node.Value)
+}
+
+// lookup reads a name from where the resolver placed it, or by name when it
+// was not resolved
+func lookup(node *identifier.Identifier, s symbol.Symbol, env *environment.Environment) (object.Object, bool) {
+	if node.Resolved {
+		return env.GetSlot(node.Depth, node.Slot, s)
+	}
+	return env.GetSymbol(s)
+}
+
+func bind(name *identifier.Identifier, val object.Object, env *environment.Environment) object.Object {
+	if name.Resolved {
+		return env.SetSlot(name.Slot, name.Symbol(), val)
+	}
+	return env.SetSymbol(name.Symbol(), val)
+}
+
+func evalHashLiteral(node *hashexp.Hash, env *environment.Environment) object.Object {
+	result := hashobj.New()
+
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(pair.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		result.Set(hashKey, value)
+	}
+
+	return result
+}
+
+func evalChain(node expression.Expression, env *environment.Environment) (object.Object, bool) {
+	switch node := node.(type) {
+	case *index.Index:
+		left, short := evalChain(node.Left, env)
+		if short || isError(left) {
+			return left, short
+		}
+
+		if node.Optional && left == NULL {
+			return NULL, true
+		}
+
+		idx := Eval(node.Index, env)
+		if isError(idx) {
+			return idx, false
+		}
+
+		return evalIndexExpression(left, idx), false
+	case *field.Field:
+		left, short := evalChain(node.Left, env)
+		if short || isError(left) {
+			return left, short
+		}
+
+		if node.Optional && left == NULL {
+			return NULL, true
+		}
+
+		return evalFieldExpression(left, node.Field.Value), false
+	default:
+		return Eval(node, env), false
+	}
+}
+
+func evalIndexExpression(left, idx object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY && idx.Type() == object.INTEGER:
+		elements := left.(*arrayobj.Array).Elements
+		i := idx.(*intobj.Integer).Value
+
+		if i < 0 || i >= int64(len(elements)) {
+			return NULL
+		}
+
+		return elements[i]
+	case left.Type() == object.HASH:
+		key, ok := idx.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", idx.Type())
+		}
+
+		value, ok := left.(*hashobj.Hash).Get(key)
+		if !ok {
+			return NULL
+		}
+
+		return value
+	default:
+		return newError("index operator not supported: %s[%s]", left.Type(), idx.Type())
+	}
+}
+
+func evalFieldExpression(left object.Object, name string) object.Object {
+	switch left := left.(type) {
+	case *hashobj.Hash:
+		value, ok := left.Get(&stringobject.String{Value: name})
+		if !ok {
+			return NULL
+		}
+		return value
+	case *moduleobj.Module:
+		value, ok := left.Exports[name]
+		if !ok {
+			return newError("module %s does not export %s", left.Name, name)
+		}
+		return value
+	default:
+		return newError("field access not supported: %s.%s", left.Type(), name)
+	}
+}
+
+func evalExpressions(exps []expression.Expression, env *environment.Environment) []object.Object {
+	result := []object.Object{}
+
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+func evalPipelineExpression(pe *pipeline.Pipeline, env *environment.Environment) object.Object {
+	left := Eval(pe.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	callee := pe.Right
+	args := []object.Object{left}
+
+	if c, ok := pe.Right.(*call.Call); ok {
+		callee = c.Function
+
+		rest := evalExpressions(c.Arguments, env)
+		if len(rest) == 1 && isError(rest[0]) {
+			return rest[0]
+		}
+
+		args = append(args, rest...)
+	}
+
+	function := Eval(callee, env)
+	if isError(function) {
+		return function
+	}
+
+	if rt := function.Type(); rt != object.FUNCTION && rt != object.BUILTIN {
+		return newError("not a function: %s in %s", function.Type(), pe.String())
+	}
+
+	return applyFunction(function, args, env)
+}
+
+func applyFunction(fn object.Object, args []object.Object, env *environment.Environment) object.Object {
+	switch function := fn.(type) {
+	case *fnobj.Function:
+		extendedEnv, err := extendFunctionEnv(function, args)
+		if err != nil {
+			return err
+		}
+
+		evaluated := Eval(function.Body, extendedEnv)
+
+		return unwrapReturnValue(evaluated)
+	case *builtin.Builtin:
+		return function.Fn(env, args...)
+	default:
+		return newError("not a function: %s", fn.Type())
+	}
+}
+
+func extendFunctionEnv(fn *fnobj.Function, args []object.Object) (*environment.Environment, object.Object) {
+	required := fn.Required()
+
+	if len(args) < required || (fn.Rest == nil && len(args) > len(fn.Parameters)) {
+		return nil, newError("wrong number of arguments to %s: want %s, got %d", fn.DisplayName(), fn.Arity(), len(args))
+	}
+
+	env := environment.NewFrame(fn.Env, fn.Frame)
+
+	for i, param := range fn.Parameters {
+		if i < len(args) {
+			bind(param, args[i], env)
+			continue
+		}
+
+		val := Eval(fn.Defaults[i], env)
+		if isError(val) {
+			return nil, val
+		}
+
+		bind(param, val, env)
+	}
+
+	if fn.Rest != nil {
+		rest := []object.Object{}
+
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+
+		bind(fn.Rest, &arrayobj.Array{Elements: rest}, env)
+	}
+
+	return env, nil
+}
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*returnvalue.ReturnValue); ok {
+		return returnValue.Value
+	}
+
+	return obj
+}
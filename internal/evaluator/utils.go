@@ -0,0 +1,40 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/w-h-a/interpreter/internal/object"
+	boolobj "github.com/w-h-a/interpreter/internal/object/boolean"
+	errorobject "github.com/w-h-a/interpreter/internal/object/error"
+)
+
+func nativeBoolToBooleanObject(input bool) *boolobj.Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL:
+		return false
+	case TRUE:
+		return true
+	case FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+func isError(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ERROR
+	}
+	return false
+}
+
+func newError(format string, a ...any) *errorobject.Error {
+	return &errorobject.Error{Message: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,281 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
+	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+const INDENT = "  "
+
+// a block with one expression this short stays on the line it opens
+const INLINE_WIDTH = 40
+
+type formatter struct {
+	operators token.Operators
+}
+
+// Format prints program in the canonical layout: a statement per line, blocks
+// indented, and only the parentheses the operators need
+func Format(program *statement.Program) string {
+	f := &formatter{operators: token.Operators{}}
+
+	f.declare(program)
+
+	var out strings.Builder
+
+	for _, stmt := range program.Statements {
+		out.WriteString(f.statement(stmt, 0))
+		out.WriteString(";\n")
+	}
+
+	return out.String()
+}
+
+// declare collects the fixity of every declared operator
+func (f *formatter) declare(node ast.Node) {
+	if op, ok := node.(*operator.Operator); ok {
+		if tk, ok := op.Token.(token.Token); ok {
+			f.operators[op.Name.Value] = token.Fixity{Precedence: int(op.Precedence.Value), Associativity: tk.Type}
+		}
+	}
+
+	for _, child := range tree.Children(node) {
+		f.declare(child.Node)
+	}
+}
+
+func (f *formatter) statement(stmt statement.Statement, depth int) string {
+	switch stmt := stmt.(type) {
+	case *let.Let:
+		out := "let " + stmt.Name.Value
+		if stmt.Type != nil {
+			out += ": " + stmt.Type.String()
+		}
+		return out + " = " + f.expression(stmt.Value, depth)
+	case *export.Export:
+		return "export " + f.statement(stmt.Let, depth)
+	case *operator.Operator:
+		return stmt.TokenLiteral() + " " + stmt.Precedence.String() + " " + stmt.Name.Value + " = " + f.expression(stmt.Value, depth)
+	case *returnstatement.Return:
+		if stmt.Value == nil {
+			return "return"
+		}
+		return "return " + f.expression(stmt.Value, depth)
+	case *importstatement.Import:
+		return "import " + stmt.Path.String() + " as " + stmt.Alias.Value
+	case *expressionstatement.Expression:
+		return f.expression(stmt.Expression, depth)
+	default:
+		return stmt.String()
+	}
+}
+
+// block ends every statement but the last with a semicolon; the closing
+// brace ends the last
+func (f *formatter) block(b *block.Block, depth int) string {
+	if len(b.Statements) == 0 {
+		return "{}"
+	}
+
+	if len(b.Statements) == 1 {
+		if stmt, ok := b.Statements[0].(*expressionstatement.Expression); ok {
+			inline := f.expression(stmt.Expression, depth)
+			if len(inline) <= INLINE_WIDTH && !strings.Contains(inline, "\n") {
+				return "{ " + inline + " }"
+			}
+		}
+	}
+
+	indent := strings.Repeat(INDENT, depth+1)
+
+	var out strings.Builder
+
+	out.WriteString("{\n")
+
+	for i, stmt := range b.Statements {
+		out.WriteString(indent)
+		out.WriteString(f.statement(stmt, depth+1))
+		if _, isExpression := stmt.(*expressionstatement.Expression); !isExpression || i < len(b.Statements)-1 {
+			out.WriteString(";")
+		}
+		out.WriteString("\n")
+	}
+
+	out.WriteString(strings.Repeat(INDENT, depth))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (f *formatter) expressions(exps []expression.Expression, depth int) string {
+	formatted := []string{}
+	for _, exp := range exps {
+		formatted = append(formatted, f.expression(exp, depth))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func (f *formatter) expression(exp expression.Expression, depth int) string {
+	switch exp := exp.(type) {
+	case nil:
+		return ""
+	case *stringexpression.String:
+		return exp.String()
+	case *interpolation.Interpolation:
+		var out strings.Builder
+		out.WriteString("\"")
+		for _, part := range exp.Parts {
+			switch part := part.(type) {
+			case *stringexpression.String:
+				out.WriteString(part.TokenLiteral())
+			case *interpolation.Embedded:
+				out.WriteString("${" + f.expression(part.Expression, depth) + "}")
+			}
+		}
+		out.WriteString("\"")
+		return out.String()
+	case *array.Array:
+		return "[" + f.expressions(exp.Elements, depth) + "]"
+	case *hash.Hash:
+		pairs := []string{}
+		for _, pair := range exp.Pairs {
+			pairs = append(pairs, f.expression(pair.Key, depth)+": "+f.expression(pair.Value, depth))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	case *prefixoperator.PrefixOperator:
+		return exp.Operator + f.operand(exp.Right, depth)
+	case *infixoperator.InfixOperator:
+		fixity := f.fixity(exp)
+		return f.side(exp.Left, fixity, token.InfixL, depth) + " " + exp.Operator + " " + f.side(exp.Right, fixity, token.InfixR, depth)
+	case *pipeline.Pipeline:
+		fixity := token.Fixity{Precedence: parser.PIPE, Associativity: token.InfixL}
+		return f.side(exp.Left, fixity, token.InfixL, depth) + " |> " + f.side(exp.Right, fixity, token.InfixR, depth)
+	case *ifexpression.If:
+		out := "if (" + f.expression(exp.Condition, depth) + ") " + f.block(exp.Consequence, depth)
+		if exp.Alternative != nil {
+			out += " else " + f.block(exp.Alternative, depth)
+		}
+		return out
+	case *fnexp.Function:
+		return f.function(exp, depth)
+	case *call.Call:
+		return f.operand(exp.Function, depth) + "(" + f.expressions(exp.Arguments, depth) + ")"
+	case *index.Index:
+		if exp.Optional {
+			return f.operand(exp.Left, depth) + "?.[" + f.expression(exp.Index, depth) + "]"
+		}
+		return f.operand(exp.Left, depth) + "[" + f.expression(exp.Index, depth) + "]"
+	case *field.Field:
+		if exp.Optional {
+			return f.operand(exp.Left, depth) + "?." + exp.Field.Value
+		}
+		return f.operand(exp.Left, depth) + "." + exp.Field.Value
+	default:
+		return exp.String()
+	}
+}
+
+func (f *formatter) function(fn *fnexp.Function, depth int) string {
+	params := []string{}
+
+	for i, p := range fn.Parameters {
+		param := p.Value
+		if i < len(fn.Types) && fn.Types[i] != nil {
+			param += ": " + fn.Types[i].String()
+		}
+		if i < len(fn.Defaults) && fn.Defaults[i] != nil {
+			param += " = " + f.expression(fn.Defaults[i], depth)
+		}
+		params = append(params, param)
+	}
+
+	if fn.Rest != nil {
+		rest := ".." + fn.Rest.Value
+		if fn.RestType != nil {
+			rest += ": " + fn.RestType.String()
+		}
+		params = append(params, rest)
+	}
+
+	out := "fn(" + strings.Join(params, ", ") + ") "
+	if fn.Result != nil {
+		out += "-> " + fn.Result.String() + " "
+	}
+
+	return out + f.block(fn.Body, depth)
+}
+
+func (f *formatter) fixity(exp *infixoperator.InfixOperator) token.Fixity {
+	if fixity, ok := f.operators[exp.Operator]; ok {
+		return fixity
+	}
+
+	if tk, ok := exp.Token.(token.Token); ok {
+		if prec, ok := parser.Precedence(tk.Type); ok {
+			return token.Fixity{Precedence: prec, Associativity: token.InfixL}
+		}
+	}
+
+	return token.Fixity{Precedence: parser.LOWEST}
+}
+
+// side parenthesizes an operand that binds more loosely than its operator,
+// or as tightly unless both group the same way and towards that side; a
+// non-associative operator groups neither way
+func (f *formatter) side(exp expression.Expression, parent token.Fixity, grouping token.TokenType, depth int) string {
+	formatted := f.expression(exp, depth)
+
+	var child token.Fixity
+
+	switch exp := exp.(type) {
+	case *infixoperator.InfixOperator:
+		child = f.fixity(exp)
+	case *pipeline.Pipeline:
+		child = token.Fixity{Precedence: parser.PIPE, Associativity: token.InfixL}
+	default:
+		return formatted
+	}
+
+	if child.Precedence < parent.Precedence || child.Precedence == parent.Precedence && (parent.Associativity != grouping || child.Associativity != grouping) {
+		return "(" + formatted + ")"
+	}
+
+	return formatted
+}
+
+// operand parenthesizes anything looser than a call or an index
+func (f *formatter) operand(exp expression.Expression, depth int) string {
+	formatted := f.expression(exp, depth)
+
+	switch exp.(type) {
+	case *infixoperator.InfixOperator, *pipeline.Pipeline, *prefixoperator.PrefixOperator, *ifexpression.If:
+		return "(" + formatted + ")"
+	default:
+		return formatted
+	}
+}
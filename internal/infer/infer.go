@@ -0,0 +1,716 @@
+package infer
+
+import (
+	"fmt"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
+	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
+	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// Binding is a name bound at the top level with its principal type
+type Binding struct {
+	Name   *identifier.Identifier
+	Scheme *Scheme
+}
+
+func (b Binding) String() string {
+	return b.Name.Value + " : " + b.Scheme.String()
+}
+
+type inferrer struct {
+	next int
+	// the result type of each function being inferred, innermost last
+	results []Type
+}
+
+// Infer runs Algorithm W over program and gives the principal type of each
+// top-level binding, or the first type error
+func Infer(program *statement.Program) ([]Binding, error) {
+	in := &inferrer{}
+
+	bindings := []Binding{}
+
+	e := in.builtins()
+
+	// every scheme at the top level is closed, so what a statement's
+	// substitution says of its variables matters to none after it
+	for _, stmt := range program.Statements {
+		_, _, e1, err := in.statement(e, stmt)
+		if err != nil {
+			return nil, err
+		}
+
+		e = e1
+
+		if name := boundName(stmt); name != nil {
+			scheme, _ := e.lookup(name.Value)
+			bindings = append(bindings, Binding{Name: name, Scheme: scheme})
+		}
+	}
+
+	return bindings, nil
+}
+
+func boundName(stmt statement.Statement) *identifier.Identifier {
+	switch stmt := stmt.(type) {
+	case *let.Let:
+		return stmt.Name
+	case *export.Export:
+		return stmt.Let.Name
+	case *operator.Operator:
+		return stmt.Name
+	default:
+		return nil
+	}
+}
+
+func (in *inferrer) fresh(pos token.Position) *Var {
+	in.next++
+	return &Var{ID: in.next, Pos: pos}
+}
+
+func (in *inferrer) builtins() *env {
+	var zero token.Position
+
+	a := in.fresh(zero)
+	b := in.fresh(zero)
+	c := in.fresh(zero)
+	d := in.fresh(zero)
+	f := in.fresh(zero)
+	g := in.fresh(zero)
+
+	array := func(t Type) Type { return con(ARRAY, zero, t) }
+
+	var e *env
+
+	e = e.extend("len", &Scheme{Vars: []int{a.ID}, Type: function([]Type{a}, con(INT, zero), zero)})
+	e = e.extend("first", &Scheme{Vars: []int{b.ID}, Type: function([]Type{array(b)}, b, zero)})
+	e = e.extend("last", &Scheme{Vars: []int{c.ID}, Type: function([]Type{array(c)}, c, zero)})
+	e = e.extend("rest", &Scheme{Vars: []int{d.ID}, Type: function([]Type{array(d)}, array(d), zero)})
+	e = e.extend("push", &Scheme{Vars: []int{f.ID}, Type: function([]Type{array(f), f}, array(f), zero)})
+	e = e.extend("puts", &Scheme{Vars: []int{g.ID}, Type: function([]Type{g}, con(NULL, zero), zero)})
+
+	return e
+}
+
+func (in *inferrer) instantiate(scheme *Scheme, pos token.Position) Type {
+	s := subst{}
+	for _, id := range scheme.Vars {
+		s[id] = in.fresh(pos)
+	}
+	return s.apply(scheme.Type)
+}
+
+// unify finds the substitution that makes a and b equal; at is the node whose
+// inference needed them to be
+func (in *inferrer) unify(a, b Type, at ast.Node) (subst, error) {
+	switch a := a.(type) {
+	case *Var:
+		return in.bind(a, b, at)
+	case *Con:
+		if v, ok := b.(*Var); ok {
+			return in.bind(v, a, at)
+		}
+
+		c := b.(*Con)
+		if a.Name != c.Name || len(a.Args) != len(c.Args) {
+			return nil, in.mismatch(a, c, at)
+		}
+
+		s := subst{}
+		for i := range a.Args {
+			s1, err := in.unify(s.apply(a.Args[i]), s.apply(c.Args[i]), at)
+			if err != nil {
+				return nil, err
+			}
+			s = s1.compose(s)
+		}
+
+		return s, nil
+	default:
+		return nil, fmt.Errorf("cannot unify %T", a)
+	}
+}
+
+func (in *inferrer) bind(v *Var, t Type, at ast.Node) (subst, error) {
+	if other, ok := t.(*Var); ok && other.ID == v.ID {
+		return subst{}, nil
+	}
+
+	if occurs(v.ID, t) {
+		n := newNamer()
+		return nil, in.errorf(at, "infinite type: %s occurs in %s", n.name(v), n.name(t))
+	}
+
+	return subst{v.ID: t}, nil
+}
+
+// mismatch points at both places the conflicting types came from
+func (in *inferrer) mismatch(a, b *Con, at ast.Node) error {
+	n := newNamer()
+	return in.errorf(at, "type mismatch: %s%s conflicts with %s%s", n.name(a), from(a), n.name(b), from(b))
+}
+
+func from(t Type) string {
+	if pos := t.Position(); pos.Line > 0 {
+		return fmt.Sprintf(" from %s", pos)
+	}
+	return " from a builtin"
+}
+
+func (in *inferrer) errorf(at ast.Node, format string, a ...any) error {
+	return &Error{Pos: tree.Token(at).Position(), Message: fmt.Sprintf(format, a...)}
+}
+
+func position(node ast.Node) token.Position {
+	return tree.Token(node).Position()
+}
+
+// statement infers stmt in e and gives the scope after it along with the type
+// of the value a block ending in it evaluates to
+func (in *inferrer) statement(e *env, stmt statement.Statement) (subst, Type, *env, error) {
+	switch stmt := stmt.(type) {
+	case *let.Let:
+		return in.let(e, stmt.Name, stmt.Type, stmt.Value, stmt)
+	case *export.Export:
+		return in.let(e, stmt.Let.Name, stmt.Let.Type, stmt.Let.Value, stmt)
+	case *operator.Operator:
+		return in.let(e, stmt.Name, nil, stmt.Value, stmt)
+	case *returnstatement.Return:
+		var value Type = con(NULL, position(stmt))
+		s := subst{}
+
+		if stmt.Value != nil {
+			var err error
+			if s, value, err = in.expression(e, stmt.Value); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+
+		if len(in.results) > 0 {
+			s1, err := in.unify(s.apply(in.results[len(in.results)-1]), value, stmt)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			s = s1.compose(s)
+		}
+
+		// nothing after a return sees its value, so it fits any type
+		return s, in.fresh(position(stmt)), s.applyEnv(e), nil
+	case *expressionstatement.Expression:
+		s, t, err := in.expression(e, stmt.Expression)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return s, t, s.applyEnv(e), nil
+	default:
+		return nil, nil, nil, in.errorf(stmt, "cannot infer the type of %s", tree.Kind(stmt))
+	}
+}
+
+// let generalizes the value's type over what the scope does not fix; a
+// function may call itself, at the one type it is being given
+func (in *inferrer) let(e *env, name *identifier.Identifier, a annotation.Annotation, value expression.Expression, stmt statement.Statement) (subst, Type, *env, error) {
+	inner := e
+
+	self := in.fresh(name.Token.Position())
+	if _, ok := value.(*fnexp.Function); ok {
+		inner = e.extend(name.Value, &Scheme{Type: self})
+	}
+
+	s, t, err := in.expression(inner, value)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	s1, err := in.unify(s.apply(self), t, value)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	s = s1.compose(s)
+
+	if a != nil {
+		s2, err := in.unify(in.annotation(a), s.apply(t), value)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		s = s2.compose(s)
+	}
+
+	e = s.applyEnv(e)
+
+	return s, con(NULL, position(stmt)), e.extend(name.Value, generalize(e, s.apply(t))), nil
+}
+
+// annotation is the type an annotation stands for, with a fresh variable for
+// each any
+func (in *inferrer) annotation(a annotation.Annotation) Type {
+	pos := position(a)
+
+	switch a := a.(type) {
+	case *annotation.Named:
+		if a.Name == annotation.ANY {
+			return in.fresh(pos)
+		}
+		return con(a.Name, pos)
+	case *annotation.Array:
+		return con(ARRAY, pos, in.annotation(a.Element))
+	case *annotation.Hash:
+		return con(HASH, pos, in.annotation(a.Key), in.annotation(a.Value))
+	case *annotation.Function:
+		params := []Type{}
+		for _, p := range a.Parameters {
+			params = append(params, in.annotation(p))
+		}
+		return function(params, in.annotation(a.Result), pos)
+	default:
+		return in.fresh(pos)
+	}
+}
+
+// block infers the statements of a block in a scope of their own and gives
+// the type of the last one's value
+func (in *inferrer) block(e *env, b *block.Block) (subst, Type, error) {
+	s := subst{}
+	var t Type = con(NULL, position(b))
+
+	for _, stmt := range b.Statements {
+		s1, t1, e1, err := in.statement(s.applyEnv(e), stmt)
+		if err != nil {
+			return nil, nil, err
+		}
+		s, t, e = s1.compose(s), t1, e1
+	}
+
+	return s, t, nil
+}
+
+func (in *inferrer) expression(e *env, exp expression.Expression) (subst, Type, error) {
+	pos := position(exp)
+
+	switch exp := exp.(type) {
+	case *integer.Integer:
+		return subst{}, con(INT, pos), nil
+	case *boolean.Boolean:
+		return subst{}, con(BOOL, pos), nil
+	case *stringexpression.String:
+		return subst{}, con(STRING, pos), nil
+	case *null.Null:
+		return subst{}, con(NULL, pos), nil
+	case *interpolation.Interpolation:
+		s := subst{}
+		for _, part := range exp.Parts {
+			if embedded, ok := part.(*interpolation.Embedded); ok {
+				s1, _, err := in.expression(s.applyEnv(e), embedded.Expression)
+				if err != nil {
+					return nil, nil, err
+				}
+				s = s1.compose(s)
+			}
+		}
+		return s, con(STRING, pos), nil
+	case *identifier.Identifier:
+		scheme, ok := e.lookup(exp.Value)
+		if !ok {
+			return nil, nil, in.errorf(exp, "undefined: %s", exp.Value)
+		}
+		return subst{}, in.instantiate(scheme, pos), nil
+	case *array.Array:
+		element := in.fresh(pos)
+		s, err := in.all(e, exp.Elements, element)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, con(ARRAY, pos, s.apply(element)), nil
+	case *hash.Hash:
+		keys, values := []expression.Expression{}, []expression.Expression{}
+		for _, pair := range exp.Pairs {
+			keys, values = append(keys, pair.Key), append(values, pair.Value)
+		}
+		key, value := in.fresh(pos), in.fresh(pos)
+		s, err := in.all(e, keys, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		s1, err := in.all(s.applyEnv(e), values, s.apply(value))
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s1.compose(s)
+		return s, con(HASH, pos, s.apply(key), s.apply(value)), nil
+	case *prefixoperator.PrefixOperator:
+		s, t, err := in.expression(e, exp.Right)
+		if err != nil {
+			return nil, nil, err
+		}
+		if exp.Operator == "!" {
+			return s, con(BOOL, pos), nil
+		}
+		s1, err := in.unify(con(INT, pos), t, exp)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s1.compose(s), con(INT, pos), nil
+	case *infixoperator.InfixOperator:
+		return in.infix(e, exp)
+	case *ifexpression.If:
+		return in.ifExpression(e, exp)
+	case *fnexp.Function:
+		return in.function(e, exp)
+	case *call.Call:
+		return in.call(e, exp.Function, exp.Function.String(), exp.Arguments, exp)
+	case *pipeline.Pipeline:
+		callee, args := exp.Right, []expression.Expression{exp.Left}
+		if callExp, ok := exp.Right.(*call.Call); ok {
+			callee, args = callExp.Function, append(args, callExp.Arguments...)
+		}
+		return in.call(e, callee, callee.String(), args, exp)
+	case *index.Index:
+		return in.index(e, exp.Left, exp.Index, exp)
+	case *field.Field:
+		return in.index(e, exp.Left, &stringexpression.String{Token: exp.Field.Token, Value: exp.Field.Value}, exp)
+	default:
+		return nil, nil, in.errorf(exp, "cannot infer the type of %s", tree.Kind(exp))
+	}
+}
+
+// all unifies the type of each expression with t
+func (in *inferrer) all(e *env, exps []expression.Expression, t Type) (subst, error) {
+	s := subst{}
+
+	for _, exp := range exps {
+		s1, t1, err := in.expression(s.applyEnv(e), exp)
+		if err != nil {
+			return nil, err
+		}
+		s = s1.compose(s)
+
+		s2, err := in.unify(s.apply(t), t1, exp)
+		if err != nil {
+			return nil, err
+		}
+		s = s2.compose(s)
+	}
+
+	return s, nil
+}
+
+func (in *inferrer) infix(e *env, exp *infixoperator.InfixOperator) (subst, Type, error) {
+	pos := position(exp)
+
+	if _, builtin := token.LookupOperator(exp.Operator); !builtin {
+		callee := &identifier.Identifier{Token: exp.Token, Value: exp.Operator}
+		return in.call(e, callee, exp.Operator, []expression.Expression{exp.Left, exp.Right}, exp)
+	}
+
+	s, left, err := in.expression(e, exp.Left)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s1, right, err := in.expression(s.applyEnv(e), exp.Right)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s1.compose(s)
+	left = s.apply(left)
+
+	var operand, result Type
+
+	switch exp.Operator {
+	case "==", "!=":
+		operand, result = left, con(BOOL, pos)
+	case "??":
+		// a null on the left always gives way to the right
+		if c, ok := left.(*Con); ok && c.Name == NULL {
+			return s, s.apply(right), nil
+		}
+		operand, result = left, left
+	case "<", ">":
+		operand, result = con(INT, pos), con(BOOL, pos)
+	case "+":
+		// strings join and ints add; an operand already known to be a string
+		// makes it a join
+		operand = con(INT, pos)
+		if isString(left) || isString(right) {
+			operand = con(STRING, pos)
+		}
+		result = operand
+	default:
+		operand, result = con(INT, pos), con(INT, pos)
+	}
+
+	for _, side := range []struct {
+		t    Type
+		node expression.Expression
+	}{{left, exp.Left}, {right, exp.Right}} {
+		s2, err := in.unify(s.apply(operand), s.apply(side.t), side.node)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s2.compose(s)
+	}
+
+	return s, s.apply(result), nil
+}
+
+func isString(t Type) bool {
+	c, ok := t.(*Con)
+	return ok && c.Name == STRING
+}
+
+// ifExpression leaves the condition unconstrained, as any value is truthy or
+// not; without an alternative the if may be null, so both branches must be
+func (in *inferrer) ifExpression(e *env, exp *ifexpression.If) (subst, Type, error) {
+	s, _, err := in.expression(e, exp.Condition)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s1, consequence, err := in.block(s.applyEnv(e), exp.Consequence)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s1.compose(s)
+
+	var alternative Type = con(NULL, position(exp))
+	var at ast.Node = exp
+
+	if exp.Alternative != nil {
+		s2, t, err := in.block(s.applyEnv(e), exp.Alternative)
+		if err != nil {
+			return nil, nil, err
+		}
+		s, alternative, at = s2.compose(s), t, exp.Alternative
+	}
+
+	s3, err := in.unify(s.apply(consequence), alternative, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s3.compose(s)
+
+	return s, s.apply(consequence), nil
+}
+
+func (in *inferrer) function(e *env, fn *fnexp.Function) (subst, Type, error) {
+	pos := position(fn)
+
+	s := subst{}
+	params := []Type{}
+	inner := e
+	optional := 0
+
+	// a default is of the type of its parameter, and sees the parameters
+	// before it
+	for i, param := range fn.Parameters {
+		var t Type = in.fresh(param.Token.Position())
+		if i < len(fn.Types) && fn.Types[i] != nil {
+			t = in.annotation(fn.Types[i])
+		}
+
+		if i < len(fn.Defaults) && fn.Defaults[i] != nil {
+			s1, d, err := in.expression(s.applyEnv(inner), fn.Defaults[i])
+			if err != nil {
+				return nil, nil, err
+			}
+			s = s1.compose(s)
+
+			s2, err := in.unify(s.apply(t), d, fn.Defaults[i])
+			if err != nil {
+				return nil, nil, err
+			}
+			s = s2.compose(s)
+
+			optional++
+		} else {
+			optional = 0
+		}
+
+		params = append(params, t)
+		inner = inner.extend(param.Value, &Scheme{Type: t})
+	}
+
+	if fn.Rest != nil {
+		var t Type = con(ARRAY, position(fn.Rest), in.fresh(position(fn.Rest)))
+		if fn.RestType != nil {
+			s1, err := in.unify(in.annotation(fn.RestType), s.apply(t), fn.RestType)
+			if err != nil {
+				return nil, nil, err
+			}
+			s = s1.compose(s)
+		}
+
+		params = append(params, t)
+		inner = inner.extend(fn.Rest.Value, &Scheme{Type: t})
+	}
+
+	var result Type = in.fresh(pos)
+	if fn.Result != nil {
+		result = in.annotation(fn.Result)
+	}
+
+	in.results = append(in.results, result)
+	defer func() { in.results = in.results[:len(in.results)-1] }()
+
+	s1, body, err := in.block(s.applyEnv(inner), fn.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s1.compose(s)
+
+	var at ast.Node = fn.Body
+	if last := len(fn.Body.Statements) - 1; last >= 0 {
+		at = fn.Body.Statements[last]
+	}
+
+	s2, err := in.unify(s.apply(result), body, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s2.compose(s)
+
+	for i := range params {
+		params[i] = s.apply(params[i])
+	}
+
+	t := function(params, s.apply(result), pos)
+	t.Optional, t.Variadic = optional, fn.Rest != nil
+
+	return s, t, nil
+}
+
+func (in *inferrer) call(e *env, callee expression.Expression, name string, args []expression.Expression, at ast.Node) (subst, Type, error) {
+	s, fn, err := in.expression(e, callee)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	types := []Type{}
+
+	for _, arg := range args {
+		s1, t, err := in.expression(s.applyEnv(e), arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s1.compose(s)
+		types = append(types, t)
+	}
+
+	fn = s.apply(fn)
+
+	var params []Type
+	var result Type
+
+	if c, ok := fn.(*Con); ok {
+		if c.Name != FUNCTION {
+			return nil, nil, in.errorf(callee, "cannot call %s of type %s%s", name, newNamer().name(c), from(c))
+		}
+
+		if !c.accepts(len(args)) {
+			return nil, nil, in.errorf(callee, "wrong number of arguments to %s: want %s, got %d", name, c.arity(), len(args))
+		}
+
+		params, result = c.parameters(len(args)), c.Args[len(c.Args)-1]
+	} else {
+		// a callee not known to be a function is one of exactly these
+		// arguments
+		result = in.fresh(position(at))
+		for range args {
+			params = append(params, in.fresh(position(at)))
+		}
+
+		s1, err := in.unify(fn, function(params, result, position(at)), callee)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s1.compose(s)
+	}
+
+	// unify each argument on its own, so a mismatch points at it
+
+	for i, arg := range args {
+		s2, err := in.unify(s.apply(params[i]), s.apply(types[i]), arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = s2.compose(s)
+	}
+
+	return s, s.apply(result), nil
+}
+
+// index looks into a hash for a field, or when left is known to be one, and
+// into an array otherwise
+func (in *inferrer) index(e *env, left, idx expression.Expression, at ast.Node) (subst, Type, error) {
+	pos := position(at)
+
+	s, container, err := in.expression(e, left)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s1, key, err := in.expression(s.applyEnv(e), idx)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s1.compose(s)
+
+	element := in.fresh(pos)
+
+	var want, wantKey Type = con(ARRAY, pos, element), con(INT, pos)
+	_, isField := at.(*field.Field)
+	if c, ok := s.apply(container).(*Con); isField || ok && c.Name == HASH {
+		wantKey = in.fresh(pos)
+		want = con(HASH, pos, wantKey, element)
+	}
+
+	s2, err := in.unify(want, s.apply(container), left)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s2.compose(s)
+
+	s3, err := in.unify(s.apply(wantKey), s.apply(key), idx)
+	if err != nil {
+		return nil, nil, err
+	}
+	s = s3.compose(s)
+
+	return s, s.apply(element), nil
+}
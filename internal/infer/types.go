@@ -0,0 +1,328 @@
+package infer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+const (
+	INT      = annotation.INT
+	STRING   = annotation.STRING
+	BOOL     = annotation.BOOL
+	NULL     = annotation.NULL
+	ARRAY    = "array"
+	HASH     = "hash"
+	FUNCTION = "fn"
+)
+
+// Type is a type variable or a constructor applied to types; Pos is where
+// the type came from in the source, to point errors at
+type Type interface {
+	Position() token.Position
+}
+
+type Var struct {
+	ID  int
+	Pos token.Position
+}
+
+func (v *Var) Position() token.Position {
+	return v.Pos
+}
+
+// Con is a named type; an array has its element as its one argument, a hash
+// its key and value, and a function its parameters followed by its result
+type Con struct {
+	Name string
+	Args []Type
+	Pos  token.Position
+	// of a function: how many of the parameters before any rest parameter
+	// have a default, and whether the last one is a rest parameter, an
+	// array of the arguments after the others
+	Optional int
+	Variadic bool
+}
+
+func (c *Con) Position() token.Position {
+	return c.Pos
+}
+
+func con(name string, pos token.Position, args ...Type) *Con {
+	return &Con{Name: name, Args: args, Pos: pos}
+}
+
+func function(params []Type, result Type, pos token.Position) *Con {
+	return con(FUNCTION, pos, append(append([]Type{}, params...), result)...)
+}
+
+// fixed is how many parameters of function c take one argument each
+func (c *Con) fixed() int {
+	n := len(c.Args) - 1
+	if c.Variadic {
+		n--
+	}
+	return n
+}
+
+// accepts reports whether function c can be called with n arguments
+func (c *Con) accepts(n int) bool {
+	return n >= c.fixed()-c.Optional && (c.Variadic || n <= c.fixed())
+}
+
+func (c *Con) arity() string {
+	required := c.fixed() - c.Optional
+	switch {
+	case c.Variadic:
+		return fmt.Sprintf("at least %d", required)
+	case c.Optional > 0:
+		return fmt.Sprintf("%d to %d", required, c.fixed())
+	default:
+		return fmt.Sprintf("%d", required)
+	}
+}
+
+// parameters is the type of each of n arguments to function c; those past
+// the fixed parameters are elements of the rest parameter
+func (c *Con) parameters(n int) []Type {
+	params := []Type{}
+	for i := 0; i < n; i++ {
+		if i < c.fixed() {
+			params = append(params, c.Args[i])
+		} else {
+			params = append(params, c.Args[c.fixed()].(*Con).Args[0])
+		}
+	}
+	return params
+}
+
+// Scheme is a type with its Vars quantified, as a let binding's type is
+type Scheme struct {
+	Vars []int
+	Type Type
+}
+
+func (s *Scheme) String() string {
+	return newNamer().name(s.Type)
+}
+
+// subst maps type variables to the types they stand for
+type subst map[int]Type
+
+func (s subst) apply(t Type) Type {
+	if len(s) == 0 {
+		return t
+	}
+
+	switch t := t.(type) {
+	case *Var:
+		if bound, ok := s[t.ID]; ok {
+			return s.apply(bound)
+		}
+		return t
+	case *Con:
+		if len(t.Args) == 0 {
+			return t
+		}
+		args := make([]Type, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = s.apply(arg)
+		}
+		return &Con{Name: t.Name, Args: args, Pos: t.Pos, Optional: t.Optional, Variadic: t.Variadic}
+	default:
+		return t
+	}
+}
+
+func (s subst) applyScheme(scheme *Scheme) *Scheme {
+	inner := s
+
+	for _, id := range scheme.Vars {
+		if _, ok := s[id]; !ok {
+			continue
+		}
+		// a quantified variable is not the one s maps
+		inner = subst{}
+		for id, t := range s {
+			inner[id] = t
+		}
+		for _, id := range scheme.Vars {
+			delete(inner, id)
+		}
+		break
+	}
+
+	return &Scheme{Vars: scheme.Vars, Type: inner.apply(scheme.Type)}
+}
+
+// applyEnv applies s to the schemes with free variables, which are the
+// innermost ones; the closed schemes further out are shared
+func (s subst) applyEnv(e *env) *env {
+	if len(s) == 0 || e == nil || !e.open {
+		return e
+	}
+
+	return s.applyEnv(e.outer).extend(e.name, s.applyScheme(e.scheme))
+}
+
+// compose is the substitution that applies other, then s
+func (s subst) compose(other subst) subst {
+	composed := subst{}
+
+	for id, t := range other {
+		composed[id] = s.apply(t)
+	}
+
+	for id, t := range s {
+		if _, ok := composed[id]; !ok {
+			composed[id] = t
+		}
+	}
+
+	return composed
+}
+
+func freeVars(t Type, free map[int]bool) {
+	switch t := t.(type) {
+	case *Var:
+		free[t.ID] = true
+	case *Con:
+		for _, arg := range t.Args {
+			freeVars(arg, free)
+		}
+	}
+}
+
+func occurs(id int, t Type) bool {
+	free := map[int]bool{}
+	freeVars(t, free)
+	return free[id]
+}
+
+// env is the schemes of the names in scope, innermost first; it is never
+// changed, only extended, so scopes can share what is further out
+type env struct {
+	name   string
+	scheme *Scheme
+	outer  *env
+	// this scheme or one further out has free variables
+	open bool
+}
+
+func (e *env) extend(name string, scheme *Scheme) *env {
+	return &env{name: name, scheme: scheme, outer: e, open: e.isOpen() || len(scheme.free()) > 0}
+}
+
+func (e *env) isOpen() bool {
+	return e != nil && e.open
+}
+
+func (e *env) lookup(name string) (*Scheme, bool) {
+	for ; e != nil; e = e.outer {
+		if e.name == name {
+			return e.scheme, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Scheme) free() map[int]bool {
+	free := map[int]bool{}
+	freeVars(s.Type, free)
+	for _, id := range s.Vars {
+		delete(free, id)
+	}
+	return free
+}
+
+func (e *env) freeVars() map[int]bool {
+	free := map[int]bool{}
+
+	for ; e.isOpen(); e = e.outer {
+		for id := range e.scheme.free() {
+			free[id] = true
+		}
+	}
+
+	return free
+}
+
+// generalize quantifies the variables of t that no name in e depends on
+func generalize(e *env, t Type) *Scheme {
+	free := map[int]bool{}
+	freeVars(t, free)
+
+	for id := range e.freeVars() {
+		delete(free, id)
+	}
+
+	vars := []int{}
+	for id := range free {
+		vars = append(vars, id)
+	}
+	sort.Ints(vars)
+
+	return &Scheme{Vars: vars, Type: t}
+}
+
+// namer names type variables a, b, c and so on in the order they are printed
+type namer struct {
+	names map[int]string
+}
+
+func newNamer() *namer {
+	return &namer{names: map[int]string{}}
+}
+
+func (n *namer) name(t Type) string {
+	switch t := t.(type) {
+	case *Var:
+		if name, ok := n.names[t.ID]; ok {
+			return name
+		}
+		i := len(n.names)
+		name := string(rune('a' + i%26))
+		if i >= 26 {
+			name += fmt.Sprint(i / 26)
+		}
+		n.names[t.ID] = name
+		return name
+	case *Con:
+		switch t.Name {
+		case ARRAY:
+			return "[" + n.name(t.Args[0]) + "]"
+		case HASH:
+			return "{" + n.name(t.Args[0]) + ": " + n.name(t.Args[1]) + "}"
+		case FUNCTION:
+			params := t.Args[:len(t.Args)-1]
+			if len(params) == 1 && t.Optional == 0 && !t.Variadic {
+				param := n.name(params[0])
+				if c, ok := params[0].(*Con); ok && c.Name == FUNCTION {
+					param = "(" + param + ")"
+				}
+				return param + " -> " + n.name(t.Args[len(t.Args)-1])
+			}
+			// a parameter with a default is marked with ?, and a rest
+			// parameter with ..
+			names := []string{}
+			for i, p := range params {
+				name := n.name(p)
+				switch {
+				case i == t.fixed():
+					name = ".." + name
+				case i >= t.fixed()-t.Optional:
+					name += "?"
+				}
+				names = append(names, name)
+			}
+			return "(" + strings.Join(names, ", ") + ") -> " + n.name(t.Args[len(t.Args)-1])
+		default:
+			return t.Name
+		}
+	default:
+		return "?"
+	}
+}
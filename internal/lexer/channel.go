@@ -0,0 +1,12 @@
+package lexer
+
+import "github.com/w-h-a/interpreter/internal/token"
+
+type Channel <-chan token.Token
+
+func (c Channel) NextToken() token.Token {
+	if tk, ok := <-c; ok {
+		return tk
+	}
+	return token.Factory(token.EOF, "")
+}
@@ -0,0 +1,22 @@
+package lexer
+
+import (
+	"fmt"
+
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+const (
+	UNEXPECTED_CHARACTER = "unexpected character"
+	UNTERMINATED_STRING  = "unterminated string"
+	INVALID_ESCAPE       = "invalid escape"
+)
+
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s at %s", d.Message, d.Pos)
+}
@@ -1,30 +1,79 @@
 package lexer
 
 import (
+	"iter"
+
+	"github.com/w-h-a/interpreter/internal/symbol"
 	"github.com/w-h-a/interpreter/internal/token"
 )
 
-type lexer struct {
-	input  string
-	start  int
-	pos    int
-	tokens chan token.Token
+type Lexer struct {
+	input          string
+	start          int
+	pos            int
+	line           int
+	lineStart      int
+	scanned        int
+	interpolations []int
+	state          stateFn
+	pending        []token.Token
+	eof            token.Token
+	diagnostics    []Diagnostic
+	operators      token.Operators
+	emitted        [2]token.TokenType
+}
+
+func (l *Lexer) NextToken() token.Token {
+	for len(l.pending) == 0 {
+		if l.state == nil {
+			return l.eof
+		}
+		l.state = l.state(l)
+	}
+
+	tk := l.pending[0]
+	l.pending = l.pending[1:]
+
+	return tk
+}
+
+// Diagnostics holds the problems found in the input lexed so far; the lexer
+// still emits an ILLEGAL token for each one and carries on after it
+func (l *Lexer) Diagnostics() []Diagnostic {
+	return l.diagnostics
+}
+
+// Operators is the table of declared operators; the parser adds to it as it
+// reads declarations, and the lexer then reads those symbols as one token
+func (l *Lexer) Operators() token.Operators {
+	return l.operators
 }
 
-func (l *lexer) run() {
-	for state := lex; state != nil; {
-		state = state(l)
+func (l *Lexer) All() iter.Seq[token.Token] {
+	return func(yield func(token.Token) bool) {
+		for {
+			tk := l.NextToken()
+			if !yield(tk) || tk.Type == token.EOF {
+				return
+			}
+		}
 	}
-	close(l.tokens)
 }
 
-func (l *lexer) emit(t token.TokenType) {
-	tk := token.Factory(t, l.input[l.start:l.pos])
-	l.tokens <- tk
+func (l *Lexer) emit(t token.TokenType) {
+	tk := token.FactoryAt(t, l.input[l.start:l.pos], l.position())
+	switch t {
+	case token.EOF:
+		l.eof = tk
+	case token.Ident, token.Operator:
+		tk.Symbol = symbol.Intern(tk.Literal())
+	}
+	l.pending = append(l.pending, tk)
 	l.start = l.pos
+	l.emitted = [2]token.TokenType{l.emitted[1], t}
 }
 
-func (l *lexer) next() byte {
+func (l *Lexer) next() byte {
 	if l.pos >= len(l.input) {
 		return 0
 	}
@@ -36,7 +85,7 @@ func (l *lexer) next() byte {
 	return b
 }
 
-func (l *lexer) peek() byte {
+func (l *Lexer) peek() byte {
 	if l.pos >= len(l.input) {
 		return 0
 	}
@@ -44,7 +93,39 @@ func (l *lexer) peek() byte {
 	return l.input[l.pos]
 }
 
-func (l *lexer) skip() {
+func (l *Lexer) position() token.Position {
+	for ; l.scanned < l.start; l.scanned++ {
+		if l.input[l.scanned] == '\n' {
+			l.line += 1
+			l.lineStart = l.scanned + 1
+		}
+	}
+
+	return token.Position{Offset: l.start, Line: l.line, Column: l.start - l.lineStart + 1}
+}
+
+func (l *Lexer) positionAt(offset int) token.Position {
+	pos := l.position()
+
+	for i := l.start; i < offset; i++ {
+		if l.input[i] == '\n' {
+			pos.Line += 1
+			pos.Column = 1
+		} else {
+			pos.Column += 1
+		}
+	}
+
+	pos.Offset = offset
+
+	return pos
+}
+
+func (l *Lexer) report(offset int, message string) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{Pos: l.positionAt(offset), Message: message})
+}
+
+func (l *Lexer) skip() {
 	for l.pos < len(l.input) && IsSpace(l.input[l.pos]) {
 		l.pos += 1
 	}
@@ -52,15 +133,47 @@ func (l *lexer) skip() {
 	l.start = l.pos
 }
 
-func Lex(input string) chan token.Token {
-	tks := make(chan token.Token, 2)
+func New(input string) *Lexer {
+	return NewWithOperators(input, token.Operators{})
+}
 
-	l := &lexer{
-		input:  input,
-		tokens: tks,
+func NewWithOperators(input string, operators token.Operators) *Lexer {
+	return &Lexer{
+		input:     input,
+		line:      1,
+		state:     lex,
+		eof:       token.Factory(token.EOF, ""),
+		operators: operators,
 	}
+}
+
+func NewAt(input string, pos token.Position, operators token.Operators) *Lexer {
+	l := NewWithOperators(input, operators)
+
+	l.start = pos.Offset
+	l.pos = pos.Offset
+	l.scanned = pos.Offset
+	l.line = pos.Line
+	l.lineStart = pos.Offset - (pos.Column - 1)
+
+	return l
+}
+
+func Tokens(input string) iter.Seq[token.Token] {
+	return New(input).All()
+}
+
+// Lex runs the lexer on its own goroutine; prefer New or Tokens, since the
+// goroutine blocks forever if the channel is not drained
+func Lex(input string) chan token.Token {
+	tks := make(chan token.Token, 2)
 
-	go l.run()
+	go func() {
+		for tk := range Tokens(input) {
+			tks <- tk
+		}
+		close(tks)
+	}()
 
 	return tks
 }
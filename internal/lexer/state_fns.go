@@ -1,12 +1,16 @@
 package lexer
 
 import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/w-h-a/interpreter/internal/token"
 )
 
-type stateFn func(*lexer) stateFn
+type stateFn func(*Lexer) stateFn
 
-func lex(l *lexer) stateFn {
+func lex(l *Lexer) stateFn {
 	l.skip()
 
 	switch char := l.peek(); {
@@ -16,12 +20,14 @@ func lex(l *lexer) stateFn {
 		return lexIdentifier
 	case IsDigit(char):
 		return lexNumber
+	case char == '"':
+		return lexString
 	default:
 		return lexSymbol
 	}
 }
 
-func lexIdentifier(l *lexer) stateFn {
+func lexIdentifier(l *Lexer) stateFn {
 	l.next()
 
 	for l.pos < len(l.input) && IsLetter(l.input[l.pos]) {
@@ -35,7 +41,7 @@ func lexIdentifier(l *lexer) stateFn {
 	return lex
 }
 
-func lexNumber(l *lexer) stateFn {
+func lexNumber(l *Lexer) stateFn {
 	l.next()
 
 	for l.pos < len(l.input) && IsDigit(l.input[l.pos]) {
@@ -47,14 +53,70 @@ func lexNumber(l *lexer) stateFn {
 	return lex
 }
 
-func lexSymbol(l *lexer) stateFn {
+func lexString(l *Lexer) stateFn {
+	l.next() // consume opening '"'
+	l.start = l.pos
+
+	return lexStringContent(token.String, token.StringStart)
+}
+
+func lexStringContinuation(l *Lexer) stateFn {
+	return lexStringContent(token.StringEnd, token.StringMiddle)(l)
+}
+
+func lexStringContent(closed, interpolated token.TokenType) stateFn {
+	return func(l *Lexer) stateFn {
+		for l.pos < len(l.input) {
+			switch {
+			case l.input[l.pos] == '\\':
+				if l.pos+1 < len(l.input) && !strings.ContainsRune(ESCAPES, rune(l.input[l.pos+1])) {
+					l.report(l.pos, fmt.Sprintf("%s \\%c", INVALID_ESCAPE, l.input[l.pos+1]))
+				}
+				l.pos += 2
+			case l.input[l.pos] == '"':
+				l.emit(closed)
+				l.next() // consume closing '"'
+				l.start = l.pos
+				return lex
+			case strings.HasPrefix(l.input[l.pos:], "${"):
+				l.emit(interpolated)
+				l.pos += 2 // consume '${'
+				l.start = l.pos
+				l.interpolations = append(l.interpolations, 0)
+				return lex
+			default:
+				l.pos += 1
+			}
+		}
+
+		l.pos = len(l.input)
+		l.start -= 1 // include the opening '"' or '}'
+		l.report(l.start, UNTERMINATED_STRING)
+		l.emit(token.Illegal)
+
+		return lex
+	}
+}
+
+func lexSymbol(l *Lexer) stateFn {
+	if n := l.operatorLength(); n > 0 {
+		l.pos += n
+		l.emit(token.Operator)
+		return lex
+	}
+
 	switch char := l.next(); char {
 	case '=':
 		return lexEqual
 	case '+':
 		l.emit(token.Plus)
 	case '-':
-		l.emit(token.Minus)
+		if l.peek() == '>' {
+			l.next()
+			l.emit(token.Arrow)
+		} else {
+			l.emit(token.Minus)
+		}
 	case '!':
 		return lexBang
 	case '*':
@@ -70,21 +132,48 @@ func lexSymbol(l *lexer) stateFn {
 	case ')':
 		l.emit(token.ParenRight)
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1] += 1
+		}
 		l.emit(token.BraceLeft)
 	case '}':
+		if n := len(l.interpolations); n > 0 {
+			if l.interpolations[n-1] == 0 {
+				l.interpolations = l.interpolations[:n-1]
+				l.start = l.pos
+				return lexStringContinuation
+			}
+			l.interpolations[n-1] -= 1
+		}
 		l.emit(token.BraceRight)
 	case ',':
 		l.emit(token.Comma)
 	case ';':
 		l.emit(token.Semicolon)
+	case ':':
+		l.emit(token.Colon)
+	case '[':
+		l.emit(token.BracketLeft)
+	case ']':
+		l.emit(token.BracketRight)
+	case '?':
+		return lexQuestion
+	case '.':
+		return lexDot
+	case '|':
+		return lexBar
 	default:
-		l.emit(token.Illegal)
+		// take the whole character so the message shows it rather than a byte
+		l.pos = l.start
+		r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+		l.pos += size
+		l.unexpected(r)
 	}
 
 	return lex
 }
 
-func lexEqual(l *lexer) stateFn {
+func lexEqual(l *Lexer) stateFn {
 	if l.peek() == '=' {
 		l.next()
 		l.emit(token.Identical)
@@ -95,7 +184,7 @@ func lexEqual(l *lexer) stateFn {
 	return lex
 }
 
-func lexBang(l *lexer) stateFn {
+func lexBang(l *Lexer) stateFn {
 	if l.peek() == '=' {
 		l.next()
 		l.emit(token.NotIdentical)
@@ -106,7 +195,79 @@ func lexBang(l *lexer) stateFn {
 	return lex
 }
 
-func lexStop(l *lexer) stateFn {
+func lexDot(l *Lexer) stateFn {
+	if l.peek() == '.' {
+		l.next()
+		l.emit(token.Spread)
+	} else {
+		l.emit(token.Dot)
+	}
+
+	return lex
+}
+
+func lexBar(l *Lexer) stateFn {
+	if l.peek() == '>' {
+		l.next()
+		l.emit(token.Pipe)
+	} else {
+		l.unexpected('|')
+	}
+
+	return lex
+}
+
+func lexQuestion(l *Lexer) stateFn {
+	switch l.peek() {
+	case '?':
+		l.next()
+		l.emit(token.Coalesce)
+	case '.':
+		l.next()
+		l.emit(token.OptionalChain)
+	default:
+		l.unexpected('?')
+	}
+
+	return lex
+}
+
+// operatorLength measures a declared operator at the current position, or
+// the operator being declared right after `infixl 6`
+func (l *Lexer) operatorLength() int {
+	end := l.pos
+	for end < len(l.input) && IsOperatorChar(l.input[end]) {
+		end += 1
+	}
+
+	run := l.input[l.pos:end]
+
+	switch l.emitted[0] {
+	case token.InfixL, token.InfixR, token.Infix:
+		if _, builtin := token.LookupOperator(run); l.emitted[1] == token.Int && !builtin {
+			// known from here on; the parser fills in how it binds
+			if _, ok := l.operators[run]; !ok {
+				l.operators[run] = token.Fixity{}
+			}
+			return len(run)
+		}
+	}
+
+	for n := len(run); n > 0; n-- {
+		if _, ok := l.operators[run[:n]]; ok {
+			return n
+		}
+	}
+
+	return 0
+}
+
+func (l *Lexer) unexpected(r rune) {
+	l.report(l.start, fmt.Sprintf("%s %q", UNEXPECTED_CHARACTER, r))
+	l.emit(token.Illegal)
+}
+
+func lexStop(l *Lexer) stateFn {
 	l.emit(token.EOF)
 	return nil
 }
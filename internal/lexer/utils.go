@@ -1,5 +1,13 @@
 package lexer
 
+import "strings"
+
+// the characters that may follow a backslash in a string
+const ESCAPES = "ntr\"\\$"
+
+// the characters a declared operator is made of
+const OPERATOR_CHARS = "!#$%&*+-./:<=>?@^|~"
+
 func IsLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
 }
@@ -11,3 +19,7 @@ func IsDigit(ch byte) bool {
 func IsSpace(ch byte) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
+
+func IsOperatorChar(ch byte) bool {
+	return ch != 0 && strings.IndexByte(OPERATOR_CHARS, ch) >= 0
+}
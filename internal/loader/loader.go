@@ -0,0 +1,233 @@
+package loader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/object"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	errorobject "github.com/w-h-a/interpreter/internal/object/error"
+	moduleobj "github.com/w-h-a/interpreter/internal/object/module"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/resolver"
+)
+
+const Extension = ".mk"
+
+type ParseError struct {
+	File   string
+	Errors []string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse errors in %s: %s", e.File, strings.Join(e.Errors, "; "))
+}
+
+type Loader struct {
+	root       string
+	searchPath []string
+	cache      map[string]*moduleobj.Module
+	loading    []string
+	out        io.Writer
+}
+
+func (l *Loader) Run(file string) (object.Object, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	l.root = filepath.Dir(abs)
+
+	program, err := l.parse(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	l.loading = append(l.loading, abs)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	return evaluator.Eval(program, l.Environment(l.root)), nil
+}
+
+func (l *Loader) RunSource(name, src, dir string) (object.Object, error) {
+	l.root = dir
+
+	p := parser.New(lexer.New(src))
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return nil, &ParseError{File: name, Errors: p.Errors()}
+	}
+
+	resolver.Resolve(program)
+
+	return evaluator.Eval(program, l.Environment(l.root)), nil
+}
+
+func (l *Loader) Environment(dir string) *environment.Environment {
+	if len(l.root) == 0 {
+		l.root = dir
+	}
+
+	return environment.NewModule(&importer{loader: l, dir: dir}, l.out)
+}
+
+func (l *Loader) load(name, file string) object.Object {
+	if mod, ok := l.cache[file]; ok {
+		return mod
+	}
+
+	for i, f := range l.loading {
+		if f == file {
+			cycle := []string{}
+			for _, c := range l.loading[i:] {
+				cycle = append(cycle, l.display(c))
+			}
+			cycle = append(cycle, l.display(file))
+			return newError("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	program, err := l.parse(file)
+	if err != nil {
+		return newError("%v", err)
+	}
+
+	l.loading = append(l.loading, file)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	env := environment.NewModule(&importer{loader: l, dir: filepath.Dir(file)}, l.out)
+
+	result := evaluator.Eval(program, env)
+	if errObj, ok := result.(*errorobject.Error); ok {
+		return newError("in module %s: %s", name, errObj.Message)
+	}
+
+	mod := &moduleobj.Module{
+		Name:    name,
+		Path:    file,
+		Names:   []string{},
+		Exports: map[string]object.Object{},
+	}
+
+	for _, stmt := range program.Statements {
+		exp, ok := stmt.(*export.Export)
+		if !ok {
+			continue
+		}
+
+		exportName := exp.Let.Name.Value
+
+		if _, ok := mod.Exports[exportName]; !ok {
+			mod.Names = append(mod.Names, exportName)
+		}
+
+		mod.Exports[exportName], _ = env.Get(exportName)
+	}
+
+	l.cache[file] = mod
+
+	return mod
+}
+
+func (l *Loader) parse(file string) (*statement.Program, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.New(lexer.New(string(src)))
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return nil, &ParseError{File: l.display(file), Errors: p.Errors()}
+	}
+
+	resolver.Resolve(program)
+
+	return program, nil
+}
+
+func (l *Loader) resolve(dir, path string) (string, error) {
+	name := filepath.FromSlash(path)
+
+	if len(filepath.Ext(name)) == 0 {
+		name += Extension
+	}
+
+	candidates := []string{}
+
+	if filepath.IsAbs(name) {
+		candidates = append(candidates, name)
+	} else {
+		candidates = append(candidates, filepath.Join(dir, name))
+		for _, search := range l.searchPath {
+			candidates = append(candidates, filepath.Join(search, name))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Abs(candidate)
+		}
+	}
+
+	return "", fmt.Errorf("cannot find module %q (looked in %s)", path, strings.Join(candidates, ", "))
+}
+
+func (l *Loader) display(file string) string {
+	if rel, err := filepath.Rel(l.root, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+func New(searchPath ...string) *Loader {
+	return NewWithOutput(os.Stdout, searchPath...)
+}
+
+// NewWithOutput is a loader whose programs and modules print to out
+func NewWithOutput(out io.Writer, searchPath ...string) *Loader {
+	paths := []string{}
+
+	for _, path := range searchPath {
+		if abs, err := filepath.Abs(path); err == nil {
+			paths = append(paths, abs)
+		}
+	}
+
+	return &Loader{
+		searchPath: paths,
+		cache:      map[string]*moduleobj.Module{},
+		loading:    []string{},
+		out:        out,
+	}
+}
+
+type importer struct {
+	loader *Loader
+	dir    string
+}
+
+func (i *importer) Import(path string) object.Object {
+	file, err := i.loader.resolve(i.dir, path)
+	if err != nil {
+		return newError("%v", err)
+	}
+
+	return i.loader.load(path, file)
+}
+
+func newError(format string, a ...any) *errorobject.Error {
+	return &errorobject.Error{Message: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,347 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
+	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/format"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/object"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+const PLAINTEXT = "plaintext"
+
+// lookup finds the file and the reference at a position in it; a position
+// on no name has no reference
+func (s *Server) lookup(params TextDocumentPositionParams) (*file, *reference, error) {
+	f, err := s.file(params.TextDocument.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return f, f.index.at(offset(f.document.Text(), params.Position)), nil
+}
+
+func (f *file) name(name *identifier.Identifier) Range {
+	start := name.Token.Position().Offset
+	return span(f.document.Text(), start, start+len(name.Value))
+}
+
+func (s *Server) hover(params json.RawMessage) (any, error) {
+	p, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ref, err := s.lookup(p)
+	if err != nil || ref == nil {
+		return nil, err
+	}
+
+	var contents string
+
+	switch bindings := ref.bindings(); {
+	case len(bindings) > 0:
+		b := bindings[len(bindings)-1]
+		contents = b.kind + " " + b.name.Value + f.describe(b)
+	case ref.builtin:
+		contents = BUILTIN + " " + ref.name.Value + " (" + string(object.BUILTIN) + ")"
+	default:
+		return nil, nil
+	}
+
+	return Hover{Contents: MarkupContent{Kind: PLAINTEXT, Value: contents}, Range: f.name(ref.name)}, nil
+}
+
+// describe is the type of what b binds, as it is annotated or inferred, or
+// else the kind of value it binds
+func (f *file) describe(b *binding) string {
+	if b.annotation != nil {
+		return ": " + b.annotation.String()
+	}
+
+	if scheme, ok := f.types[b.name]; ok {
+		return ": " + scheme.String()
+	}
+
+	if kind, ok := valueKind(b.value); ok {
+		return " (" + string(kind) + ")"
+	}
+
+	return ""
+}
+
+func valueKind(exp expression.Expression) (object.ObjectType, bool) {
+	switch exp.(type) {
+	case *integer.Integer:
+		return object.INTEGER, true
+	case *boolean.Boolean:
+		return object.BOOLEAN, true
+	case *stringexpression.String, *interpolation.Interpolation:
+		return object.STRING, true
+	case *null.Null:
+		return object.NULL, true
+	case *array.Array:
+		return object.ARRAY, true
+	case *hash.Hash:
+		return object.HASH, true
+	case *fnexp.Function:
+		return object.FUNCTION, true
+	default:
+		return "", false
+	}
+}
+
+func (s *Server) definition(params json.RawMessage) (any, error) {
+	p, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ref, err := s.lookup(p)
+	if err != nil || ref == nil {
+		return nil, err
+	}
+
+	locations := []Location{}
+
+	for _, b := range ref.bindings() {
+		locations = append(locations, Location{URI: p.TextDocument.URI, Range: f.name(b.name)})
+	}
+
+	return locations, nil
+}
+
+func (s *Server) references(params json.RawMessage) (any, error) {
+	p, err := decode[ReferenceParams](params)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ref, err := s.lookup(p.TextDocumentPositionParams)
+	if err != nil || ref == nil || ref.variable == nil {
+		return nil, err
+	}
+
+	locations := []Location{}
+
+	for _, name := range occurrences(ref.variable, p.Context.IncludeDeclaration) {
+		locations = append(locations, Location{URI: p.TextDocument.URI, Range: f.name(name)})
+	}
+
+	return locations, nil
+}
+
+// occurrences is every place v's name is written, in source order
+func occurrences(v *variable, bindings bool) []*identifier.Identifier {
+	names := append([]*identifier.Identifier{}, v.uses...)
+
+	if bindings {
+		for _, b := range v.bindings {
+			names = append(names, b.name)
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return names[i].Token.Position().Offset < names[j].Token.Position().Offset
+	})
+
+	return names
+}
+
+func (s *Server) completion(params json.RawMessage) (any, error) {
+	p, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.file(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+
+	for _, v := range f.index.visible(offset(f.document.Text(), p.Position)) {
+		seen[v.name] = true
+
+		item := CompletionItem{Label: v.name, Kind: COMPLETION_VARIABLE}
+		if len(v.bindings) > 0 {
+			b := v.bindings[len(v.bindings)-1]
+			item.Detail = b.kind + f.describe(b)
+			switch b.kind {
+			case IMPORT:
+				item.Kind = COMPLETION_MODULE
+			case OPERATOR:
+				continue
+			}
+			if _, ok := b.value.(*fnexp.Function); ok {
+				item.Kind = COMPLETION_FUNCTION
+			}
+		}
+		items = append(items, item)
+	}
+
+	for _, name := range evaluator.Builtins() {
+		if !seen[name] {
+			items = append(items, CompletionItem{Label: name, Kind: COMPLETION_FUNCTION, Detail: BUILTIN})
+		}
+	}
+
+	for _, word := range token.Keywords() {
+		items = append(items, CompletionItem{Label: word, Kind: COMPLETION_KEYWORD})
+	}
+
+	return items, nil
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (any, error) {
+	p, err := decode[DocumentParams](params)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.file(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	text := f.document.Text()
+	stmts := f.document.Program().Statements
+
+	symbols := []DocumentSymbol{}
+
+	for i, stmt := range stmts {
+		end := len(text)
+		if i+1 < len(stmts) {
+			end = tree.Token(stmts[i+1]).Position().Offset
+		}
+
+		start := tree.Token(stmt).Position().Offset
+		end = start + len(strings.TrimRight(text[start:end], " \t\r\n"))
+
+		symbol := DocumentSymbol{Range: span(text, start, end)}
+
+		var name *identifier.Identifier
+
+		switch stmt := stmt.(type) {
+		case *export.Export:
+			name, symbol.Kind, symbol.Detail = stmt.Let.Name, kindOf(stmt.Let.Value), "export "+LET+f.describe(&binding{name: stmt.Let.Name, value: stmt.Let.Value, annotation: stmt.Let.Type})
+		case *let.Let:
+			name, symbol.Kind, symbol.Detail = stmt.Name, kindOf(stmt.Value), LET+f.describe(&binding{name: stmt.Name, value: stmt.Value, annotation: stmt.Type})
+		case *operator.Operator:
+			name, symbol.Kind, symbol.Detail = stmt.Name, SYMBOL_OPERATOR, stmt.TokenLiteral()+" "+stmt.Precedence.String()
+		case *importstatement.Import:
+			name, symbol.Kind, symbol.Detail = stmt.Alias, SYMBOL_MODULE, IMPORT+" "+stmt.Path.String()
+		default:
+			continue
+		}
+
+		symbol.Name, symbol.SelectionRange = name.Value, f.name(name)
+
+		symbols = append(symbols, symbol)
+	}
+
+	return symbols, nil
+}
+
+func kindOf(value expression.Expression) int {
+	if _, ok := value.(*fnexp.Function); ok {
+		return SYMBOL_FUNCTION
+	}
+	return SYMBOL_VARIABLE
+}
+
+// formatting replaces the whole text, or nothing while it does not parse
+func (s *Server) formatting(params json.RawMessage) (any, error) {
+	p, err := decode[DocumentParams](params)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.file(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.document.Errors()) > 0 {
+		return []TextEdit{}, nil
+	}
+
+	text := f.document.Text()
+
+	formatted := format.Format(f.document.Program())
+	if formatted == text {
+		return []TextEdit{}, nil
+	}
+
+	return []TextEdit{{Range: span(text, 0, len(text)), NewText: formatted}}, nil
+}
+
+func (s *Server) rename(params json.RawMessage) (any, error) {
+	p, err := decode[RenameParams](params)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ref, err := s.lookup(p.TextDocumentPositionParams)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ref == nil:
+		return nil, &ResponseError{Code: REQUEST_FAILED, Message: "no name to rename here"}
+	case ref.variable == nil:
+		if ref.builtin {
+			return nil, &ResponseError{Code: REQUEST_FAILED, Message: fmt.Sprintf("cannot rename the builtin %s", ref.name.Value)}
+		}
+		return nil, &ResponseError{Code: REQUEST_FAILED, Message: fmt.Sprintf("%s is not defined", ref.name.Value)}
+	case len(ref.variable.bindings) > 0 && ref.variable.bindings[0].kind == OPERATOR:
+		return nil, &ResponseError{Code: REQUEST_FAILED, Message: fmt.Sprintf("cannot rename the operator %s", ref.name.Value)}
+	case !isIdentifier(p.NewName):
+		return nil, &ResponseError{Code: INVALID_PARAMS, Message: fmt.Sprintf("%q is not a valid name", p.NewName)}
+	}
+
+	if conflict := f.index.conflict(ref.variable, p.NewName); len(conflict) > 0 {
+		return nil, &ResponseError{Code: REQUEST_FAILED, Message: fmt.Sprintf("cannot rename %s to %s: %s", ref.name.Value, p.NewName, conflict)}
+	}
+
+	edits := []TextEdit{}
+
+	for _, name := range occurrences(ref.variable, true) {
+		edits = append(edits, TextEdit{Range: f.name(name), NewText: p.NewName})
+	}
+
+	return WorkspaceEdit{Changes: map[string][]TextEdit{p.TextDocument.URI: edits}}, nil
+}
+
+// isIdentifier reports whether name lexes as one identifier, which keywords
+// do not
+func isIdentifier(name string) bool {
+	l := lexer.New(name)
+
+	first := l.NextToken()
+
+	return first.Type == token.Ident && first.Literal() == name && l.NextToken().Type == token.EOF
+}
@@ -0,0 +1,354 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+const (
+	LET       = "let"
+	PARAMETER = "parameter"
+	IMPORT    = "import"
+	OPERATOR  = "operator"
+	BUILTIN   = "builtin"
+)
+
+type binding struct {
+	name       *identifier.Identifier
+	kind       string
+	value      expression.Expression
+	annotation annotation.Annotation
+	variable   *variable
+}
+
+// a variable is every binding of one name in one scope, which is what
+// references and rename work on
+type variable struct {
+	name     string
+	scope    *scope
+	bindings []*binding
+	uses     []*identifier.Identifier
+}
+
+// a reference is an identifier in the source and what it names: a binding
+// when it is known which one, or only the variable when any of its bindings
+// may be meant, or a builtin when neither
+type reference struct {
+	name     *identifier.Identifier
+	scope    *scope
+	binding  *binding
+	variable *variable
+	builtin  bool
+}
+
+// a scope is the names one function binds, as the resolver has it: blocks
+// share the scope of the function they are in
+type scope struct {
+	outer      *scope
+	start, end int
+	bound      map[string]*binding
+	declared   map[string]*variable
+	names      []string
+}
+
+func newScope(outer *scope, start, end int) *scope {
+	return &scope{outer: outer, start: start, end: end, bound: map[string]*binding{}, declared: map[string]*variable{}}
+}
+
+// index is what the server knows of a program's names
+type index struct {
+	text       string
+	builtins   map[string]bool
+	scopes     []*scope
+	references []*reference
+	// the matching closing brace of each opening one, by offset
+	braces map[int]int
+}
+
+func newIndex(text string, program *statement.Program) *index {
+	x := &index{text: text, builtins: map[string]bool{}, braces: braces(text)}
+
+	for _, name := range evaluator.Builtins() {
+		x.builtins[name] = true
+	}
+
+	s := newScope(nil, 0, len(text))
+	x.scopes = append(x.scopes, s)
+
+	x.declare(s, program)
+	x.node(s, program)
+
+	sort.SliceStable(x.references, func(i, j int) bool {
+		return x.references[i].name.Token.Position().Offset < x.references[j].name.Token.Position().Offset
+	})
+
+	return x
+}
+
+// braces pairs up the braces of text; the braces of interpolations are part
+// of their string tokens, so they are not counted
+func braces(text string) map[int]int {
+	pairs := map[int]int{}
+	open := []int{}
+
+	for tk := range lexer.New(text).All() {
+		switch tk.Type {
+		case token.BraceLeft:
+			open = append(open, tk.Position().Offset)
+		case token.BraceRight:
+			if len(open) > 0 {
+				pairs[open[len(open)-1]] = tk.Position().Offset + 1
+				open = open[:len(open)-1]
+			}
+		}
+	}
+
+	return pairs
+}
+
+// declare gives a variable to every name the scope binds, wherever it is
+// bound, so functions defined in the scope can reach them
+func (x *index) declare(s *scope, node ast.Node) {
+	switch node := node.(type) {
+	case *fnexp.Function:
+		return
+	case *let.Let:
+		x.variable(s, node.Name.Value)
+	case *operator.Operator:
+		x.variable(s, node.Name.Value)
+	case *importstatement.Import:
+		x.variable(s, node.Alias.Value)
+	}
+
+	for _, child := range tree.Children(node) {
+		x.declare(s, child.Node)
+	}
+}
+
+func (x *index) variable(s *scope, name string) *variable {
+	v, ok := s.declared[name]
+	if !ok {
+		v = &variable{name: name, scope: s}
+		s.declared[name] = v
+		s.names = append(s.names, name)
+	}
+	return v
+}
+
+func (x *index) node(s *scope, node ast.Node) {
+	switch node := node.(type) {
+	case *identifier.Identifier:
+		x.use(s, node)
+		return
+	case *fnexp.Function:
+		x.function(s, node)
+		return
+	case *field.Field:
+		// the field name is looked up in the value, not in scope
+		x.node(s, node.Left)
+		return
+	case *let.Let:
+		x.node(s, node.Value)
+		x.bind(s, &binding{name: node.Name, kind: LET, value: node.Value, annotation: node.Type})
+		return
+	case *operator.Operator:
+		x.node(s, node.Value)
+		x.bind(s, &binding{name: node.Name, kind: OPERATOR, value: node.Value})
+		return
+	case *importstatement.Import:
+		x.bind(s, &binding{name: node.Alias, kind: IMPORT})
+		return
+	}
+
+	for _, child := range tree.Children(node) {
+		if !tree.IsNil(child.Node) {
+			x.node(s, child.Node)
+		}
+	}
+}
+
+func (x *index) function(outer *scope, fn *fnexp.Function) {
+	start := fn.Token.Position().Offset
+
+	end, ok := x.braces[fn.Body.Token.Position().Offset]
+	if !ok {
+		end = len(x.text)
+	}
+
+	s := newScope(outer, start, end)
+	x.scopes = append(x.scopes, s)
+
+	for _, param := range fn.Parameters {
+		x.variable(s, param.Value)
+	}
+
+	if fn.Rest != nil {
+		x.variable(s, fn.Rest.Value)
+	}
+
+	x.declare(s, fn.Body)
+
+	// defaults run once the parameters before them are bound
+	for i, param := range fn.Parameters {
+		if i < len(fn.Defaults) && fn.Defaults[i] != nil {
+			x.node(s, fn.Defaults[i])
+		}
+
+		b := &binding{name: param, kind: PARAMETER}
+		if i < len(fn.Types) {
+			b.annotation = fn.Types[i]
+		}
+		x.bind(s, b)
+	}
+
+	if fn.Rest != nil {
+		x.bind(s, &binding{name: fn.Rest, kind: PARAMETER})
+	}
+
+	x.node(s, fn.Body)
+}
+
+func (x *index) bind(s *scope, b *binding) {
+	b.variable = x.variable(s, b.name.Value)
+	b.variable.bindings = append(b.variable.bindings, b)
+
+	s.bound[b.name.Value] = b
+
+	x.references = append(x.references, &reference{name: b.name, scope: s, binding: b, variable: b.variable})
+}
+
+// use finds what a name refers to as vet does: in its own scope the binding
+// made last before it, and further out any binding of the scope, since a
+// function may run after the scope it is defined in has bound everything
+func (x *index) use(s *scope, name *identifier.Identifier) {
+	ref := &reference{name: name, scope: s}
+
+	for crossed := false; s != nil; s, crossed = s.outer, true {
+		if b, ok := s.bound[name.Value]; ok && !crossed {
+			ref.binding, ref.variable = b, b.variable
+			break
+		}
+
+		if v, ok := s.declared[name.Value]; ok && crossed {
+			ref.variable = v
+			break
+		}
+	}
+
+	if ref.variable != nil {
+		ref.variable.uses = append(ref.variable.uses, name)
+	} else {
+		ref.builtin = x.builtins[name.Value]
+	}
+
+	x.references = append(x.references, ref)
+}
+
+// bindings is where what ref names is bound
+func (ref *reference) bindings() []*binding {
+	if ref.binding != nil {
+		return []*binding{ref.binding}
+	}
+
+	if ref.variable != nil {
+		return ref.variable.bindings
+	}
+
+	return nil
+}
+
+// at is the reference whose name covers offset, ending at it included
+func (x *index) at(offset int) *reference {
+	for _, ref := range x.references {
+		start := ref.name.Token.Position().Offset
+		if start <= offset && offset <= start+len(ref.name.Value) {
+			return ref
+		}
+	}
+
+	return nil
+}
+
+// scope is the innermost scope around offset
+func (x *index) scope(offset int) *scope {
+	innermost := x.scopes[0]
+
+	for _, s := range x.scopes[1:] {
+		if s.start <= offset && offset < s.end && s.start >= innermost.start {
+			innermost = s
+		}
+	}
+
+	return innermost
+}
+
+// visible is the names in scope at offset, innermost first, each with the
+// variable it names there
+func (x *index) visible(offset int) []*variable {
+	seen := map[string]bool{}
+	visible := []*variable{}
+
+	for s := x.scope(offset); s != nil; s = s.outer {
+		for _, name := range s.names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			visible = append(visible, s.declared[name])
+		}
+	}
+
+	return visible
+}
+
+// within reports whether s is inner or is outer itself
+func (s *scope) within(outer *scope) bool {
+	for ; s != nil; s = s.outer {
+		if s == outer {
+			return true
+		}
+	}
+	return false
+}
+
+// conflict is why renaming v to name would change what a name refers to,
+// or "" when it would not: name may already be bound where v is, a scope
+// between a use of v and v's own may bind name, or a use of name v can see
+// may refer to something further out that v would then stand in front of
+func (x *index) conflict(v *variable, name string) string {
+	if other, ok := v.scope.declared[name]; ok && other != v {
+		return fmt.Sprintf("%s is already bound in the scope of %s", name, v.name)
+	}
+
+	for _, ref := range x.references {
+		binds := ref.binding != nil && ref.binding.name == ref.name
+
+		switch {
+		case ref.variable == v && !binds:
+			for s := ref.scope; s != v.scope; s = s.outer {
+				if _, ok := s.declared[name]; ok {
+					return fmt.Sprintf("the use of %s at %s would refer to the %s bound in an inner scope", v.name, ref.name.Token.Position(), name)
+				}
+			}
+		case ref.name.Value == name && ref.scope.within(v.scope) && (ref.variable == nil || ref.variable.scope != v.scope && !ref.variable.scope.within(v.scope)):
+			return fmt.Sprintf("the use of %s at %s would refer to %s instead", name, ref.name.Token.Position(), v.name)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,87 @@
+package lsp
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+// position is where offset is in text as the protocol counts
+func position(text string, offset int) Position {
+	offset = max(0, min(offset, len(text)))
+
+	before := text[:offset]
+	start := strings.LastIndexByte(before, '\n') + 1
+
+	return Position{Line: strings.Count(before, "\n"), Character: width(before[start:])}
+}
+
+// offset is the byte offset of pos in text; positions past the end of a
+// line are at its end, and past the last line at the end of text
+func offset(text string, pos Position) int {
+	start := 0
+
+	for line := 0; line < pos.Line; line++ {
+		next := strings.IndexByte(text[start:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+		start += next + 1
+	}
+
+	end := strings.IndexByte(text[start:], '\n')
+	if end < 0 {
+		end = len(text)
+	} else {
+		end += start
+	}
+
+	units := 0
+	for i, r := range text[start:end] {
+		if units >= pos.Character {
+			return start + i
+		}
+		units += utf16.RuneLen(r)
+	}
+
+	return end
+}
+
+func span(text string, start, end int) Range {
+	return Range{Start: position(text, start), End: position(text, end)}
+}
+
+// at is the range of a token-sized word starting at pos, for diagnostics that
+// only know where they start
+func at(text string, pos token.Position) Range {
+	end := pos.Offset
+
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(r) {
+			break
+		}
+		end += size
+	}
+
+	if end == pos.Offset && end < len(text) && text[end] != '\n' {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+
+	return span(text, pos.Offset, end)
+}
+
+func width(s string) int {
+	units := 0
+	for _, r := range s {
+		units += utf16.RuneLen(r)
+	}
+	return units
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
@@ -0,0 +1,116 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const CONTENT_LENGTH = "Content-Length"
+
+// JSON-RPC error codes
+const (
+	PARSE_ERROR            = -32700
+	INVALID_REQUEST        = -32600
+	METHOD_NOT_FOUND       = -32601
+	INVALID_PARAMS         = -32602
+	SERVER_NOT_INITIALIZED = -32002
+	REQUEST_FAILED         = -32803
+)
+
+// Message is a request, a response or a notification; a notification has no
+// ID, and a response has no Method
+type Message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// responses always carry a result, even a null one
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  any              `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   *ResponseError   `json:"error"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Read reads the body of the next message, framed by its headers; it returns
+// io.EOF when the input ends between messages
+func Read(in *bufio.Reader) ([]byte, error) {
+	length := -1
+
+	for {
+		line, err := in.ReadString('\n')
+		if err == io.EOF && line == "" && length < 0 {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed header %q", line)
+		}
+
+		if strings.EqualFold(strings.TrimSpace(name), CONTENT_LENGTH) {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid %s %q", CONTENT_LENGTH, value)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("missing %s header", CONTENT_LENGTH)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(in, body); err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	return body, nil
+}
+
+// Write frames body and writes it out
+func Write(out io.Writer, body []byte) error {
+	if _, err := fmt.Fprintf(out, "%s: %d\r\n\r\n", CONTENT_LENGTH, len(body)); err != nil {
+		return err
+	}
+
+	_, err := out.Write(body)
+
+	return err
+}
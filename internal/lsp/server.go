@@ -0,0 +1,302 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/document"
+	"github.com/w-h-a/interpreter/internal/infer"
+	"github.com/w-h-a/interpreter/internal/typecheck"
+	"github.com/w-h-a/interpreter/internal/vet"
+)
+
+const NAME = "monkey"
+
+// a file is an open document and what the server worked out from its text
+type file struct {
+	document *document.Document
+	index    *index
+	// the inferred types of the top-level bindings, when the program infers
+	types map[*identifier.Identifier]*infer.Scheme
+}
+
+type handler func(s *Server, params json.RawMessage) (any, error)
+
+type Server struct {
+	out         io.Writer
+	files       map[string]*file
+	initialized bool
+	shutdown    bool
+	exited      bool
+}
+
+var (
+	requests = map[string]handler{
+		"initialize":                  (*Server).initialize,
+		"shutdown":                    (*Server).shutdownRequest,
+		"textDocument/hover":          (*Server).hover,
+		"textDocument/definition":     (*Server).definition,
+		"textDocument/references":     (*Server).references,
+		"textDocument/completion":     (*Server).completion,
+		"textDocument/documentSymbol": (*Server).documentSymbol,
+		"textDocument/formatting":     (*Server).formatting,
+		"textDocument/rename":         (*Server).rename,
+	}
+
+	notifications = map[string]func(s *Server, params json.RawMessage) error{
+		"initialized":            func(*Server, json.RawMessage) error { return nil },
+		"exit":                   (*Server).exit,
+		"textDocument/didOpen":   (*Server).didOpen,
+		"textDocument/didChange": (*Server).didChange,
+		"textDocument/didClose":  (*Server).didClose,
+	}
+)
+
+var ErrNoShutdown = errors.New("exit before shutdown")
+
+// Serve answers the messages read from in on out until the client says to
+// exit or in ends
+func Serve(in io.Reader, out io.Writer) error {
+	s := &Server{out: out, files: map[string]*file{}}
+
+	reader := bufio.NewReader(in)
+
+	for !s.exited {
+		body, err := Read(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.handle(body); err != nil {
+			return err
+		}
+	}
+
+	if !s.shutdown {
+		return ErrNoShutdown
+	}
+
+	return nil
+}
+
+func (s *Server) handle(body []byte) error {
+	var msg Message
+
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return s.respondError(nil, &ResponseError{Code: PARSE_ERROR, Message: err.Error()})
+	}
+
+	if msg.ID == nil {
+		notify, ok := notifications[msg.Method]
+		if !ok || !s.initialized && msg.Method != "exit" {
+			// unknown notifications are dropped
+			return nil
+		}
+		return notify(s, msg.Params)
+	}
+
+	handle, ok := requests[msg.Method]
+
+	switch {
+	case !ok:
+		return s.respondError(msg.ID, &ResponseError{Code: METHOD_NOT_FOUND, Message: fmt.Sprintf("unknown method %s", msg.Method)})
+	case !s.initialized && msg.Method != "initialize":
+		return s.respondError(msg.ID, &ResponseError{Code: SERVER_NOT_INITIALIZED, Message: "server not initialized"})
+	case s.shutdown:
+		return s.respondError(msg.ID, &ResponseError{Code: INVALID_REQUEST, Message: "server is shut down"})
+	}
+
+	result, err := handle(s, msg.Params)
+	if err != nil {
+		var responseErr *ResponseError
+		if !errors.As(err, &responseErr) {
+			responseErr = &ResponseError{Code: REQUEST_FAILED, Message: err.Error()}
+		}
+		return s.respondError(msg.ID, responseErr)
+	}
+
+	return s.write(response{JSONRPC: "2.0", ID: msg.ID, Result: result})
+}
+
+func (s *Server) respondError(id *json.RawMessage, err *ResponseError) error {
+	return s.write(errorResponse{JSONRPC: "2.0", ID: id, Error: err})
+}
+
+func (s *Server) notify(method string, params any) error {
+	return s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) write(msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return Write(s.out, body)
+}
+
+func decode[T any](params json.RawMessage) (T, error) {
+	var decoded T
+
+	if err := json.Unmarshal(params, &decoded); err != nil {
+		return decoded, &ResponseError{Code: INVALID_PARAMS, Message: err.Error()}
+	}
+
+	return decoded, nil
+}
+
+func (s *Server) initialize(json.RawMessage) (any, error) {
+	s.initialized = true
+
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           SYNC_INCREMENTAL,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			ReferencesProvider:         true,
+			CompletionProvider:         struct{}{},
+			DocumentSymbolProvider:     true,
+			DocumentFormattingProvider: true,
+			RenameProvider:             true,
+		},
+		ServerInfo: ServerInfo{Name: NAME},
+	}, nil
+}
+
+func (s *Server) shutdownRequest(json.RawMessage) (any, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) exit(json.RawMessage) error {
+	s.exited = true
+	return nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) error {
+	p, err := decode[DidOpenTextDocumentParams](params)
+	if err != nil {
+		return nil
+	}
+
+	s.files[p.TextDocument.URI] = &file{document: document.New(p.TextDocument.Text)}
+
+	return s.update(p.TextDocument.URI)
+}
+
+func (s *Server) didChange(params json.RawMessage) error {
+	p, err := decode[DidChangeTextDocumentParams](params)
+	if err != nil {
+		return nil
+	}
+
+	f, ok := s.files[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	for _, change := range p.ContentChanges {
+		if change.Range == nil {
+			f.document = document.New(change.Text)
+			continue
+		}
+
+		text := f.document.Text()
+		edit := document.Edit{Start: offset(text, change.Range.Start), End: offset(text, change.Range.End), Text: change.Text}
+
+		if err := f.document.Apply(edit); err != nil {
+			// start over from the text as the edit leaves it
+			f.document = document.New(text[:edit.Start] + edit.Text + text[max(edit.Start, edit.End):])
+		}
+	}
+
+	return s.update(p.TextDocument.URI)
+}
+
+func (s *Server) didClose(params json.RawMessage) error {
+	p, err := decode[DidCloseTextDocumentParams](params)
+	if err != nil {
+		return nil
+	}
+
+	delete(s.files, p.TextDocument.URI)
+
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+}
+
+// update works out what the server knows of a file from its new text and
+// publishes its diagnostics
+func (s *Server) update(uri string) error {
+	f := s.files[uri]
+
+	text := f.document.Text()
+	program := f.document.Program()
+
+	f.index = newIndex(text, program)
+	f.types = map[*identifier.Identifier]*infer.Scheme{}
+
+	diagnostics := []Diagnostic{}
+
+	for _, d := range f.document.Diagnostics() {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    span(text, d.Start.Offset, d.End.Offset),
+			Severity: SEVERITY_ERROR,
+			Source:   "parse",
+			Message:  d.Message,
+		})
+	}
+
+	// the checks only make sense of a whole program
+	if len(diagnostics) == 0 {
+		diagnostics = append(diagnostics, check(text, program)...)
+
+		if bindings, err := infer.Infer(program); err == nil {
+			for _, b := range bindings {
+				f.types[b.Name] = b.Scheme
+			}
+		}
+	}
+
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+func check(text string, program *statement.Program) []Diagnostic {
+	diagnostics := []Diagnostic{}
+
+	for _, d := range vet.Vet(program) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    at(text, d.Pos),
+			Severity: SEVERITY_WARNING,
+			Source:   "vet",
+			Message:  fmt.Sprintf("%s (%s)", d.Message, d.Check),
+		})
+	}
+
+	for _, d := range typecheck.Check(program) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    at(text, d.Pos),
+			Severity: SEVERITY_ERROR,
+			Source:   "typecheck",
+			Message:  d.Message,
+		})
+	}
+
+	return diagnostics
+}
+
+func (s *Server) file(uri string) (*file, error) {
+	f, ok := s.files[uri]
+	if !ok {
+		return nil, &ResponseError{Code: INVALID_PARAMS, Message: fmt.Sprintf("document %s is not open", uri)}
+	}
+
+	return f, nil
+}
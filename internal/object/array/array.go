@@ -0,0 +1,31 @@
+package array
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/object"
+)
+
+type Array struct {
+	Elements []object.Object
+}
+
+func (o *Array) Inspect() string {
+	var out strings.Builder
+
+	elements := []string{}
+
+	for _, e := range o.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+func (o *Array) Type() object.ObjectType {
+	return object.ARRAY
+}
@@ -17,3 +17,13 @@ func (o *Boolean) Inspect() string {
 func (o *Boolean) Type() object.ObjectType {
 	return object.BOOLEAN
 }
+
+func (o *Boolean) HashKey() object.HashKey {
+	var value uint64
+
+	if o.Value {
+		value = 1
+	}
+
+	return object.HashKey{Type: o.Type(), Value: value}
+}
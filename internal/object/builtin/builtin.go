@@ -0,0 +1,23 @@
+package builtin
+
+import (
+	"github.com/w-h-a/interpreter/internal/object"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+)
+
+// a builtin is given the environment it is called from, for what it needs of
+// the program around it, such as where its output goes
+type BuiltinFunction func(env *environment.Environment, args ...object.Object) object.Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (o *Builtin) Inspect() string {
+	return "builtin function " + o.Name
+}
+
+func (o *Builtin) Type() object.ObjectType {
+	return object.BUILTIN
+}
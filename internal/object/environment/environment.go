@@ -0,0 +1,163 @@
+package environment
+
+import (
+	"io"
+	"os"
+	"sort"
+
+	"github.com/w-h-a/interpreter/internal/object"
+	"github.com/w-h-a/interpreter/internal/symbol"
+)
+
+type Importer interface {
+	Import(path string) object.Object
+}
+
+// a frame keeps the names the resolver gave slots in slots, indexed in the
+// order of names, and anything else in store
+type Environment struct {
+	store    map[symbol.Symbol]object.Object
+	slots    []object.Object
+	names    []symbol.Symbol
+	outer    *Environment
+	importer Importer
+	out      io.Writer
+}
+
+// a name that was never interned cannot be bound anywhere
+func (e *Environment) Get(name string) (object.Object, bool) {
+	s, ok := symbol.Lookup(name)
+	if !ok {
+		return nil, false
+	}
+	return e.GetSymbol(s)
+}
+
+func (e *Environment) GetSymbol(s symbol.Symbol) (object.Object, bool) {
+	for env := e; env != nil; env = env.outer {
+		for i, name := range env.names {
+			if name == s && env.slots[i] != nil {
+				return env.slots[i], true
+			}
+		}
+		if obj, ok := env.store[s]; ok {
+			return obj, true
+		}
+	}
+	return nil, false
+}
+
+// GetSlot reads slot of the frame depth frames out; a negative slot, or one
+// not set yet, is looked up by name from that frame
+func (e *Environment) GetSlot(depth, slot int, s symbol.Symbol) (object.Object, bool) {
+	env := e
+	for range depth {
+		if env.outer == nil {
+			return e.GetSymbol(s)
+		}
+		env = env.outer
+	}
+
+	if slot >= 0 && slot < len(env.slots) {
+		if obj := env.slots[slot]; obj != nil {
+			return obj, true
+		}
+	}
+
+	return env.GetSymbol(s)
+}
+
+func (e *Environment) Set(name string, val object.Object) object.Object {
+	return e.SetSymbol(symbol.Intern(name), val)
+}
+
+func (e *Environment) SetSymbol(s symbol.Symbol, val object.Object) object.Object {
+	for i, name := range e.names {
+		if name == s {
+			e.slots[i] = val
+			return val
+		}
+	}
+	if e.store == nil {
+		e.store = map[symbol.Symbol]object.Object{}
+	}
+	e.store[s] = val
+	return val
+}
+
+func (e *Environment) SetSlot(slot int, s symbol.Symbol, val object.Object) object.Object {
+	if slot < 0 || slot >= len(e.slots) {
+		return e.SetSymbol(s, val)
+	}
+	e.slots[slot] = val
+	return val
+}
+
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store)+len(e.names))
+	for s := range e.store {
+		names = append(names, s.String())
+	}
+	for i, s := range e.names {
+		if e.slots[i] != nil {
+			names = append(names, s.String())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *Environment) Importer() Importer {
+	if e.importer == nil && e.outer != nil {
+		return e.outer.Importer()
+	}
+	return e.importer
+}
+
+// SetImporter changes where the imports evaluated in e are resolved from,
+// and gives back the importer it had
+func (e *Environment) SetImporter(importer Importer) Importer {
+	previous := e.importer
+	e.importer = importer
+	return previous
+}
+
+// Output is where the program's output goes, standard output unless the
+// module it runs in was given somewhere else
+func (e *Environment) Output() io.Writer {
+	for env := e; env != nil; env = env.outer {
+		if env.out != nil {
+			return env.out
+		}
+	}
+	return os.Stdout
+}
+
+func New() *Environment {
+	return &Environment{
+		store: map[symbol.Symbol]object.Object{},
+	}
+}
+
+func NewEnclosed(outer *Environment) *Environment {
+	env := New()
+	env.outer = outer
+	return env
+}
+
+// NewFrame is the environment of a call to a resolved function, with a slot
+// for each of names; its store is only made if something is bound by name
+func NewFrame(outer *Environment, names []symbol.Symbol) *Environment {
+	return &Environment{
+		slots: make([]object.Object, len(names)),
+		names: names,
+		outer: outer,
+	}
+}
+
+func NewModule(importer Importer, out io.Writer) *Environment {
+	env := New()
+	env.importer = importer
+	env.out = out
+	return env
+}
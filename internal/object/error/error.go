@@ -0,0 +1,15 @@
+package errorobject
+
+import "github.com/w-h-a/interpreter/internal/object"
+
+type Error struct {
+	Message string
+}
+
+func (o *Error) Inspect() string {
+	return "ERROR: " + o.Message
+}
+
+func (o *Error) Type() object.ObjectType {
+	return object.ERROR
+}
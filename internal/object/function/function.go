@@ -0,0 +1,87 @@
+package function
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/object"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	"github.com/w-h-a/interpreter/internal/symbol"
+)
+
+type Function struct {
+	Name       string
+	Parameters []*identifier.Identifier
+	Defaults   []expression.Expression
+	Rest       *identifier.Identifier
+	Body       *block.Block
+	Frame      []symbol.Symbol
+	Env        *environment.Environment
+}
+
+func (o *Function) Inspect() string {
+	var out strings.Builder
+
+	params := []string{}
+
+	for i, p := range o.Parameters {
+		if i < len(o.Defaults) && o.Defaults[i] != nil {
+			params = append(params, p.String()+" = "+o.Defaults[i].String())
+			continue
+		}
+		params = append(params, p.String())
+	}
+
+	if o.Rest != nil {
+		params = append(params, ".."+o.Rest.String())
+	}
+
+	out.WriteString("fn")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(o.Body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
+func (o *Function) Type() object.ObjectType {
+	return object.FUNCTION
+}
+
+func (o *Function) DisplayName() string {
+	if len(o.Name) == 0 {
+		return "<anonymous>"
+	}
+	return o.Name
+}
+
+func (o *Function) Required() int {
+	required := 0
+
+	for i := range o.Parameters {
+		if i < len(o.Defaults) && o.Defaults[i] != nil {
+			break
+		}
+		required += 1
+	}
+
+	return required
+}
+
+func (o *Function) Arity() string {
+	required := o.Required()
+
+	switch {
+	case o.Rest != nil:
+		return fmt.Sprintf("at least %d", required)
+	case required != len(o.Parameters):
+		return fmt.Sprintf("%d to %d", required, len(o.Parameters))
+	default:
+		return fmt.Sprintf("%d", required)
+	}
+}
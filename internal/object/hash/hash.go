@@ -0,0 +1,63 @@
+package hash
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/object"
+)
+
+type Pair struct {
+	Key   object.Object
+	Value object.Object
+}
+
+type Hash struct {
+	Pairs map[object.HashKey]Pair
+	Keys  []object.HashKey
+}
+
+func (o *Hash) Inspect() string {
+	var out strings.Builder
+
+	pairs := []string{}
+
+	for _, key := range o.Keys {
+		pair := o.Pairs[key]
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (o *Hash) Type() object.ObjectType {
+	return object.HASH
+}
+
+func (o *Hash) Get(key object.Hashable) (object.Object, bool) {
+	pair, ok := o.Pairs[key.HashKey()]
+	if !ok {
+		return nil, false
+	}
+	return pair.Value, true
+}
+
+func (o *Hash) Set(key object.Hashable, value object.Object) {
+	hashKey := key.HashKey()
+
+	if _, ok := o.Pairs[hashKey]; !ok {
+		o.Keys = append(o.Keys, hashKey)
+	}
+
+	o.Pairs[hashKey] = Pair{Key: key, Value: value}
+}
+
+func New() *Hash {
+	return &Hash{
+		Pairs: map[object.HashKey]Pair{},
+		Keys:  []object.HashKey{},
+	}
+}
@@ -17,3 +17,7 @@ func (o *Integer) Inspect() string {
 func (o *Integer) Type() object.ObjectType {
 	return object.INTEGER
 }
+
+func (o *Integer) HashKey() object.HashKey {
+	return object.HashKey{Type: o.Type(), Value: uint64(o.Value)}
+}
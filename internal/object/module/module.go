@@ -0,0 +1,30 @@
+package module
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/object"
+)
+
+type Module struct {
+	Name    string
+	Path    string
+	Names   []string
+	Exports map[string]object.Object
+}
+
+func (o *Module) Inspect() string {
+	var out strings.Builder
+
+	out.WriteString("module ")
+	out.WriteString(o.Name)
+	out.WriteString(" {")
+	out.WriteString(strings.Join(o.Names, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (o *Module) Type() object.ObjectType {
+	return object.MODULE
+}
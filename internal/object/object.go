@@ -3,12 +3,30 @@ package object
 type ObjectType string
 
 const (
-	INTEGER ObjectType = "INTEGER"
-	BOOLEAN ObjectType = "BOOLEAN"
-	NULL    ObjectType = "NULL"
+	INTEGER      ObjectType = "INTEGER"
+	BOOLEAN      ObjectType = "BOOLEAN"
+	NULL         ObjectType = "NULL"
+	RETURN_VALUE ObjectType = "RETURN_VALUE"
+	ERROR        ObjectType = "ERROR"
+	FUNCTION     ObjectType = "FUNCTION"
+	ARRAY        ObjectType = "ARRAY"
+	STRING       ObjectType = "STRING"
+	HASH         ObjectType = "HASH"
+	BUILTIN      ObjectType = "BUILTIN"
+	MODULE       ObjectType = "MODULE"
 )
 
 type Object interface {
 	Type() ObjectType
 	Inspect() string
 }
+
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
@@ -0,0 +1,15 @@
+package returnvalue
+
+import "github.com/w-h-a/interpreter/internal/object"
+
+type ReturnValue struct {
+	Value object.Object
+}
+
+func (o *ReturnValue) Inspect() string {
+	return o.Value.Inspect()
+}
+
+func (o *ReturnValue) Type() object.ObjectType {
+	return object.RETURN_VALUE
+}
@@ -0,0 +1,25 @@
+package stringobject
+
+import (
+	"hash/fnv"
+
+	"github.com/w-h-a/interpreter/internal/object"
+)
+
+type String struct {
+	Value string
+}
+
+func (o *String) Inspect() string {
+	return o.Value
+}
+
+func (o *String) Type() object.ObjectType {
+	return object.STRING
+}
+
+func (o *String) HashKey() object.HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(o.Value))
+	return object.HashKey{Type: o.Type(), Value: h.Sum64()}
+}
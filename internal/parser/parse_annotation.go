@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+// parseAnnotation reads the type after `:` or `->`, from the current token to
+// its last one
+func (p *Parser) parseAnnotation() (annotation.Annotation, error) {
+	first := p.pulled - 2
+
+	var a annotation.Annotation
+	var err error
+
+	switch p.curToken.Type {
+	case token.Ident, token.Null:
+		a, err = p.parseNamedAnnotation()
+	case token.BracketLeft:
+		a, err = p.parseArrayAnnotation()
+	case token.BraceLeft:
+		a, err = p.parseHashAnnotation()
+	case token.Function:
+		a, err = p.parseFunctionAnnotation()
+	default:
+		err = fmt.Errorf("expected a type, got %s", p.curToken.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.record(a, first)
+
+	return a, nil
+}
+
+func (p *Parser) parseNamedAnnotation() (annotation.Annotation, error) {
+	name := p.curToken.Literal()
+
+	if !annotation.IsName(name) {
+		return nil, fmt.Errorf("unknown type %s", name)
+	}
+
+	return &annotation.Named{Token: p.curToken, Name: name}, nil
+}
+
+func (p *Parser) parseArrayAnnotation() (annotation.Annotation, error) {
+	a := &annotation.Array{Token: p.curToken}
+
+	p.nextToken() // consume '['
+
+	var err error
+
+	a.Element, err = p.parseAnnotation()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectAnnotationEnd(token.BracketRight); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (p *Parser) parseHashAnnotation() (annotation.Annotation, error) {
+	a := &annotation.Hash{Token: p.curToken}
+
+	p.nextToken() // consume '{'
+
+	var err error
+
+	a.Key, err = p.parseAnnotation()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectAnnotationEnd(token.Colon); err != nil {
+		return nil, err
+	}
+
+	p.nextToken() // consume ':'
+
+	a.Value, err = p.parseAnnotation()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectAnnotationEnd(token.BraceRight); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (p *Parser) parseFunctionAnnotation() (annotation.Annotation, error) {
+	a := &annotation.Function{Token: p.curToken, Parameters: []annotation.Annotation{}}
+
+	if err := p.expectAnnotationEnd(token.ParenLeft); err != nil {
+		return nil, err
+	}
+
+	for p.peekToken.Type != token.ParenRight {
+		p.nextToken() // consume '(' or ','
+
+		param, err := p.parseAnnotation()
+		if err != nil {
+			return nil, err
+		}
+
+		a.Parameters = append(a.Parameters, param)
+
+		if p.peekToken.Type != token.ParenRight && p.peekToken.Type != token.Comma {
+			errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.ParenRight, p.peekToken.Type)
+			return nil, errors.New(errDetail)
+		}
+
+		if p.peekToken.Type == token.Comma {
+			p.nextToken() // consume parameter
+		}
+	}
+
+	p.nextToken() // consume last parameter
+
+	if !isArrow(p.peekToken) {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.Arrow, p.peekToken.Type)
+		return nil, errors.New(errDetail)
+	}
+
+	p.nextToken() // consume ')'
+	p.nextToken() // consume '->'
+
+	var err error
+
+	a.Result, err = p.parseAnnotation()
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// expectAnnotationEnd moves onto the next token when it is expected
+func (p *Parser) expectAnnotationEnd(expected token.TokenType) error {
+	if p.peekToken.Type != expected {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", expected, p.peekToken.Type)
+		return errors.New(errDetail)
+	}
+
+	p.nextToken()
+
+	return nil
+}
+
+// isArrow reports whether tk is the `->` before a result type; a program that
+// declares `->` as an operator gets it lexed as one, and it still is
+func isArrow(tk token.Token) bool {
+	return tk.Type == token.Arrow || tk.Type == token.Operator && tk.Literal() == "->"
+}
+
+// parseOptionalAnnotation reads `: type` after a name, if there is one
+func (p *Parser) parseOptionalAnnotation() (annotation.Annotation, error) {
+	if p.peekToken.Type != token.Colon {
+		return nil, nil
+	}
+
+	p.nextToken() // consume name
+	p.nextToken() // consume ':'
+
+	return p.parseAnnotation()
+}
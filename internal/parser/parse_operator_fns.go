@@ -1,10 +1,17 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/w-h-a/interpreter/internal/ast/expression"
 	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
 	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
 	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	"github.com/w-h-a/interpreter/internal/token"
 )
 
 type (
@@ -39,6 +46,38 @@ func parseInfixOperatorExpression(p *Parser, left expression.Expression) (expres
 
 	precedence := p.currentPrecendence()
 
+	p.operator = p.curToken
+
+	p.nextToken()
+
+	var err error
+
+	expression.Right, err = p.parseExpression(precedence)
+	if err != nil {
+		return nil, err
+	}
+
+	return expression, nil
+}
+
+func parseDeclaredOperatorExpression(p *Parser, left expression.Expression) (expression.Expression, error) {
+	expression := &infixoperator.InfixOperator{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal(),
+		Left:     left,
+	}
+
+	fixity := p.fixity(p.curToken)
+
+	// a right-associative operator lets its right operand take another
+	// operator of the same level
+	precedence := fixity.Precedence
+	if fixity.Associativity == token.InfixR {
+		precedence -= 1
+	}
+
+	p.operator = p.curToken
+
 	p.nextToken()
 
 	var err error
@@ -63,3 +102,68 @@ func parseCallExpression(p *Parser, function expression.Expression) (expression.
 
 	return exp, nil
 }
+
+func parsePipelineExpression(p *Parser, left expression.Expression) (expression.Expression, error) {
+	exp := &pipeline.Pipeline{Token: p.curToken, Left: left}
+
+	precedence := p.currentPrecendence()
+
+	p.operator = p.curToken
+
+	p.nextToken()
+
+	var err error
+
+	exp.Right, err = p.parseExpression(precedence)
+	if err != nil {
+		return nil, err
+	}
+
+	return exp, nil
+}
+
+func parseIndexExpression(p *Parser, left expression.Expression) (expression.Expression, error) {
+	exp := &index.Index{Token: p.curToken, Left: left}
+
+	if err := p.parseIndex(exp); err != nil {
+		return nil, err
+	}
+
+	return exp, nil
+}
+
+func parseFieldExpression(p *Parser, left expression.Expression) (expression.Expression, error) {
+	exp := &field.Field{Token: p.curToken, Left: left}
+
+	if err := p.parseField(exp); err != nil {
+		return nil, err
+	}
+
+	return exp, nil
+}
+
+func parseOptionalChainExpression(p *Parser, left expression.Expression) (expression.Expression, error) {
+	switch p.peekToken.Type {
+	case token.BracketLeft:
+		exp := &index.Index{Token: p.curToken, Left: left, Optional: true}
+
+		p.nextToken() // consume '?.'
+
+		if err := p.parseIndex(exp); err != nil {
+			return nil, err
+		}
+
+		return exp, nil
+	case token.Ident:
+		exp := &field.Field{Token: p.curToken, Left: left, Optional: true}
+
+		if err := p.parseField(exp); err != nil {
+			return nil, err
+		}
+
+		return exp, nil
+	default:
+		errDetail := fmt.Sprintf("expected next token to be %s or %s, got %s", token.BracketLeft, token.Ident, p.peekToken.Type)
+		return nil, errors.New(errDetail)
+	}
+}
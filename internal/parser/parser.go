@@ -5,54 +5,162 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
 	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
 	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
 	"github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
 	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
 	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
 	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
 	"github.com/w-h-a/interpreter/internal/ast/statement"
 	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
 	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
 	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
 	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+	"github.com/w-h-a/interpreter/internal/lexer"
 	"github.com/w-h-a/interpreter/internal/token"
 )
 
+type TokenSource interface {
+	NextToken() token.Token
+}
+
+// a source that explains its ILLEGAL tokens, like *lexer.Lexer
+type DiagnosticSource interface {
+	TokenSource
+	Diagnostics() []lexer.Diagnostic
+}
+
+// a source that reads declared operators as single tokens, like *lexer.Lexer
+type OperatorSource interface {
+	TokenSource
+	Operators() token.Operators
+}
+
 type Parser struct {
-	tokens         chan token.Token
+	tokens         TokenSource
 	curToken       token.Token
 	peekToken      token.Token
 	parsePrefixFns map[token.TokenType]parsePrefixFn
 	parseInfixFns  map[token.TokenType]parseInfixFn
+	operators      token.Operators
+	operator       token.Token
 	errors         []string
+	diagnostics    []lexer.Diagnostic
+	reported       int
+	statementStart int
+	pulled         int
+	recording      bool
+	spans          []Span
+}
+
+// Span is the run of tokens a node was parsed from, counted in the order the
+// parser pulled them from its source
+type Span struct {
+	Node  ast.Node
+	First int
+	Last  int
+}
+
+type Parsed struct {
+	Statement statement.Statement
+	Start     token.Position
+	Errors    []string
 }
 
 func (p *Parser) ParseProgram() *statement.Program {
 	program := &statement.Program{}
 	program.Statements = []statement.Statement{}
 
-	for p.curToken.Type != token.EOF {
-		stmt, err := p.parseStatement()
-		if err == nil {
-			program.Statements = append(program.Statements, stmt)
+	for {
+		parsed, ok := p.Next()
+		if !ok {
+			break
+		}
+		if parsed.Statement != nil {
+			program.Statements = append(program.Statements, parsed.Statement)
 		}
-		p.nextToken()
 	}
 
 	return program
 }
 
+func (p *Parser) Next() (Parsed, bool) {
+	if p.curToken.Type == token.EOF {
+		return Parsed{}, false
+	}
+
+	parsed := Parsed{Start: p.curToken.Start()}
+
+	stmt, err := p.parseStatement()
+	if err == nil {
+		parsed.Statement = stmt
+	}
+
+	parsed.Errors = p.errors[p.statementStart:len(p.errors):len(p.errors)]
+
+	// lexer diagnostics for the next statement's first token belong to it
+	p.statementStart = len(p.errors)
+
+	p.nextToken()
+
+	return parsed, true
+}
+
 func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// RecordSpans makes the parser remember which tokens each statement, block
+// and expression came from; a parenthesized expression is recorded twice
+func (p *Parser) RecordSpans() {
+	p.recording = true
+}
+
+func (p *Parser) Spans() []Span {
+	return p.spans
+}
+
+func (p *Parser) record(node ast.Node, first int) {
+	if p.recording {
+		p.spans = append(p.spans, Span{Node: node, First: first, Last: p.pulled - 2})
+	}
+}
+
 func (p *Parser) parseStatement() (statement.Statement, error) {
+	first := p.pulled - 2
+
+	stmt, err := p.parseStatementKind()
+	if err == nil {
+		p.record(stmt, first)
+	}
+
+	return stmt, err
+}
+
+func (p *Parser) parseStatementKind() (statement.Statement, error) {
 	switch p.curToken.Type {
 	case token.Return:
 		return p.parseReturnStatement()
 	case token.Let:
 		return p.parseLetStatement()
+	case token.Import:
+		return p.parseImportStatement()
+	case token.Export:
+		return p.parseExportStatement()
+	case token.InfixL, token.InfixR, token.Infix:
+		return p.parseOperatorStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -90,22 +198,166 @@ func (p *Parser) parseLetStatement() (*let.Let, error) {
 
 	stmt.Name = &identifier.Identifier{Token: p.curToken, Value: p.curToken.Literal()}
 
+	var err error
+
+	stmt.Type, err = p.parseOptionalAnnotation()
+	if err != nil {
+		p.appendError(err.Error())
+		return nil, err
+	}
+
 	if p.peekToken.Type != token.Assign {
 		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.Assign, p.peekToken.Type)
 		p.appendError(errDetail)
 		return nil, errors.New(errDetail)
 	}
 
-	p.nextToken() // consume identifier
+	p.nextToken() // consume identifier or type
 	p.nextToken() // consume assignment
 
+	stmt.Value, err = p.parseExpression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	if fn, ok := stmt.Value.(*function.Function); ok {
+		fn.Name = stmt.Name.Value
+	}
+
+	if p.peekToken.Type == token.Semicolon {
+		p.nextToken()
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseImportStatement() (*importstatement.Import, error) {
+	stmt := &importstatement.Import{Token: p.curToken}
+
+	if p.peekToken.Type != token.String {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.String, p.peekToken.Type)
+		p.appendError(errDetail)
+		return nil, errors.New(errDetail)
+	}
+
+	p.nextToken() // consume 'import'
+
+	path, err := p.parseStringExpression()
+	if err != nil {
+		p.appendError(err.Error())
+		return nil, err
+	}
+
+	stmt.Path = path.(*stringexpression.String)
+
+	if p.peekToken.Type != token.As {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.As, p.peekToken.Type)
+		p.appendError(errDetail)
+		return nil, errors.New(errDetail)
+	}
+
+	p.nextToken() // consume path
+
+	if p.peekToken.Type != token.Ident {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.Ident, p.peekToken.Type)
+		p.appendError(errDetail)
+		return nil, errors.New(errDetail)
+	}
+
+	p.nextToken() // consume 'as'
+
+	stmt.Alias = &identifier.Identifier{Token: p.curToken, Value: p.curToken.Literal()}
+
+	if p.peekToken.Type == token.Semicolon {
+		p.nextToken()
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseExportStatement() (*export.Export, error) {
+	stmt := &export.Export{Token: p.curToken}
+
+	if p.peekToken.Type != token.Let {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.Let, p.peekToken.Type)
+		p.appendError(errDetail)
+		return nil, errors.New(errDetail)
+	}
+
+	p.nextToken() // consume 'export'
+
 	var err error
 
+	stmt.Let, err = p.parseLetStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseOperatorStatement() (*operator.Operator, error) {
+	stmt := &operator.Operator{Token: p.curToken}
+
+	associativity := p.curToken.Type
+
+	if p.peekToken.Type != token.Int {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.Int, p.peekToken.Type)
+		p.appendError(errDetail)
+		return nil, errors.New(errDetail)
+	}
+
+	p.nextToken() // consume 'infixl', 'infixr' or 'infix'
+
+	precedence, err := p.parseIntegerExpression()
+	if err != nil {
+		p.appendError(err.Error())
+		return nil, err
+	}
+
+	stmt.Precedence = precedence.(*integer.Integer)
+
+	// declared operators share the levels of the built-in binary operators
+	if stmt.Precedence.Value < int64(PIPE) || stmt.Precedence.Value > int64(PRODUCT) {
+		errDetail := fmt.Sprintf("operator precedence must be between %d and %d, got %d", PIPE, PRODUCT, stmt.Precedence.Value)
+		p.appendError(errDetail)
+		return nil, errors.New(errDetail)
+	}
+
+	if p.peekToken.Type != token.Operator {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.Operator, p.peekToken.Type)
+		p.appendError(errDetail)
+		return nil, errors.New(errDetail)
+	}
+
+	p.nextToken() // consume precedence
+
+	stmt.Name = &identifier.Identifier{Token: p.curToken, Value: p.curToken.Literal()}
+
+	// declared before its value so the operator can be used recursively
+	p.operators[stmt.Name.Value] = token.Fixity{
+		Precedence:    int(stmt.Precedence.Value),
+		Associativity: associativity,
+	}
+
+	if p.peekToken.Type != token.Assign {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.Assign, p.peekToken.Type)
+		p.appendError(errDetail)
+		return nil, errors.New(errDetail)
+	}
+
+	p.nextToken() // consume operator
+	p.nextToken() // consume assignment
+
 	stmt.Value, err = p.parseExpression(LOWEST)
 	if err != nil {
 		return nil, err
 	}
 
+	if fn, ok := stmt.Value.(*function.Function); ok {
+		fn.Name = stmt.Name.Value
+	}
+
 	if p.peekToken.Type == token.Semicolon {
 		p.nextToken()
 	}
@@ -116,6 +368,8 @@ func (p *Parser) parseLetStatement() (*let.Let, error) {
 func (p *Parser) parseBlockStatement() (*block.Block, error) {
 	stmt := &block.Block{Token: p.curToken}
 
+	first := p.pulled - 2
+
 	stmt.Statements = []statement.Statement{}
 
 	p.nextToken() // consume '{'
@@ -128,6 +382,13 @@ func (p *Parser) parseBlockStatement() (*block.Block, error) {
 		p.nextToken()
 	}
 
+	if p.curToken.Type == token.EOF {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.BraceRight, token.EOF)
+		return nil, errors.New(errDetail)
+	}
+
+	p.record(stmt, first)
+
 	return stmt, nil
 }
 
@@ -149,6 +410,13 @@ func (p *Parser) parseExpression(precedence int) (expression.Expression, error)
 	var exp expression.Expression
 	var err error
 
+	first := p.pulled - 2
+
+	// the operator to the left of this operand, if any, so that chaining it
+	// with the next one can be checked whichever side binds
+	last := p.operator
+	p.operator = token.Token{}
+
 	switch p.curToken.Type {
 	case token.Ident:
 		exp, err = p.parseIdentifierExpression()
@@ -162,9 +430,24 @@ func (p *Parser) parseExpression(precedence int) (expression.Expression, error)
 		exp, err = p.parseIntegerExpression()
 	case token.True, token.False:
 		exp, err = p.parseBooleanExpression()
+	case token.String:
+		exp, err = p.parseStringExpression()
+	case token.StringStart:
+		exp, err = p.parseInterpolationExpression()
+	case token.Null:
+		exp, err = p.parseNullExpression()
+	case token.BracketLeft:
+		exp, err = p.parseArrayExpression()
+	case token.BraceLeft:
+		exp, err = p.parseHashExpression()
 	default:
 		parsePrefixExpression := p.parsePrefixFns[p.curToken.Type]
 
+		if d, ok := p.diagnosticAt(p.curToken); ok {
+			// the lexer has already reported it
+			return nil, errors.New(d.String())
+		}
+
 		if parsePrefixExpression == nil {
 			errDetail := fmt.Sprintf("no parse function for %s found", p.curToken.Type)
 			p.appendError(errDetail)
@@ -180,6 +463,8 @@ func (p *Parser) parseExpression(precedence int) (expression.Expression, error)
 		return nil, fmt.Errorf("%s: %w", errDetail, err)
 	}
 
+	p.record(exp, first)
+
 	for p.peekToken.Type != token.Semicolon && precedence < p.peekPrecedence() {
 		parseInfixExpression := p.parseInfixFns[p.peekToken.Type]
 
@@ -187,14 +472,24 @@ func (p *Parser) parseExpression(precedence int) (expression.Expression, error)
 			return exp, nil
 		}
 
+		if err := p.chain(last, p.peekToken); err != nil {
+			errDetail := fmt.Sprintf("failed to parse infix expression literal %q", p.curToken.Literal())
+			p.appendError(fmt.Sprintf("%s: %v", errDetail, err))
+			return nil, fmt.Errorf("%s: %w", errDetail, err)
+		}
+
 		p.nextToken()
 
+		last = p.curToken
+
 		exp, err = parseInfixExpression(p, exp)
 		if err != nil {
 			errDetail := fmt.Sprintf("failed to parse infix expression literal %q", p.curToken.Literal())
 			p.appendError(fmt.Sprintf("%s: %v", errDetail, err))
 			return nil, fmt.Errorf("%s: %w", errDetail, err)
 		}
+
+		p.record(exp, first)
 	}
 
 	return exp, nil
@@ -255,7 +550,10 @@ func (p *Parser) parseIfExpression() (expression.Expression, error) {
 
 	p.nextToken() // move to '{'
 
-	exp.Consequence, _ = p.parseBlockStatement()
+	exp.Consequence, err = p.parseBlockStatement()
+	if err != nil {
+		return nil, err
+	}
 
 	if p.peekToken.Type == token.Else {
 		p.nextToken() // move to 'else'
@@ -267,47 +565,54 @@ func (p *Parser) parseIfExpression() (expression.Expression, error) {
 
 		p.nextToken() // move to '{'
 
-		exp.Alternative, _ = p.parseBlockStatement()
+		exp.Alternative, err = p.parseBlockStatement()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return exp, nil
 }
 
 func (p *Parser) parseCallArguments() ([]expression.Expression, error) {
-	args := []expression.Expression{}
+	return p.parseExpressionList(token.ParenRight)
+}
 
-	if p.peekToken.Type == token.ParenRight {
+func (p *Parser) parseExpressionList(end token.TokenType) ([]expression.Expression, error) {
+	list := []expression.Expression{}
+
+	if p.peekToken.Type == end {
 		p.nextToken()
-		return args, nil
+		return list, nil
 	}
 
-	p.nextToken() // consume '('
+	p.nextToken() // consume opening delimiter
 
 	exp, err := p.parseExpression(LOWEST)
 	if err != nil {
 		return nil, err
 	}
 
-	args = append(args, exp)
+	list = append(list, exp)
 
 	for p.peekToken.Type == token.Comma {
-		p.nextToken() // consume previous arg
+		p.nextToken() // consume previous element
 		p.nextToken() // consume ','
 		exp, err := p.parseExpression(LOWEST)
 		if err != nil {
 			return nil, err
 		}
-		args = append(args, exp)
+		list = append(list, exp)
 	}
 
-	if p.peekToken.Type != token.ParenRight {
-		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.ParenRight, p.peekToken.Type)
+	if p.peekToken.Type != end {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", end, p.peekToken.Type)
 		return nil, errors.New(errDetail)
 	}
 
-	p.nextToken() // consume last arg
+	p.nextToken() // consume last element
 
-	return args, nil
+	return list, nil
 }
 
 func (p *Parser) parseFunctionExpression() (expression.Expression, error) {
@@ -320,62 +625,129 @@ func (p *Parser) parseFunctionExpression() (expression.Expression, error) {
 
 	p.nextToken() // consume 'fn'
 
-	var err error
-
-	exp.Parameters, err = p.parseFunctionParameters()
-	if err != nil {
+	if err := p.parseFunctionParameters(exp); err != nil {
 		return nil, err
 	}
 
+	if isArrow(p.peekToken) {
+		p.nextToken() // consume ')'
+		p.nextToken() // consume '->'
+
+		result, err := p.parseAnnotation()
+		if err != nil {
+			return nil, err
+		}
+
+		exp.Result = result
+	}
+
 	if p.peekToken.Type != token.BraceLeft {
 		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.BraceLeft, p.peekToken.Type)
 		return nil, errors.New(errDetail)
 	}
 
-	p.nextToken() // consume ')'
+	p.nextToken() // consume ')' or the result type
 
-	exp.Body, _ = p.parseBlockStatement()
+	var err error
+
+	exp.Body, err = p.parseBlockStatement()
+	if err != nil {
+		return nil, err
+	}
 
 	return exp, nil
 }
 
-func (p *Parser) parseFunctionParameters() ([]*identifier.Identifier, error) {
-	identifiers := []*identifier.Identifier{}
+func (p *Parser) parseFunctionParameters(exp *function.Function) error {
+	exp.Parameters = []*identifier.Identifier{}
+	exp.Types = []annotation.Annotation{}
+	exp.Defaults = []expression.Expression{}
 
 	if p.peekToken.Type == token.ParenRight {
 		p.nextToken()
-		return identifiers, nil
+		return nil
 	}
 
 	p.nextToken() // consume '('
 
-	if p.curToken.Type != token.Ident {
-		errDetail := fmt.Sprintf("expected identifier as function parameter, got %s", p.curToken.Type)
-		return nil, errors.New(errDetail)
+	if err := p.parseFunctionParameter(exp); err != nil {
+		return err
 	}
 
-	ident := &identifier.Identifier{Token: p.curToken, Value: p.curToken.Literal()}
-	identifiers = append(identifiers, ident)
-
 	for p.peekToken.Type == token.Comma {
 		p.nextToken() // consume previous param
 		p.nextToken() // consume ','
-		if p.curToken.Type != token.Ident {
-			errDetail := fmt.Sprintf("expected identifier as function parameter, got %s", p.curToken.Type)
-			return nil, errors.New(errDetail)
+		if err := p.parseFunctionParameter(exp); err != nil {
+			return err
 		}
-		ident := &identifier.Identifier{Token: p.curToken, Value: p.curToken.Literal()}
-		identifiers = append(identifiers, ident)
 	}
 
 	if p.peekToken.Type != token.ParenRight {
 		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.ParenRight, p.peekToken.Type)
-		return nil, errors.New(errDetail)
+		return errors.New(errDetail)
 	}
 
 	p.nextToken() // consume last param
 
-	return identifiers, nil
+	return nil
+}
+
+func (p *Parser) parseFunctionParameter(exp *function.Function) error {
+	if exp.Rest != nil {
+		return fmt.Errorf("rest parameter %s must be the last function parameter", exp.Rest.Value)
+	}
+
+	if p.curToken.Type == token.Spread {
+		if p.peekToken.Type != token.Ident {
+			errDetail := fmt.Sprintf("expected identifier as rest parameter, got %s", p.peekToken.Type)
+			return errors.New(errDetail)
+		}
+
+		p.nextToken() // consume '..'
+
+		exp.Rest = &identifier.Identifier{Token: p.curToken, Value: p.curToken.Literal()}
+
+		typ, err := p.parseOptionalAnnotation()
+		if err != nil {
+			return err
+		}
+
+		exp.RestType = typ
+
+		return nil
+	}
+
+	if p.curToken.Type != token.Ident {
+		errDetail := fmt.Sprintf("expected identifier as function parameter, got %s", p.curToken.Type)
+		return errors.New(errDetail)
+	}
+
+	ident := &identifier.Identifier{Token: p.curToken, Value: p.curToken.Literal()}
+
+	typ, err := p.parseOptionalAnnotation()
+	if err != nil {
+		return err
+	}
+
+	var def expression.Expression
+
+	if p.peekToken.Type == token.Assign {
+		p.nextToken() // consume param or type
+		p.nextToken() // consume '='
+
+		def, err = p.parseExpression(LOWEST)
+		if err != nil {
+			return err
+		}
+	} else if n := len(exp.Defaults); n > 0 && exp.Defaults[n-1] != nil {
+		return fmt.Errorf("parameter %s without default follows parameter with default", ident.Value)
+	}
+
+	exp.Parameters = append(exp.Parameters, ident)
+	exp.Types = append(exp.Types, typ)
+	exp.Defaults = append(exp.Defaults, def)
+
+	return nil
 }
 
 func (p *Parser) parseIntegerExpression() (expression.Expression, error) {
@@ -391,18 +763,179 @@ func (p *Parser) parseBooleanExpression() (expression.Expression, error) {
 	return &boolean.Boolean{Token: p.curToken, Value: p.curToken.Type == token.True}, nil
 }
 
-func (p *Parser) peekPrecedence() int {
-	if prec, ok := precedences[p.peekToken.Type]; ok {
-		return prec
+func (p *Parser) parseStringExpression() (expression.Expression, error) {
+	return &stringexpression.String{Token: p.curToken, Value: unescape(p.curToken.Literal())}, nil
+}
+
+func (p *Parser) parseInterpolationExpression() (expression.Expression, error) {
+	exp := &interpolation.Interpolation{Token: p.curToken}
+
+	for {
+		part, err := p.parseStringExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		exp.Parts = append(exp.Parts, part)
+
+		if p.curToken.Type == token.StringEnd {
+			return exp, nil
+		}
+
+		p.nextToken() // consume string part
+
+		embedded := &interpolation.Embedded{Token: p.curToken}
+
+		embedded.Expression, err = p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, fmt.Errorf("in interpolation at %s: %w", embedded.Token.Position(), err)
+		}
+
+		exp.Parts = append(exp.Parts, embedded)
+
+		if p.peekToken.Type != token.StringMiddle && p.peekToken.Type != token.StringEnd {
+			errDetail := fmt.Sprintf("in interpolation at %s: expected next token to be %s, got %s", embedded.Token.Position(), token.BraceRight, p.peekToken.Type)
+			return nil, errors.New(errDetail)
+		}
+
+		p.nextToken() // consume embedded expression
+	}
+}
+
+func (p *Parser) parseNullExpression() (expression.Expression, error) {
+	return &null.Null{Token: p.curToken}, nil
+}
+
+func (p *Parser) parseArrayExpression() (expression.Expression, error) {
+	exp := &array.Array{Token: p.curToken}
+
+	var err error
+
+	exp.Elements, err = p.parseExpressionList(token.BracketRight)
+	if err != nil {
+		return nil, err
+	}
+
+	return exp, nil
+}
+
+func (p *Parser) parseHashExpression() (expression.Expression, error) {
+	exp := &hash.Hash{Token: p.curToken, Pairs: []hash.Pair{}}
+
+	for p.peekToken.Type != token.BraceRight {
+		p.nextToken() // consume '{' or ','
+
+		key, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peekToken.Type != token.Colon {
+			errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.Colon, p.peekToken.Type)
+			return nil, errors.New(errDetail)
+		}
+
+		p.nextToken() // consume key
+		p.nextToken() // consume ':'
+
+		value, err := p.parseExpression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		exp.Pairs = append(exp.Pairs, hash.Pair{Key: key, Value: value})
+
+		if p.peekToken.Type != token.BraceRight && p.peekToken.Type != token.Comma {
+			errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.BraceRight, p.peekToken.Type)
+			return nil, errors.New(errDetail)
+		}
+
+		if p.peekToken.Type == token.Comma {
+			p.nextToken() // consume value
+		}
+	}
+
+	p.nextToken() // consume last pair
+
+	return exp, nil
+}
+
+func (p *Parser) parseIndex(exp *index.Index) error {
+	p.nextToken() // consume '['
+
+	var err error
+
+	exp.Index, err = p.parseExpression(LOWEST)
+	if err != nil {
+		return err
+	}
+
+	if p.peekToken.Type != token.BracketRight {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.BracketRight, p.peekToken.Type)
+		return errors.New(errDetail)
+	}
+
+	p.nextToken() // consume index
+
+	return nil
+}
+
+func (p *Parser) parseField(exp *field.Field) error {
+	if p.peekToken.Type != token.Ident {
+		errDetail := fmt.Sprintf("expected next token to be %s, got %s", token.Ident, p.peekToken.Type)
+		return errors.New(errDetail)
 	}
-	return LOWEST
+
+	p.nextToken() // consume '.' or '?.'
+
+	exp.Field = &identifier.Identifier{Token: p.curToken, Value: p.curToken.Literal()}
+
+	return nil
+}
+
+func (p *Parser) peekPrecedence() int {
+	return p.fixity(p.peekToken).Precedence
 }
 
 func (p *Parser) currentPrecendence() int {
-	if prec, ok := precedences[p.curToken.Type]; ok {
-		return prec
+	return p.fixity(p.curToken).Precedence
+}
+
+// built-in operators are all left-associative
+func (p *Parser) fixity(tk token.Token) token.Fixity {
+	if tk.Type == token.Operator {
+		if fixity, ok := p.operators[tk.Literal()]; ok {
+			return fixity
+		}
+		return token.Fixity{Precedence: LOWEST}
 	}
-	return LOWEST
+
+	if prec, ok := precedences[tk.Type]; ok {
+		return token.Fixity{Precedence: prec, Associativity: token.InfixL}
+	}
+
+	return token.Fixity{Precedence: LOWEST}
+}
+
+// chain reports whether operators a and b may share an operand without
+// parentheses: at one precedence they must both group the same way
+func (p *Parser) chain(a, b token.Token) error {
+	left, right := p.fixity(a), p.fixity(b)
+
+	if left.Precedence != right.Precedence {
+		return nil
+	}
+
+	switch {
+	case left.Associativity == token.Infix:
+		return fmt.Errorf("operator %s is non-associative, use parentheses", a.Literal())
+	case right.Associativity == token.Infix:
+		return fmt.Errorf("operator %s is non-associative, use parentheses", b.Literal())
+	case left.Associativity != right.Associativity:
+		return fmt.Errorf("cannot mix %s and %s at precedence %d without parentheses", a.Literal(), b.Literal(), left.Precedence)
+	}
+
+	return nil
 }
 
 func (p *Parser) appendError(msg string) {
@@ -411,7 +944,43 @@ func (p *Parser) appendError(msg string) {
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
-	p.peekToken = <-p.tokens
+
+	p.peekToken = p.tokens.NextToken()
+	p.pulled += 1
+
+	p.reportDiagnostics()
+}
+
+func (p *Parser) reportDiagnostics() {
+	source, ok := p.tokens.(DiagnosticSource)
+	if !ok {
+		return
+	}
+
+	p.diagnostics = source.Diagnostics()
+
+	// report problems once the parser reaches them, so they land with the
+	// statement they are in
+	for ; p.reported < len(p.diagnostics); p.reported++ {
+		if p.diagnostics[p.reported].Pos.Offset >= p.peekToken.Start().Offset && p.peekToken.Type != token.EOF {
+			break
+		}
+		p.appendError(p.diagnostics[p.reported].String())
+	}
+}
+
+func (p *Parser) diagnosticAt(tk token.Token) (lexer.Diagnostic, bool) {
+	if tk.Type != token.Illegal {
+		return lexer.Diagnostic{}, false
+	}
+
+	for i := p.reported - 1; i >= 0; i-- {
+		if p.diagnostics[i].Pos.Offset == tk.Start().Offset {
+			return p.diagnostics[i], true
+		}
+	}
+
+	return lexer.Diagnostic{}, false
 }
 
 func (p *Parser) registerParsePrefixFn(tokenType token.TokenType, fn parsePrefixFn) {
@@ -422,12 +991,17 @@ func (p *Parser) registerParseInfixFn(tokenType token.TokenType, fn parseInfixFn
 	p.parseInfixFns[tokenType] = fn
 }
 
-func New(tks chan token.Token) *Parser {
+func New(tks TokenSource) *Parser {
 	p := &Parser{
 		tokens:         tks,
 		errors:         []string{},
 		parsePrefixFns: map[token.TokenType]parsePrefixFn{},
 		parseInfixFns:  map[token.TokenType]parseInfixFn{},
+		operators:      token.Operators{},
+	}
+
+	if source, ok := tks.(OperatorSource); ok {
+		p.operators = source.Operators()
 	}
 
 	p.registerParsePrefixFn(token.Bang, parsePrefixOperatorExpression)
@@ -442,6 +1016,12 @@ func New(tks chan token.Token) *Parser {
 	p.registerParseInfixFn(token.Asterisk, parseInfixOperatorExpression)
 	p.registerParseInfixFn(token.Slash, parseInfixOperatorExpression)
 	p.registerParseInfixFn(token.ParenLeft, parseCallExpression)
+	p.registerParseInfixFn(token.Pipe, parsePipelineExpression)
+	p.registerParseInfixFn(token.Coalesce, parseInfixOperatorExpression)
+	p.registerParseInfixFn(token.BracketLeft, parseIndexExpression)
+	p.registerParseInfixFn(token.Dot, parseFieldExpression)
+	p.registerParseInfixFn(token.OptionalChain, parseOptionalChainExpression)
+	p.registerParseInfixFn(token.Operator, parseDeclaredOperatorExpression)
 
 	p.nextToken()
 	p.nextToken()
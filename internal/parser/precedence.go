@@ -4,24 +4,38 @@ import "github.com/w-h-a/interpreter/internal/token"
 
 const (
 	LOWEST int = iota
+	PIPE
+	COALESCE
 	EQUALITY
 	LESSGREATER
 	SUM
 	PRODUCT
 	PREFIX
 	CALL
+	INDEX
 )
 
 var (
 	precedences = map[token.TokenType]int{
-		token.Identical:    EQUALITY,
-		token.NotIdentical: EQUALITY,
-		token.LessThan:     LESSGREATER,
-		token.GreaterThan:  LESSGREATER,
-		token.Plus:         SUM,
-		token.Minus:        SUM,
-		token.Asterisk:     PRODUCT,
-		token.Slash:        PRODUCT,
-		token.ParenLeft:    CALL,
+		token.Pipe:          PIPE,
+		token.Coalesce:      COALESCE,
+		token.Identical:     EQUALITY,
+		token.NotIdentical:  EQUALITY,
+		token.LessThan:      LESSGREATER,
+		token.GreaterThan:   LESSGREATER,
+		token.Plus:          SUM,
+		token.Minus:         SUM,
+		token.Asterisk:      PRODUCT,
+		token.Slash:         PRODUCT,
+		token.ParenLeft:     CALL,
+		token.BracketLeft:   INDEX,
+		token.Dot:           INDEX,
+		token.OptionalChain: INDEX,
 	}
 )
+
+// Precedence is how tightly a built-in infix operator binds
+func Precedence(t token.TokenType) (int, bool) {
+	prec, ok := precedences[t]
+	return prec, ok
+}
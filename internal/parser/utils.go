@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"strings"
+)
+
+// unescape reads the escapes in a string literal; the lexer reports the
+// invalid ones, so here they just stand for the character after the slash
+func unescape(literal string) string {
+	if !strings.ContainsRune(literal, '\\') {
+		return literal
+	}
+
+	var out strings.Builder
+
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '\\' {
+			out.WriteByte(literal[i])
+			continue
+		}
+
+		i += 1
+
+		if i >= len(literal) {
+			break
+		}
+
+		switch literal[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		default:
+			out.WriteByte(literal[i])
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,34 @@
+package pretty
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	RESET   = "\x1b[0m"
+	RED     = "\x1b[31m"
+	GREEN   = "\x1b[32m"
+	YELLOW  = "\x1b[33m"
+	BLUE    = "\x1b[34m"
+	MAGENTA = "\x1b[35m"
+	CYAN    = "\x1b[36m"
+	DIM     = "\x1b[2m"
+)
+
+var escapes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func paint(enabled bool, colour, text string) string {
+	if !enabled || len(colour) == 0 || len(text) == 0 {
+		return text
+	}
+	return colour + text + RESET
+}
+
+func Strip(text string) string {
+	return escapes.ReplaceAllString(text, "")
+}
+
+func width(text string) int {
+	return utf8.RuneCountInString(Strip(text))
+}
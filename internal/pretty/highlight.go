@@ -0,0 +1,89 @@
+package pretty
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+var tokenColours = map[token.TokenType]string{
+	token.Illegal:       RED,
+	token.Int:           YELLOW,
+	token.True:          YELLOW,
+	token.False:         YELLOW,
+	token.Null:          YELLOW,
+	token.String:        GREEN,
+	token.StringStart:   GREEN,
+	token.StringMiddle:  GREEN,
+	token.StringEnd:     GREEN,
+	token.Function:      MAGENTA,
+	token.Let:           MAGENTA,
+	token.If:            MAGENTA,
+	token.Else:          MAGENTA,
+	token.Return:        MAGENTA,
+	token.Import:        MAGENTA,
+	token.Export:        MAGENTA,
+	token.As:            MAGENTA,
+	token.InfixL:        MAGENTA,
+	token.InfixR:        MAGENTA,
+	token.Infix:         MAGENTA,
+	token.Assign:        CYAN,
+	token.Operator:      CYAN,
+	token.Plus:          CYAN,
+	token.Minus:         CYAN,
+	token.Bang:          CYAN,
+	token.Asterisk:      CYAN,
+	token.Slash:         CYAN,
+	token.LessThan:      CYAN,
+	token.GreaterThan:   CYAN,
+	token.Identical:     CYAN,
+	token.NotIdentical:  CYAN,
+	token.Pipe:          CYAN,
+	token.Coalesce:      CYAN,
+	token.OptionalChain: CYAN,
+	token.Spread:        CYAN,
+}
+
+func Highlight(src string) string {
+	colours := make([]string, len(src))
+
+	for tk := range lexer.Tokens(src) {
+		if tk.Type == token.EOF {
+			break
+		}
+
+		colour, ok := tokenColours[tk.Type]
+		if !ok {
+			continue
+		}
+
+		start := tk.Position().Offset
+		end := start + len(tk.Literal())
+
+		// string literals do not include their quotes and ${ } delimiters
+		switch tk.Type {
+		case token.String, token.StringEnd:
+			start, end = start-1, end+1
+		case token.StringStart, token.StringMiddle:
+			start, end = start-1, end+2
+		}
+
+		for i := max(start, 0); i < min(end, len(src)); i++ {
+			colours[i] = colour
+		}
+	}
+
+	var out strings.Builder
+
+	for i := 0; i < len(src); {
+		j := i
+		for j < len(src) && colours[j] == colours[i] {
+			j++
+		}
+		out.WriteString(paint(true, colours[i], src[i:j]))
+		i = j
+	}
+
+	return out.String()
+}
@@ -0,0 +1,185 @@
+package pretty
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/w-h-a/interpreter/internal/object"
+	"github.com/w-h-a/interpreter/internal/object/array"
+	"github.com/w-h-a/interpreter/internal/object/hash"
+)
+
+const (
+	DEFAULT_MAX_ITEMS  = 100
+	DEFAULT_MAX_STRING = 1000
+	DEFAULT_WIDTH      = 80
+	INDENT             = "  "
+)
+
+type Printer struct {
+	Color     bool
+	MaxItems  int
+	MaxString int
+	Width     int
+}
+
+func (p Printer) Format(obj object.Object) (string, bool) {
+	f := &formatter{printer: p}
+	return f.value(obj, 0), f.truncated
+}
+
+type formatter struct {
+	printer   Printer
+	truncated bool
+}
+
+func (f *formatter) value(obj object.Object, depth int) string {
+	switch obj := obj.(type) {
+	case *array.Array:
+		inline := f.inline(obj)
+		if f.fits(inline, depth) {
+			return inline
+		}
+
+		items, more := f.limit(len(obj.Elements))
+
+		lines := []string{}
+		for _, e := range obj.Elements[:items] {
+			lines = append(lines, f.value(e, depth+1))
+		}
+
+		return f.block("[", "]", lines, more, depth)
+	case *hash.Hash:
+		inline := f.inline(obj)
+		if f.fits(inline, depth) {
+			return inline
+		}
+
+		items, more := f.limit(len(obj.Keys))
+
+		lines := []string{}
+		for _, key := range obj.Keys[:items] {
+			pair := obj.Pairs[key]
+			lines = append(lines, f.inline(pair.Key)+": "+f.value(pair.Value, depth+1))
+		}
+
+		return f.block("{", "}", lines, more, depth)
+	default:
+		return f.inline(obj)
+	}
+}
+
+func (f *formatter) inline(obj object.Object) string {
+	color := f.printer.Color
+
+	switch obj := obj.(type) {
+	case *array.Array:
+		items, more := f.limit(len(obj.Elements))
+
+		elements := []string{}
+		for _, e := range obj.Elements[:items] {
+			elements = append(elements, f.inline(e))
+		}
+		if more > 0 {
+			elements = append(elements, f.more(more))
+		}
+
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *hash.Hash:
+		items, more := f.limit(len(obj.Keys))
+
+		pairs := []string{}
+		for _, key := range obj.Keys[:items] {
+			pair := obj.Pairs[key]
+			pairs = append(pairs, f.inline(pair.Key)+": "+f.inline(pair.Value))
+		}
+		if more > 0 {
+			pairs = append(pairs, f.more(more))
+		}
+
+		return "{" + strings.Join(pairs, ", ") + "}"
+	}
+
+	switch obj.Type() {
+	case object.STRING:
+		return paint(color, GREEN, f.truncate(obj.Inspect()))
+	case object.INTEGER, object.BOOLEAN:
+		return paint(color, YELLOW, obj.Inspect())
+	case object.NULL:
+		return paint(color, DIM, obj.Inspect())
+	case object.FUNCTION, object.BUILTIN, object.MODULE:
+		return paint(color, BLUE, obj.Inspect())
+	case object.ERROR:
+		return paint(color, RED, obj.Inspect())
+	default:
+		return obj.Inspect()
+	}
+}
+
+func (f *formatter) block(open, close string, lines []string, more int, depth int) string {
+	var out strings.Builder
+
+	indent := strings.Repeat(INDENT, depth+1)
+
+	if more > 0 {
+		lines = append(lines, f.more(more))
+	}
+
+	out.WriteString(open)
+	out.WriteString("\n")
+
+	for i, line := range lines {
+		out.WriteString(indent)
+		out.WriteString(line)
+		if i < len(lines)-1 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n")
+	}
+
+	out.WriteString(strings.Repeat(INDENT, depth))
+	out.WriteString(close)
+
+	return out.String()
+}
+
+func (f *formatter) fits(inline string, depth int) bool {
+	if f.printer.Width <= 0 {
+		return true
+	}
+	return len(INDENT)*depth+width(inline) <= f.printer.Width
+}
+
+func (f *formatter) limit(n int) (int, int) {
+	if f.printer.MaxItems <= 0 || n <= f.printer.MaxItems {
+		return n, 0
+	}
+	f.truncated = true
+	return f.printer.MaxItems, n - f.printer.MaxItems
+}
+
+func (f *formatter) more(n int) string {
+	return paint(f.printer.Color, DIM, fmt.Sprintf("... %d more", n))
+}
+
+func (f *formatter) truncate(s string) string {
+	if f.printer.MaxString <= 0 || utf8.RuneCountInString(s) <= f.printer.MaxString {
+		return s
+	}
+
+	f.truncated = true
+
+	runes := []rune(s)
+
+	return string(runes[:f.printer.MaxString]) + paint(f.printer.Color, DIM, fmt.Sprintf("... %d more chars", len(runes)-f.printer.MaxString))
+}
+
+func New(color bool) Printer {
+	return Printer{
+		Color:     color,
+		MaxItems:  DEFAULT_MAX_ITEMS,
+		MaxString: DEFAULT_MAX_STRING,
+		Width:     DEFAULT_WIDTH,
+	}
+}
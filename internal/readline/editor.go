@@ -0,0 +1,469 @@
+package readline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyBackspace = 8
+	keyTab       = 9
+	keyLineFeed  = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+const (
+	keyUp = -(iota + 1)
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDeleteForward
+	keyUnknown
+)
+
+var ErrInterrupted = errors.New("interrupted")
+
+type Completer func() []string
+
+type Highlighter func(line string) string
+
+type Editor struct {
+	in        *bufio.Reader
+	out       io.Writer
+	history   *History
+	complete  Completer
+	highlight Highlighter
+	raw       func() (func() error, error)
+}
+
+func (e *Editor) History() *History {
+	return e.history
+}
+
+func (e *Editor) SetHighlighter(highlight Highlighter) {
+	e.highlight = highlight
+}
+
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	s := &state{editor: e, prompt: prompt, historyIndex: len(e.history.Entries())}
+
+	line, err := s.run()
+	if err != nil {
+		return "", err
+	}
+
+	// losing the history file should not cost the user their line
+	_ = e.history.Add(line)
+
+	return line, nil
+}
+
+func (e *Editor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+
+	if r != keyEscape {
+		return r, nil
+	}
+
+	// a sequence arrives all at once, so an escape with nothing after it was
+	// pressed on its own; waiting for more would block
+	if e.in.Buffered() == 0 {
+		return keyUnknown, nil
+	}
+
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+
+	params := []rune{}
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+
+		if r >= 0x40 && r <= 0x7e {
+			return escapeKey(string(params), r), nil
+		}
+
+		params = append(params, r)
+	}
+}
+
+func escapeKey(params string, final rune) rune {
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		return keyRight
+	case 'D':
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case '~':
+		switch params {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyDeleteForward
+		}
+	}
+
+	return keyUnknown
+}
+
+type state struct {
+	editor       *Editor
+	prompt       string
+	buf          []rune
+	pos          int
+	historyIndex int
+	draft        []rune
+}
+
+func (s *state) run() (string, error) {
+	s.refresh()
+
+	for {
+		key, err := s.editor.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case keyEnter, keyLineFeed:
+			s.newline()
+			return string(s.buf), nil
+		case keyCtrlC:
+			s.write("^C\r\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(s.buf) == 0 {
+				return "", io.EOF
+			}
+			s.deleteForward()
+		case keyCtrlR:
+			line, done, err := s.reverseSearch()
+			if err != nil || done {
+				return line, err
+			}
+		case keyTab:
+			s.completeWord()
+		default:
+			s.edit(key)
+		}
+	}
+}
+
+func (s *state) edit(key rune) {
+	switch key {
+	case keyCtrlA, keyHome:
+		s.pos = 0
+	case keyCtrlE, keyEnd:
+		s.pos = len(s.buf)
+	case keyCtrlB, keyLeft:
+		if s.pos > 0 {
+			s.pos -= 1
+		}
+	case keyCtrlF, keyRight:
+		if s.pos < len(s.buf) {
+			s.pos += 1
+		}
+	case keyBackspace, keyDelete:
+		if s.pos > 0 {
+			s.buf = append(s.buf[:s.pos-1], s.buf[s.pos:]...)
+			s.pos -= 1
+		}
+	case keyDeleteForward:
+		s.deleteForward()
+		return
+	case keyCtrlK:
+		s.buf = s.buf[:s.pos]
+	case keyCtrlU:
+		s.buf = s.buf[s.pos:]
+		s.pos = 0
+	case keyCtrlW:
+		start := s.pos
+		for start > 0 && unicode.IsSpace(s.buf[start-1]) {
+			start -= 1
+		}
+		for start > 0 && !unicode.IsSpace(s.buf[start-1]) {
+			start -= 1
+		}
+		s.buf = append(s.buf[:start], s.buf[s.pos:]...)
+		s.pos = start
+	case keyCtrlP, keyUp:
+		s.historyMove(-1)
+	case keyCtrlN, keyDown:
+		s.historyMove(1)
+	case keyCtrlL:
+		s.write("\x1b[H\x1b[2J")
+	default:
+		if key < 0 || unicode.IsControl(key) {
+			return
+		}
+		s.buf = append(s.buf[:s.pos], append([]rune{key}, s.buf[s.pos:]...)...)
+		s.pos += 1
+	}
+
+	s.refresh()
+}
+
+func (s *state) deleteForward() {
+	if s.pos < len(s.buf) {
+		s.buf = append(s.buf[:s.pos], s.buf[s.pos+1:]...)
+	}
+	s.refresh()
+}
+
+func (s *state) historyMove(delta int) {
+	entries := s.editor.history.Entries()
+
+	next := s.historyIndex + delta
+	if next < 0 || next > len(entries) {
+		return
+	}
+
+	if s.historyIndex == len(entries) {
+		s.draft = s.buf
+	}
+
+	s.historyIndex = next
+
+	if next == len(entries) {
+		s.buf = s.draft
+	} else {
+		s.buf = []rune(entries[next])
+	}
+
+	s.pos = len(s.buf)
+}
+
+func (s *state) completeWord() {
+	if s.editor.complete == nil {
+		return
+	}
+
+	start := s.pos
+	for start > 0 && isWordRune(s.buf[start-1]) {
+		start -= 1
+	}
+
+	prefix := string(s.buf[start:s.pos])
+
+	candidates := matches(prefix, s.editor.complete())
+	if len(candidates) == 0 {
+		return
+	}
+
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		common = commonPrefix(common, c)
+	}
+
+	if len(common) > len(prefix) {
+		insert := []rune(common[len(prefix):])
+		s.buf = append(s.buf[:s.pos], append(insert, s.buf[s.pos:]...)...)
+		s.pos += len(insert)
+		s.refresh()
+		return
+	}
+
+	if len(candidates) > 1 {
+		s.write("\r\n" + strings.Join(candidates, "  ") + "\r\n")
+		s.refresh()
+	}
+}
+
+func (s *state) reverseSearch() (string, bool, error) {
+	entries := s.editor.history.Entries()
+
+	query := []rune{}
+	index := len(entries)
+	match := ""
+
+	// search finds the latest entry from from back that has the query in it
+	search := func(from int) bool {
+		for i := from; i >= 0; i-- {
+			if strings.Contains(entries[i], string(query)) {
+				index = i
+				match = entries[i]
+				return true
+			}
+		}
+		return false
+	}
+
+	render := func() {
+		s.write(fmt.Sprintf("\r(reverse-i-search)`%s': %s\x1b[K", string(query), match))
+	}
+
+	render()
+
+	for {
+		key, err := s.editor.readKey()
+		if err != nil {
+			return "", false, err
+		}
+
+		switch key {
+		case keyCtrlR:
+			if index > 0 {
+				search(index - 1)
+			}
+		case keyBackspace, keyDelete:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				if !search(len(entries) - 1) {
+					match = ""
+				}
+			}
+		case keyCtrlG, keyCtrlC:
+			s.refresh()
+			return "", false, nil
+		case keyEnter, keyLineFeed:
+			s.buf = []rune(match)
+			s.pos = len(s.buf)
+			s.refresh()
+			s.newline()
+			return match, true, nil
+		default:
+			if key >= 0 && !unicode.IsControl(key) {
+				query = append(query, key)
+				// the match so far may not have the longer query in it
+				if !search(min(index, len(entries)-1)) {
+					match = ""
+				}
+				break
+			}
+
+			// any other key leaves the search with the match in the buffer
+			s.buf = []rune(match)
+			s.pos = len(s.buf)
+			s.historyIndex = len(entries)
+			s.refresh()
+			return "", false, nil
+		}
+
+		render()
+	}
+}
+
+func (s *state) refresh() {
+	line := string(s.buf)
+
+	if s.editor.highlight != nil {
+		line = s.editor.highlight(line)
+	}
+
+	s.write("\r" + s.prompt + line + "\x1b[K")
+
+	if back := len(s.buf) - s.pos; back > 0 {
+		s.write(fmt.Sprintf("\x1b[%dD", back))
+	}
+}
+
+func (s *state) newline() {
+	s.write("\r\n")
+}
+
+func (s *state) write(text string) {
+	io.WriteString(s.editor.out, text)
+}
+
+func matches(prefix string, words []string) []string {
+	seen := map[string]bool{}
+	found := []string{}
+
+	for _, word := range words {
+		if !strings.HasPrefix(word, prefix) || seen[word] {
+			continue
+		}
+		seen[word] = true
+		found = append(found, word)
+	}
+
+	sort.Strings(found)
+
+	return found
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == ':' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func New(in io.Reader, out io.Writer, history *History, complete Completer) *Editor {
+	return &Editor{
+		in:       bufio.NewReader(in),
+		out:      out,
+		history:  history,
+		complete: complete,
+	}
+}
+
+func NewTerminal(in *os.File, out io.Writer, history *History, complete Completer) *Editor {
+	e := New(in, out, history, complete)
+	e.raw = func() (func() error, error) {
+		return makeRaw(in.Fd())
+	}
+	return e
+}
+
+func IsTerminal(f *os.File) bool {
+	return isTerminal(f.Fd())
+}
@@ -0,0 +1,93 @@
+package readline
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+)
+
+const MAX_HISTORY = 1000
+
+type History struct {
+	path    string
+	entries []string
+}
+
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+func (h *History) Add(line string) error {
+	if len(strings.TrimSpace(line)) == 0 || strings.ContainsAny(line, "\r\n") {
+		return nil
+	}
+
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return nil
+	}
+
+	h.entries = append(h.entries, line)
+
+	if len(h.entries) > MAX_HISTORY {
+		h.entries = h.entries[len(h.entries)-MAX_HISTORY:]
+	}
+
+	if len(h.path) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func NewHistory() *History {
+	return &History{}
+}
+
+func LoadHistory(path string) (*History, error) {
+	h := &History{path: path}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		h.entries = append(h.entries, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(h.entries) > MAX_HISTORY {
+		h.entries = h.entries[len(h.entries)-MAX_HISTORY:]
+
+		// keep the file from growing without bound
+		if err := os.WriteFile(path, []byte(strings.Join(h.entries, "\n")+"\n"), 0o600); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
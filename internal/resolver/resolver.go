@@ -0,0 +1,220 @@
+package resolver
+
+import (
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/symbol"
+)
+
+// a scope is the frame of one function: blocks do not start their own, and
+// names outside every function stay in the environment's store
+type scope struct {
+	function *fnexp.Function
+	slots    map[string]int
+	captured map[string]bool
+}
+
+type resolver struct {
+	scopes []*scope
+}
+
+// Resolve gives every identifier in program its frame coordinates and every
+// function literal its frame layout and captured names
+func Resolve(program *statement.Program) {
+	r := &resolver{}
+
+	for _, stmt := range program.Statements {
+		r.node(stmt)
+	}
+}
+
+func (r *resolver) node(node ast.Node) {
+	switch node := node.(type) {
+	case *identifier.Identifier:
+		r.reference(node)
+		return
+	case *fnexp.Function:
+		r.function(node)
+		return
+	case *field.Field:
+		// the field name is looked up in the value, not in scope
+		r.node(node.Left)
+		return
+	case *let.Let:
+		r.node(node.Value)
+		r.binding(node.Name)
+		return
+	case *operator.Operator:
+		r.node(node.Value)
+		r.binding(node.Name)
+		return
+	case *importstatement.Import:
+		r.binding(node.Alias)
+		return
+	}
+
+	each(node, r.node)
+}
+
+func (r *resolver) function(fn *fnexp.Function) {
+	s := &scope{function: fn, slots: map[string]int{}, captured: map[string]bool{}}
+
+	fn.Frame = []symbol.Symbol{}
+	fn.Captures = []*identifier.Identifier{}
+
+	for _, param := range fn.Parameters {
+		r.declare(s, param)
+	}
+
+	if fn.Rest != nil {
+		r.declare(s, fn.Rest)
+	}
+
+	r.declareAll(s, fn.Body)
+
+	r.scopes = append(r.scopes, s)
+	defer func() { r.scopes = r.scopes[:len(r.scopes)-1] }()
+
+	// defaults run in the new frame, once the parameters before them are set
+	for i, param := range fn.Parameters {
+		if i < len(fn.Defaults) && fn.Defaults[i] != nil {
+			r.node(fn.Defaults[i])
+		}
+		r.binding(param)
+	}
+
+	if fn.Rest != nil {
+		r.binding(fn.Rest)
+	}
+
+	r.node(fn.Body)
+}
+
+// declareAll gives a slot to every name bound in a function's body, wherever
+// it is bound, so a use before the binding still finds the frame it will be in
+func (r *resolver) declareAll(s *scope, node ast.Node) {
+	switch node := node.(type) {
+	case *fnexp.Function:
+		return
+	case *let.Let:
+		r.declare(s, node.Name)
+	case *operator.Operator:
+		r.declare(s, node.Name)
+	case *importstatement.Import:
+		r.declare(s, node.Alias)
+	}
+
+	each(node, func(child ast.Node) { r.declareAll(s, child) })
+}
+
+func (r *resolver) declare(s *scope, name *identifier.Identifier) {
+	if _, ok := s.slots[name.Value]; ok {
+		return
+	}
+
+	s.slots[name.Value] = len(s.function.Frame)
+	s.function.Frame = append(s.function.Frame, name.Symbol())
+}
+
+// binding places a name where it is bound: in the current frame, or in the
+// store outside every function
+func (r *resolver) binding(name *identifier.Identifier) {
+	name.Resolved, name.Depth, name.Slot = true, 0, identifier.GLOBAL
+
+	if len(r.scopes) > 0 {
+		name.Slot = r.scopes[len(r.scopes)-1].slots[name.Value]
+	}
+}
+
+// reference finds the innermost frame with a slot for the name; each function
+// between the use and that frame captures it
+func (r *resolver) reference(name *identifier.Identifier) {
+	name.Resolved, name.Depth, name.Slot = true, len(r.scopes), identifier.GLOBAL
+
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		slot, ok := r.scopes[i].slots[name.Value]
+		if !ok {
+			continue
+		}
+
+		name.Depth, name.Slot = len(r.scopes)-1-i, slot
+
+		for j := i + 1; j < len(r.scopes); j++ {
+			r.capture(r.scopes[j], name, j-1-i, slot)
+		}
+
+		return
+	}
+}
+
+func (r *resolver) capture(s *scope, name *identifier.Identifier, depth, slot int) {
+	if s.captured[name.Value] {
+		return
+	}
+
+	s.captured[name.Value] = true
+
+	s.function.Captures = append(s.function.Captures, &identifier.Identifier{
+		Token:    name.Token,
+		Value:    name.Value,
+		Resolved: true,
+		Depth:    depth,
+		Slot:     slot,
+	})
+}
+
+// each visits the children of the nodes programs are mostly made of without
+// building a list of them, as tree.Children does for the rest
+func each(node ast.Node, visit func(ast.Node)) {
+	switch node := node.(type) {
+	case *identifier.Identifier, *integer.Integer, *boolean.Boolean, *stringexpression.String, *null.Null:
+	case *expressionstatement.Expression:
+		visit(node.Expression)
+	case *returnstatement.Return:
+		if node.Value != nil {
+			visit(node.Value)
+		}
+	case *block.Block:
+		for _, stmt := range node.Statements {
+			visit(stmt)
+		}
+	case *infixoperator.InfixOperator:
+		visit(node.Left)
+		visit(node.Right)
+	case *prefixoperator.PrefixOperator:
+		visit(node.Right)
+	case *call.Call:
+		visit(node.Function)
+		for _, arg := range node.Arguments {
+			visit(arg)
+		}
+	case *ifexpression.If:
+		visit(node.Condition)
+		visit(node.Consequence)
+		if node.Alternative != nil {
+			visit(node.Alternative)
+		}
+	default:
+		for _, child := range tree.Children(node) {
+			visit(child.Node)
+		}
+	}
+}
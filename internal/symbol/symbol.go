@@ -0,0 +1,61 @@
+//go:build !nosymbols
+
+package symbol
+
+import (
+	"strings"
+	"sync"
+)
+
+// Symbol is an interned name; symbols are equal exactly when their names
+// are, and the zero Symbol is no name at all
+type Symbol uint32
+
+const None Symbol = 0
+
+type table struct {
+	mu    sync.RWMutex
+	ids   map[string]Symbol
+	names []string
+}
+
+var symbols = &table{ids: map[string]Symbol{}, names: []string{""}}
+
+func (s Symbol) String() string {
+	symbols.mu.RLock()
+	defer symbols.mu.RUnlock()
+
+	return symbols.names[s]
+}
+
+func Intern(name string) Symbol {
+	if s, ok := Lookup(name); ok {
+		return s
+	}
+
+	symbols.mu.Lock()
+	defer symbols.mu.Unlock()
+
+	if s, ok := symbols.ids[name]; ok {
+		return s
+	}
+
+	// copy the name so the table does not keep the whole source it came from
+	name = strings.Clone(name)
+
+	s := Symbol(len(symbols.names))
+	symbols.names = append(symbols.names, name)
+	symbols.ids[name] = s
+
+	return s
+}
+
+// Lookup finds the symbol of a name without interning it
+func Lookup(name string) (Symbol, bool) {
+	symbols.mu.RLock()
+	defer symbols.mu.RUnlock()
+
+	s, ok := symbols.ids[name]
+
+	return s, ok
+}
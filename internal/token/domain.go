@@ -1,5 +1,11 @@
 package token
 
+import (
+	"fmt"
+
+	"github.com/w-h-a/interpreter/internal/symbol"
+)
+
 type TokenType string
 
 const (
@@ -8,8 +14,13 @@ const (
 	EOF     TokenType = "EOF"
 
 	// Identifiers + literals
-	Ident TokenType = "IDENT"
-	Int   TokenType = "INT"
+	Ident        TokenType = "IDENT"
+	Operator     TokenType = "OPERATOR"
+	Int          TokenType = "INT"
+	String       TokenType = "STRING"
+	StringStart  TokenType = "STRING_START"
+	StringMiddle TokenType = "STRING_MIDDLE"
+	StringEnd    TokenType = "STRING_END"
 
 	// Operators
 	Assign       TokenType = "="
@@ -22,14 +33,23 @@ const (
 	GreaterThan  TokenType = ">"
 	Identical    TokenType = "=="
 	NotIdentical TokenType = "!="
+	Pipe         TokenType = "|>"
+	Coalesce     TokenType = "??"
 
 	// Delimiters
-	Comma      TokenType = ","
-	Semicolon  TokenType = ";"
-	ParenLeft  TokenType = "("
-	ParenRight TokenType = ")"
-	BraceLeft  TokenType = "{"
-	BraceRight TokenType = "}"
+	Comma         TokenType = ","
+	Semicolon     TokenType = ";"
+	Colon         TokenType = ":"
+	ParenLeft     TokenType = "("
+	ParenRight    TokenType = ")"
+	BraceLeft     TokenType = "{"
+	BraceRight    TokenType = "}"
+	BracketLeft   TokenType = "["
+	BracketRight  TokenType = "]"
+	Dot           TokenType = "."
+	OptionalChain TokenType = "?."
+	Spread        TokenType = ".."
+	Arrow         TokenType = "->"
 
 	// Keywords
 	Function TokenType = "FUNCTION"
@@ -39,13 +59,60 @@ const (
 	If       TokenType = "IF"
 	Else     TokenType = "ELSE"
 	Return   TokenType = "RETURN"
+	Null     TokenType = "NULL"
+	Import   TokenType = "IMPORT"
+	Export   TokenType = "EXPORT"
+	As       TokenType = "AS"
+	InfixL   TokenType = "INFIXL"
+	InfixR   TokenType = "INFIXR"
+	Infix    TokenType = "INFIX"
 )
 
+// Fixity is how a declared operator binds: Associativity is the keyword it
+// was declared with
+type Fixity struct {
+	Precedence    int
+	Associativity TokenType
+}
+
+// Operators maps the symbols a program declared to their fixity; the lexer
+// and parser of one program share it
+type Operators map[string]Fixity
+
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
 type Token struct {
 	Type    TokenType
+	Pos     Position
+	Symbol  symbol.Symbol // for identifiers and declared operators
 	literal string
 }
 
 func (t Token) Literal() string {
 	return t.literal
 }
+
+func (t Token) Position() Position {
+	return t.Pos
+}
+
+func (t Token) Start() Position {
+	pos := t.Pos
+
+	// string literals exclude their opening quote or closing brace
+	switch t.Type {
+	case String, StringStart, StringMiddle, StringEnd:
+		pos.Offset -= 1
+		pos.Column -= 1
+	}
+
+	return pos
+}
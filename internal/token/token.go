@@ -6,3 +6,11 @@ func Factory(t TokenType, char string) Token {
 		literal: char,
 	}
 }
+
+func FactoryAt(t TokenType, char string, pos Position) Token {
+	return Token{
+		Type:    t,
+		Pos:     pos,
+		literal: char,
+	}
+}
@@ -1,5 +1,7 @@
 package token
 
+import "sort"
+
 var keywords = map[string]TokenType{
 	"fn":     Function,
 	"let":    Let,
@@ -8,6 +10,32 @@ var keywords = map[string]TokenType{
 	"if":     If,
 	"else":   Else,
 	"return": Return,
+	"null":   Null,
+	"import": Import,
+	"export": Export,
+	"as":     As,
+	"infixl": InfixL,
+	"infixr": InfixR,
+	"infix":  Infix,
+}
+
+var operators = map[string]TokenType{
+	"=":  Assign,
+	"+":  Plus,
+	"-":  Minus,
+	"!":  Bang,
+	"*":  Asterisk,
+	"/":  Slash,
+	"<":  LessThan,
+	">":  GreaterThan,
+	"==": Identical,
+	"!=": NotIdentical,
+	"|>": Pipe,
+	"??": Coalesce,
+	":":  Colon,
+	".":  Dot,
+	"?.": OptionalChain,
+	"..": Spread,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -16,3 +44,17 @@ func LookupIdent(ident string) TokenType {
 	}
 	return Ident
 }
+
+func LookupOperator(symbol string) (TokenType, bool) {
+	tk, ok := operators[symbol]
+	return tk, ok
+}
+
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
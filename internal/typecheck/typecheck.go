@@ -0,0 +1,552 @@
+package typecheck
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
+	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
+	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
+
+var builtins = map[string]*Type{
+	"len":   FunctionOf([]*Type{Dynamic}, Int),
+	"first": FunctionOf([]*Type{Dynamic}, Dynamic),
+	"last":  FunctionOf([]*Type{Dynamic}, Dynamic),
+	"rest":  FunctionOf([]*Type{Dynamic}, Dynamic),
+	"push":  FunctionOf([]*Type{Dynamic, Dynamic}, Dynamic),
+	"puts":  {Kind: FUNCTION, Parameters: []*Type{}, Variadic: true, Result: Null},
+}
+
+// a scope is the bindings of one function, which its blocks share
+type scope struct {
+	outer    *scope
+	types    map[string]*Type
+	rebound  map[string]bool
+	function *Type // nil outside every function
+}
+
+func newScope(outer *scope, function *Type) *scope {
+	return &scope{outer: outer, types: map[string]*Type{}, rebound: map[string]bool{}, function: function}
+}
+
+type checker struct {
+	diagnostics []Diagnostic
+}
+
+func Check(program *statement.Program) []Diagnostic {
+	c := &checker{}
+
+	s := newScope(nil, nil)
+
+	c.declare(s, program, map[string]bool{})
+	c.statements(s, program.Statements)
+
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		return c.diagnostics[i].Pos.Offset < c.diagnostics[j].Pos.Offset
+	})
+
+	return c.diagnostics
+}
+
+func (c *checker) report(node ast.Node, format string, a ...any) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{Pos: tree.Token(node).Position(), Message: fmt.Sprintf(format, a...)})
+}
+
+// declare finds the names a scope binds more than once; an unannotated one
+// could hold a value of either binding wherever it is used, so it stays
+// dynamic
+func (c *checker) declare(s *scope, node ast.Node, seen map[string]bool) {
+	var name *identifier.Identifier
+
+	switch node := node.(type) {
+	case *fnexp.Function:
+		return
+	case *let.Let:
+		name = node.Name
+	case *operator.Operator:
+		name = node.Name
+	case *importstatement.Import:
+		name = node.Alias
+	}
+
+	if name != nil {
+		s.rebound[name.Value] = seen[name.Value]
+		seen[name.Value] = true
+	}
+
+	for _, child := range tree.Children(node) {
+		c.declare(s, child.Node, seen)
+	}
+}
+
+func (c *checker) bind(s *scope, name string, t *Type) {
+	s.types[name] = t
+}
+
+func (c *checker) lookup(s *scope, name string) *Type {
+	for ; s != nil; s = s.outer {
+		if t, ok := s.types[name]; ok {
+			return t
+		}
+	}
+
+	if t, ok := builtins[name]; ok {
+		return t
+	}
+
+	return Dynamic
+}
+
+// statements gives the type of the last statement's value; a block without
+// one has no value the checker can describe
+func (c *checker) statements(s *scope, stmts []statement.Statement) *Type {
+	result := Dynamic
+
+	for _, stmt := range stmts {
+		result = c.statement(s, stmt)
+	}
+
+	return result
+}
+
+// statement checks stmt and gives the type of the value a block ending in it
+// evaluates to
+func (c *checker) statement(s *scope, stmt statement.Statement) *Type {
+	switch stmt := stmt.(type) {
+	case *let.Let:
+		c.let(s, stmt)
+		return Dynamic
+	case *export.Export:
+		c.let(s, stmt.Let)
+		return Dynamic
+	case *operator.Operator:
+		c.bind(s, stmt.Name.Value, c.expression(s, stmt.Value))
+		return Dynamic
+	case *importstatement.Import:
+		c.bind(s, stmt.Alias.Value, Dynamic)
+		return Dynamic
+	case *returnstatement.Return:
+		if stmt.Value == nil {
+			if s.function != nil && !Consistent(Null, s.function.Result) {
+				c.report(stmt, "cannot return %s from a function returning %s", Null, s.function.Result)
+			}
+			return Dynamic
+		}
+		if s.function == nil {
+			c.expression(s, stmt.Value)
+			return Dynamic
+		}
+		c.expect(s, stmt.Value, s.function.Result, func(node ast.Node, t, expected *Type) {
+			// the whole value is reported at the return
+			if node == stmt.Value {
+				node = stmt
+			}
+			c.report(node, "cannot return %s from a function returning %s", t, expected)
+		})
+		return Dynamic
+	case *expressionstatement.Expression:
+		return c.expression(s, stmt.Expression)
+	case *block.Block:
+		return c.statements(s, stmt.Statements)
+	default:
+		return Dynamic
+	}
+}
+
+func (c *checker) let(s *scope, stmt *let.Let) {
+	if stmt.Type == nil {
+		t := Null
+		if stmt.Value != nil {
+			t = c.expression(s, stmt.Value)
+		}
+		if s.rebound[stmt.Name.Value] {
+			t = Dynamic
+		}
+		c.bind(s, stmt.Name.Value, t)
+		return
+	}
+
+	declared := FromAnnotation(stmt.Type)
+
+	if stmt.Value != nil {
+		c.expect(s, stmt.Value, declared, func(node ast.Node, t, expected *Type) {
+			c.report(node, "cannot use %s as %s in let %s", t, expected, stmt.Name.Value)
+		})
+	}
+
+	c.bind(s, stmt.Name.Value, declared)
+}
+
+// expect checks exp against the type its context expects, looking into array
+// literals and if branches so that each value that does not fit is reported
+// where it is written; an if without else gives null when it is not taken
+func (c *checker) expect(s *scope, exp expression.Expression, expected *Type, mismatch func(node ast.Node, t, expected *Type)) {
+	switch exp := exp.(type) {
+	case *array.Array:
+		if expected.Kind == ARRAY {
+			for _, e := range exp.Elements {
+				c.expect(s, e, expected.Element, mismatch)
+			}
+			return
+		}
+	case *ifexpression.If:
+		c.expression(s, exp.Condition)
+		c.branch(s, exp.Consequence, expected, mismatch)
+		if exp.Alternative == nil {
+			if !Consistent(Null, expected) {
+				mismatch(exp, Null, expected)
+			}
+			return
+		}
+		c.branch(s, exp.Alternative, expected, mismatch)
+		return
+	}
+
+	if t := c.expression(s, exp); !Consistent(t, expected) {
+		mismatch(exp, t, expected)
+	}
+}
+
+// branch checks the value a block ends in against the expected type
+func (c *checker) branch(s *scope, b *block.Block, expected *Type, mismatch func(node ast.Node, t, expected *Type)) {
+	last := len(b.Statements) - 1
+	if last < 0 {
+		return
+	}
+
+	c.statements(s, b.Statements[:last])
+
+	if stmt, ok := b.Statements[last].(*expressionstatement.Expression); ok {
+		c.expect(s, stmt.Expression, expected, mismatch)
+		return
+	}
+
+	c.statement(s, b.Statements[last])
+}
+
+func (c *checker) expression(s *scope, exp expression.Expression) *Type {
+	switch exp := exp.(type) {
+	case *integer.Integer:
+		return Int
+	case *boolean.Boolean:
+		return Bool
+	case *stringexpression.String:
+		return String
+	case *null.Null:
+		return Null
+	case *interpolation.Interpolation:
+		for _, part := range exp.Parts {
+			if embedded, ok := part.(*interpolation.Embedded); ok {
+				c.expression(s, embedded.Expression)
+			}
+		}
+		return String
+	case *identifier.Identifier:
+		return c.lookup(s, exp.Value)
+	case *array.Array:
+		var element *Type
+		for _, e := range exp.Elements {
+			element = c.joined(element, c.expression(s, e))
+		}
+		if element == nil {
+			element = Dynamic
+		}
+		return ArrayOf(element)
+	case *hash.Hash:
+		var key, value *Type
+		for _, pair := range exp.Pairs {
+			key = c.joined(key, c.expression(s, pair.Key))
+			value = c.joined(value, c.expression(s, pair.Value))
+		}
+		if key == nil {
+			key, value = Dynamic, Dynamic
+		}
+		return HashOf(key, value)
+	case *prefixoperator.PrefixOperator:
+		return c.prefix(exp, c.expression(s, exp.Right))
+	case *infixoperator.InfixOperator:
+		return c.infix(s, exp)
+	case *ifexpression.If:
+		c.expression(s, exp.Condition)
+		consequence := c.statements(s, exp.Consequence.Statements)
+		if exp.Alternative == nil {
+			return join(consequence, Null)
+		}
+		return join(consequence, c.statements(s, exp.Alternative.Statements))
+	case *fnexp.Function:
+		return c.function(s, exp)
+	case *call.Call:
+		callee := c.expression(s, exp.Function)
+		return c.call(exp.Function, exp.Function.String(), callee, exp.Arguments, c.arguments(s, exp.Arguments))
+	case *pipeline.Pipeline:
+		at := []expression.Expression{exp.Left}
+		args := []*Type{c.expression(s, exp.Left)}
+		callee := exp.Right
+		if callExp, ok := exp.Right.(*call.Call); ok {
+			callee = callExp.Function
+			at = append(at, callExp.Arguments...)
+			args = append(args, c.arguments(s, callExp.Arguments)...)
+		}
+		return c.call(exp, callee.String(), c.expression(s, callee), at, args)
+	case *index.Index:
+		left := c.expression(s, exp.Left)
+		t := c.index(exp, left, c.expression(s, exp.Index))
+		if exp.Optional {
+			return join(t, Null)
+		}
+		return t
+	case *field.Field:
+		left := c.expression(s, exp.Left)
+		t := c.index(exp, left, String)
+		if exp.Optional {
+			return join(t, Null)
+		}
+		return t
+	default:
+		return Dynamic
+	}
+}
+
+// joined is join for a type gathered over elements, of which there may not be
+// any yet
+func (c *checker) joined(acc, t *Type) *Type {
+	if acc == nil {
+		return t
+	}
+	return join(acc, t)
+}
+
+func (c *checker) arguments(s *scope, args []expression.Expression) []*Type {
+	types := []*Type{}
+	for _, arg := range args {
+		types = append(types, c.expression(s, arg))
+	}
+	return types
+}
+
+func (c *checker) prefix(exp *prefixoperator.PrefixOperator, right *Type) *Type {
+	switch exp.Operator {
+	case "!":
+		return Bool
+	case "-":
+		if !Consistent(right, Int) {
+			c.report(exp, "invalid operation: -%s", right)
+		}
+		return Int
+	default:
+		return Dynamic
+	}
+}
+
+func (c *checker) infix(s *scope, exp *infixoperator.InfixOperator) *Type {
+	left := c.expression(s, exp.Left)
+	right := c.expression(s, exp.Right)
+
+	if _, builtin := token.LookupOperator(exp.Operator); !builtin {
+		return c.call(exp, exp.Operator, c.lookup(s, exp.Operator), []expression.Expression{exp.Left, exp.Right}, []*Type{left, right})
+	}
+
+	switch exp.Operator {
+	case "==", "!=":
+		return Bool
+	case "??":
+		if left.Kind == NULL {
+			return right
+		}
+		return left
+	case "+":
+		// ints add and strings join; either side settles the type
+		known := left
+		if known.Kind == DYNAMIC {
+			known = right
+		}
+		if (known.Kind == INT || known.Kind == STRING) && Consistent(left, known) && Consistent(right, known) {
+			return known
+		}
+		if known.Kind == DYNAMIC {
+			return Dynamic
+		}
+	case "-", "*", "/":
+		if Consistent(left, Int) && Consistent(right, Int) {
+			return Int
+		}
+	case "<", ">":
+		if Consistent(left, Int) && Consistent(right, Int) {
+			return Bool
+		}
+	default:
+		return Dynamic
+	}
+
+	c.report(exp, "invalid operation: %s %s %s", left, exp.Operator, right)
+
+	return Dynamic
+}
+
+// call checks a call of callee at node with the arguments at, of types args
+func (c *checker) call(node ast.Node, name string, callee *Type, at []expression.Expression, args []*Type) *Type {
+	switch callee.Kind {
+	case DYNAMIC:
+		return Dynamic
+	case FUNCTION:
+	default:
+		c.report(node, "cannot call %s of type %s", name, callee)
+		return Dynamic
+	}
+
+	if !accepts(callee, len(args)) {
+		c.report(node, "wrong number of arguments to %s: want %s, got %d", name, arity(callee), len(args))
+		return callee.Result
+	}
+
+	for i, arg := range args {
+		param := callee.Rest
+		if i < len(callee.Parameters) {
+			param = callee.Parameters[i]
+		}
+		if param != nil && !Consistent(arg, param) {
+			c.report(at[i], "cannot use %s as %s in argument %d to %s", arg, param, i+1, name)
+		}
+	}
+
+	return callee.Result
+}
+
+func arity(fn *Type) string {
+	switch {
+	case fn.Variadic:
+		return fmt.Sprintf("at least %d", fn.Required)
+	case fn.Required < len(fn.Parameters):
+		return fmt.Sprintf("%d to %d", fn.Required, len(fn.Parameters))
+	default:
+		return fmt.Sprintf("%d", fn.Required)
+	}
+}
+
+// index is the type of an element of left; a missing element is null, which
+// is left to the evaluator as it would make every element nullable
+func (c *checker) index(node ast.Node, left, idx *Type) *Type {
+	switch left.Kind {
+	case ARRAY:
+		if !Consistent(idx, Int) {
+			c.report(node, "cannot index %s with %s", left, idx)
+		}
+		return left.Element
+	case HASH:
+		if !Consistent(idx, left.Key) {
+			c.report(node, "cannot index %s with %s", left, idx)
+		}
+		return left.Value
+	case DYNAMIC:
+		return Dynamic
+	default:
+		c.report(node, "cannot index %s", left)
+		return Dynamic
+	}
+}
+
+func (c *checker) function(outer *scope, fn *fnexp.Function) *Type {
+	t := &Type{Kind: FUNCTION, Parameters: []*Type{}, Variadic: fn.Rest != nil, Result: FromAnnotation(fn.Result)}
+
+	s := newScope(outer, t)
+
+	for i, param := range fn.Parameters {
+		p := Dynamic
+		if i < len(fn.Types) {
+			p = FromAnnotation(fn.Types[i])
+		}
+
+		if i < len(fn.Defaults) && fn.Defaults[i] != nil {
+			c.expect(s, fn.Defaults[i], p, func(node ast.Node, t, expected *Type) {
+				c.report(node, "cannot use %s as %s in default of parameter %s", t, expected, param.Value)
+			})
+		} else {
+			t.Required = i + 1
+		}
+
+		t.Parameters = append(t.Parameters, p)
+		c.bind(s, param.Value, p)
+	}
+
+	if fn.Rest != nil {
+		rest := FromAnnotation(fn.RestType)
+		if !Consistent(rest, ArrayOf(Dynamic)) {
+			c.report(fn.RestType, "rest parameter %s must be an array, not %s", fn.Rest.Value, rest)
+			rest = Dynamic
+		}
+		if rest.Kind == ARRAY {
+			t.Rest = rest.Element
+		} else {
+			rest = ArrayOf(Dynamic)
+		}
+		c.bind(s, fn.Rest.Value, rest)
+	}
+
+	c.declare(s, fn.Body, map[string]bool{})
+
+	// a body ending in a return was checked there, one ending in an expression
+	// gives its value, and any other falls off the end with null
+	var end ast.Node = fn.Body
+
+	if last := len(fn.Body.Statements) - 1; last >= 0 {
+		c.statements(s, fn.Body.Statements[:last])
+
+		end = fn.Body.Statements[last]
+		switch stmt := fn.Body.Statements[last].(type) {
+		case *returnstatement.Return:
+			c.statement(s, stmt)
+			return t
+		case *expressionstatement.Expression:
+			c.expect(s, stmt.Expression, t.Result, func(node ast.Node, result, expected *Type) {
+				if node == stmt.Expression {
+					node = stmt
+				}
+				c.report(node, "cannot return %s from a function returning %s", result, expected)
+			})
+			return t
+		default:
+			c.statement(s, stmt)
+		}
+	}
+
+	if !Consistent(Null, t.Result) {
+		c.report(end, "cannot return %s from a function returning %s", Null, t.Result)
+	}
+
+	return t
+}
@@ -0,0 +1,151 @@
+package typecheck
+
+import (
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast/annotation"
+)
+
+const (
+	DYNAMIC  = annotation.ANY
+	INT      = annotation.INT
+	STRING   = annotation.STRING
+	BOOL     = annotation.BOOL
+	NULL     = annotation.NULL
+	ARRAY    = "array"
+	HASH     = "hash"
+	FUNCTION = "function"
+)
+
+// Type is what the checker knows of a value; DYNAMIC is consistent with
+// every type, so unannotated code is never reported
+type Type struct {
+	Kind       string
+	Element    *Type // of an array
+	Key        *Type // of a hash
+	Value      *Type // of a hash
+	Parameters []*Type
+	Required   int   // parameters without a default
+	Variadic   bool  // takes more arguments after Parameters
+	Rest       *Type // of each of those arguments, when it is known
+	Result     *Type
+}
+
+var (
+	Dynamic = &Type{Kind: DYNAMIC}
+	Int     = &Type{Kind: INT}
+	String  = &Type{Kind: STRING}
+	Bool    = &Type{Kind: BOOL}
+	Null    = &Type{Kind: NULL}
+)
+
+func ArrayOf(element *Type) *Type {
+	return &Type{Kind: ARRAY, Element: element}
+}
+
+func HashOf(key, value *Type) *Type {
+	return &Type{Kind: HASH, Key: key, Value: value}
+}
+
+func FunctionOf(parameters []*Type, result *Type) *Type {
+	return &Type{Kind: FUNCTION, Parameters: parameters, Required: len(parameters), Result: result}
+}
+
+func (t *Type) String() string {
+	switch t.Kind {
+	case ARRAY:
+		return "[" + t.Element.String() + "]"
+	case HASH:
+		return "{" + t.Key.String() + ": " + t.Value.String() + "}"
+	case FUNCTION:
+		params := []string{}
+		for _, p := range t.Parameters {
+			params = append(params, p.String())
+		}
+		if t.Variadic {
+			params = append(params, "..")
+		}
+		return "fn(" + strings.Join(params, ", ") + ") -> " + t.Result.String()
+	default:
+		return t.Kind
+	}
+}
+
+// FromAnnotation is the type an annotation stands for; a missing one is
+// dynamic
+func FromAnnotation(a annotation.Annotation) *Type {
+	switch a := a.(type) {
+	case *annotation.Named:
+		switch a.Name {
+		case INT:
+			return Int
+		case STRING:
+			return String
+		case BOOL:
+			return Bool
+		case NULL:
+			return Null
+		default:
+			return Dynamic
+		}
+	case *annotation.Array:
+		return ArrayOf(FromAnnotation(a.Element))
+	case *annotation.Hash:
+		return HashOf(FromAnnotation(a.Key), FromAnnotation(a.Value))
+	case *annotation.Function:
+		params := []*Type{}
+		for _, p := range a.Parameters {
+			params = append(params, FromAnnotation(p))
+		}
+		return FunctionOf(params, FromAnnotation(a.Result))
+	default:
+		return Dynamic
+	}
+}
+
+// Consistent reports whether a value of type actual may be used where
+// expected is wanted: the types agree wherever neither is dynamic
+func Consistent(actual, expected *Type) bool {
+	if actual.Kind == DYNAMIC || expected.Kind == DYNAMIC {
+		return true
+	}
+
+	if actual.Kind != expected.Kind {
+		return false
+	}
+
+	switch actual.Kind {
+	case ARRAY:
+		return Consistent(actual.Element, expected.Element)
+	case HASH:
+		return Consistent(actual.Key, expected.Key) && Consistent(actual.Value, expected.Value)
+	case FUNCTION:
+		// actual must accept every call expected allows
+		if !accepts(actual, len(expected.Parameters)) || expected.Variadic && !actual.Variadic {
+			return false
+		}
+		for i := 0; i < len(actual.Parameters) && i < len(expected.Parameters); i++ {
+			if !Consistent(expected.Parameters[i], actual.Parameters[i]) {
+				return false
+			}
+		}
+		return Consistent(actual.Result, expected.Result)
+	default:
+		return true
+	}
+}
+
+// accepts reports whether a function of type fn can be called with n
+// arguments
+func accepts(fn *Type, n int) bool {
+	return n >= fn.Required && (fn.Variadic || n <= len(fn.Parameters))
+}
+
+// join is the type of a value that is either a or b
+func join(a, b *Type) *Type {
+	if a.String() == b.String() {
+		return a
+	}
+
+	return Dynamic
+}
@@ -0,0 +1,61 @@
+package vet
+
+import (
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
+	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
+	"github.com/w-h-a/interpreter/internal/object"
+)
+
+func isConstant(exp expression.Expression) bool {
+	switch exp.(type) {
+	case *integer.Integer, *boolean.Boolean, *stringexpression.String, *null.Null:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTruthy follows the evaluator: only false and null are falsy
+func isTruthy(exp expression.Expression) bool {
+	switch exp := exp.(type) {
+	case *boolean.Boolean:
+		return exp.Value
+	case *null.Null:
+		return false
+	default:
+		return true
+	}
+}
+
+func literalType(exp expression.Expression) (object.ObjectType, bool) {
+	switch exp.(type) {
+	case *integer.Integer:
+		return object.INTEGER, true
+	case *boolean.Boolean:
+		return object.BOOLEAN, true
+	case *stringexpression.String, *interpolation.Interpolation:
+		return object.STRING, true
+	case *null.Null:
+		return object.NULL, true
+	case *array.Array:
+		return object.ARRAY, true
+	case *hash.Hash:
+		return object.HASH, true
+	case *fnexp.Function:
+		return object.FUNCTION, true
+	default:
+		return "", false
+	}
+}
+
+// a new array, hash or function is a different object from every other
+func isFresh(t object.ObjectType) bool {
+	return t == object.ARRAY || t == object.HASH || t == object.FUNCTION
+}
@@ -0,0 +1,392 @@
+package vet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/pipeline"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/block"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
+	"github.com/w-h-a/interpreter/internal/ast/statement/let"
+	"github.com/w-h-a/interpreter/internal/ast/statement/operator"
+	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	fnobj "github.com/w-h-a/interpreter/internal/object/function"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+const (
+	UNDEFINED          = "undefined"
+	UNUSED             = "unused"
+	SHADOW             = "shadow"
+	UNREACHABLE        = "unreachable"
+	CONSTANT_CONDITION = "constant-condition"
+	ARITY              = "arity"
+	COMPARISON         = "comparison"
+)
+
+// names starting with _ are meant to go unused
+const IGNORED_PREFIX = "_"
+
+type Diagnostic struct {
+	Pos     token.Position
+	Check   string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Message, d.Check)
+}
+
+type binding struct {
+	name     *identifier.Identifier
+	kind     string
+	function *fnexp.Function
+	exported bool
+	used     bool
+}
+
+type scope struct {
+	outer    *scope
+	bindings map[string]*binding
+	all      []*binding
+	// names bound anywhere in the scope, which a function defined in it can
+	// reach even before the binding runs
+	declared map[string][]*binding
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, bindings: map[string]*binding{}, declared: map[string][]*binding{}}
+}
+
+type checker struct {
+	diagnostics []Diagnostic
+	builtins    map[string]bool
+}
+
+func Vet(program *statement.Program) []Diagnostic {
+	c := &checker{builtins: map[string]bool{}}
+
+	for _, name := range evaluator.Builtins() {
+		c.builtins[name] = true
+	}
+
+	s := newScope(nil)
+
+	c.declare(s, program)
+	c.statements(s, program.Statements)
+	c.unused(s)
+
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		return c.diagnostics[i].Pos.Offset < c.diagnostics[j].Pos.Offset
+	})
+
+	return c.diagnostics
+}
+
+func (c *checker) report(tk ast.Token, check, format string, a ...any) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{Pos: tk.Position(), Check: check, Message: fmt.Sprintf(format, a...)})
+}
+
+// declare collects the names a scope binds; blocks share the scope of the
+// function they are in, functions start their own
+func (c *checker) declare(s *scope, node ast.Node) {
+	switch node := node.(type) {
+	case *fnexp.Function:
+		return
+	case *let.Let:
+		b := &binding{name: node.Name, kind: "let"}
+		if fn, ok := node.Value.(*fnexp.Function); ok {
+			b.function = fn
+		}
+		s.declared[node.Name.Value] = append(s.declared[node.Name.Value], b)
+	case *operator.Operator:
+		s.declared[node.Name.Value] = append(s.declared[node.Name.Value], &binding{name: node.Name, kind: "operator"})
+	case *importstatement.Import:
+		s.declared[node.Alias.Value] = append(s.declared[node.Alias.Value], &binding{name: node.Alias, kind: "import"})
+	}
+
+	for _, child := range tree.Children(node) {
+		c.declare(s, child.Node)
+	}
+}
+
+// bind makes b the binding its name refers to from here on
+func (c *checker) bind(s *scope, b *binding) {
+	name := b.name.Value
+
+	if _, rebound := s.bindings[name]; !rebound && b.kind != "operator" {
+		if outer, _ := c.lookup(s.outer, name); len(outer) > 0 {
+			c.report(b.name.Token, SHADOW, "%s %s shadows a binding in an enclosing scope", b.kind, name)
+		} else if c.builtins[name] {
+			c.report(b.name.Token, SHADOW, "%s %s shadows the builtin %s", b.kind, name, name)
+		}
+	}
+
+	s.bindings[name] = b
+	s.all = append(s.all, b)
+}
+
+// lookup finds every binding name may refer to from s; uses outside a
+// function run after the scope it was defined in has bound everything, so any
+// of that scope's bindings may be meant
+func (c *checker) lookup(s *scope, name string) ([]*binding, bool) {
+	for crossed := false; s != nil; s, crossed = s.outer, true {
+		if b, ok := s.bindings[name]; ok && !crossed {
+			return []*binding{b}, true
+		}
+
+		if !crossed {
+			continue
+		}
+
+		if declared := s.declared[name]; len(declared) > 0 {
+			return declared, true
+		}
+
+		if b, ok := s.bindings[name]; ok {
+			return []*binding{b}, true
+		}
+	}
+
+	return nil, c.builtins[name]
+}
+
+func (c *checker) resolve(s *scope, name string) bool {
+	bindings, ok := c.lookup(s, name)
+
+	for _, b := range bindings {
+		b.used = true
+	}
+
+	return ok
+}
+
+func (c *checker) unused(s *scope) {
+	for _, b := range s.all {
+		if b.used || b.exported || b.kind == "operator" || strings.HasPrefix(b.name.Value, IGNORED_PREFIX) {
+			continue
+		}
+
+		c.report(b.name.Token, UNUSED, "%s %s is declared but never used", b.kind, b.name.Value)
+	}
+}
+
+func (c *checker) statements(s *scope, stmts []statement.Statement) {
+	terminated := false
+
+	for _, stmt := range stmts {
+		if terminated {
+			c.report(tree.Token(stmt), UNREACHABLE, "unreachable code after return")
+			terminated = false
+		}
+
+		c.statement(s, stmt)
+
+		if terminates(stmt) {
+			terminated = true
+		}
+	}
+}
+
+// a return, or an if whose branches both end in one
+func terminates(stmt statement.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *returnstatement.Return:
+		return true
+	case *expressionstatement.Expression:
+		ie, ok := stmt.Expression.(*ifexpression.If)
+		if !ok || ie.Alternative == nil {
+			return false
+		}
+		return blockTerminates(ie.Consequence) && blockTerminates(ie.Alternative)
+	default:
+		return false
+	}
+}
+
+func blockTerminates(b *block.Block) bool {
+	for _, stmt := range b.Statements {
+		if terminates(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) statement(s *scope, stmt statement.Statement) {
+	switch stmt := stmt.(type) {
+	case *let.Let:
+		c.let(s, stmt, false)
+	case *export.Export:
+		c.let(s, stmt.Let, true)
+	case *operator.Operator:
+		c.expression(s, stmt.Value)
+		c.bind(s, &binding{name: stmt.Name, kind: "operator"})
+	case *importstatement.Import:
+		c.bind(s, c.declaredBinding(s, stmt.Alias))
+	default:
+		c.node(s, stmt)
+	}
+}
+
+func (c *checker) let(s *scope, stmt *let.Let, exported bool) {
+	c.expression(s, stmt.Value)
+
+	b := c.declaredBinding(s, stmt.Name)
+	b.exported = exported
+
+	c.bind(s, b)
+}
+
+// declaredBinding is the binding declare made for name, so that uses found
+// through declared and through bindings mark the same one
+func (c *checker) declaredBinding(s *scope, name *identifier.Identifier) *binding {
+	for _, b := range s.declared[name.Value] {
+		if b.name == name {
+			return b
+		}
+	}
+	return &binding{name: name, kind: "let"}
+}
+
+func (c *checker) expression(s *scope, exp expression.Expression) {
+	if exp != nil {
+		c.node(s, exp)
+	}
+}
+
+func (c *checker) node(s *scope, node ast.Node) {
+	switch node := node.(type) {
+	case *block.Block:
+		c.statements(s, node.Statements)
+		return
+	case *identifier.Identifier:
+		if !c.resolve(s, node.Value) {
+			c.report(node.Token, UNDEFINED, "undefined: %s", node.Value)
+		}
+		return
+	case *fnexp.Function:
+		c.function(s, node)
+		return
+	case *field.Field:
+		// the field name is looked up in the value, not in scope
+		c.expression(s, node.Left)
+		return
+	case *ifexpression.If:
+		if isConstant(node.Condition) {
+			c.report(tree.Token(node.Condition), CONSTANT_CONDITION, "if condition %s is always %t", node.Condition.String(), isTruthy(node.Condition))
+		}
+	case *infixoperator.InfixOperator:
+		c.comparison(node)
+		if _, builtin := token.LookupOperator(node.Operator); !builtin {
+			if !c.resolve(s, node.Operator) {
+				c.report(node.Token, UNDEFINED, "undefined operator: %s", node.Operator)
+			}
+		}
+	case *call.Call:
+		c.arity(s, tree.Token(node.Function), node.Function, len(node.Arguments))
+	case *pipeline.Pipeline:
+		if callExp, ok := node.Right.(*call.Call); ok {
+			c.expression(s, node.Left)
+			c.expression(s, callExp.Function)
+			for _, arg := range callExp.Arguments {
+				c.expression(s, arg)
+			}
+			c.arity(s, node.Token, callExp.Function, len(callExp.Arguments)+1)
+			return
+		}
+		c.arity(s, node.Token, node.Right, 1)
+	}
+
+	for _, child := range tree.Children(node) {
+		if stmt, ok := child.Node.(statement.Statement); ok {
+			c.statement(s, stmt)
+			continue
+		}
+		c.node(s, child.Node)
+	}
+}
+
+func (c *checker) function(outer *scope, fn *fnexp.Function) {
+	s := newScope(outer)
+
+	for i, param := range fn.Parameters {
+		if i < len(fn.Defaults) {
+			c.expression(s, fn.Defaults[i])
+		}
+		c.bind(s, &binding{name: param, kind: "parameter"})
+	}
+
+	if fn.Rest != nil {
+		c.bind(s, &binding{name: fn.Rest, kind: "parameter"})
+	}
+
+	c.declare(s, fn.Body)
+	c.statements(s, fn.Body.Statements)
+	c.unused(s)
+}
+
+// arity checks calls of function literals, directly or through the name a
+// let bound one to
+func (c *checker) arity(s *scope, tk ast.Token, callee expression.Expression, args int) {
+	var fn *fnexp.Function
+
+	switch callee := callee.(type) {
+	case *fnexp.Function:
+		fn = callee
+	case *identifier.Identifier:
+		if bindings, _ := c.lookup(s, callee.Value); len(bindings) == 1 {
+			fn = bindings[0].function
+		}
+	}
+
+	if fn == nil {
+		return
+	}
+
+	f := &fnobj.Function{Name: fn.Name, Parameters: fn.Parameters, Defaults: fn.Defaults, Rest: fn.Rest}
+
+	if args < f.Required() || (f.Rest == nil && args > len(f.Parameters)) {
+		c.report(tk, ARITY, "wrong number of arguments to %s: want %s, got %d", f.DisplayName(), f.Arity(), args)
+	}
+}
+
+func (c *checker) comparison(node *infixoperator.InfixOperator) {
+	if node.Operator != "==" && node.Operator != "!=" {
+		return
+	}
+
+	left, leftOk := literalType(node.Left)
+	right, rightOk := literalType(node.Right)
+
+	if !leftOk || !rightOk {
+		return
+	}
+
+	fresh := isFresh(left) || isFresh(right)
+
+	if left == right && !fresh {
+		return
+	}
+
+	result := "false"
+	if node.Operator == "!=" {
+		result = "true"
+	}
+
+	c.report(node.Token, COMPARISON, "comparison of %s and %s with %s is always %s", left, right, node.Operator, result)
+}
@@ -13,7 +13,22 @@ func main() {
 	app := &cli.App{
 		Name:  "repl",
 		Usage: "A REPL for the Monkey programming language",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "path",
+				Aliases: []string{"I"},
+				Usage:   "directory to search for imported modules (repeatable; defaults to the list in " + cmd.SEARCH_PATH_ENV + ")",
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "disable coloured output (also honours NO_COLOR)",
+			},
+		},
 		Action: func(ctx *cli.Context) error {
+			if !cmd.IsInteractive(os.Stdin) {
+				return cmd.Batch(os.Stdin, cmd.SearchPath(ctx.StringSlice("path")))
+			}
+
 			user, err := user.Current()
 			if err != nil {
 				return err
@@ -22,7 +37,130 @@ func main() {
 			fmt.Printf("Hello %s! This is the Monkey programming language REPL!\n", user.Username)
 			fmt.Printf("Feel free to type in Monkey statements!\n")
 
-			return cmd.StartRepl(os.Stdin, os.Stdout)
+			return cmd.StartRepl(
+				os.Stdin,
+				os.Stdout,
+				cmd.WithSearchPath(cmd.SearchPath(ctx.StringSlice("path"))...),
+				cmd.WithColor(!ctx.Bool("no-color")),
+			)
+		},
+		Commands: []*cli.Command{
+			{
+				Name:      "run",
+				Usage:     "Evaluate a Monkey program file",
+				ArgsUsage: "<file>",
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() != 1 {
+						return fmt.Errorf("expected exactly one file to run, got %d", ctx.NArg())
+					}
+
+					return cmd.Run(ctx.Args().First(), cmd.SearchPath(ctx.StringSlice("path")))
+				},
+			},
+			{
+				Name:      "tokens",
+				Usage:     "Print the tokens of a Monkey program with their positions",
+				ArgsUsage: "<file|->",
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() != 1 {
+						return fmt.Errorf("expected exactly one file to tokenize, got %d", ctx.NArg())
+					}
+
+					return cmd.Tokens(os.Stdin, os.Stdout, os.Stderr, ctx.Args().First())
+				},
+			},
+			{
+				Name:      "parse",
+				Usage:     "Print the syntax tree of a Monkey program",
+				ArgsUsage: "<file|->",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "output format: tree, json, dot or cst",
+						Value: cmd.FORMAT_TREE,
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() != 1 {
+						return fmt.Errorf("expected exactly one file to parse, got %d", ctx.NArg())
+					}
+
+					return cmd.Parse(os.Stdin, os.Stdout, ctx.Args().First(), ctx.String("format"))
+				},
+			},
+			{
+				Name:      "vet",
+				Usage:     "Report likely mistakes in a Monkey program",
+				ArgsUsage: "<file|->",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "output format: text or json",
+						Value: cmd.FORMAT_TEXT,
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() != 1 {
+						return fmt.Errorf("expected exactly one file to vet, got %d", ctx.NArg())
+					}
+
+					return cmd.Vet(os.Stdin, os.Stdout, ctx.Args().First(), ctx.String("format"))
+				},
+			},
+			{
+				Name:      "typecheck",
+				Usage:     "Report type mismatches in the annotated parts of a Monkey program",
+				ArgsUsage: "<file|->",
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() != 1 {
+						return fmt.Errorf("expected exactly one file to typecheck, got %d", ctx.NArg())
+					}
+
+					return cmd.Typecheck(os.Stdin, os.Stdout, ctx.Args().First())
+				},
+			},
+			{
+				Name:      "infer",
+				Usage:     "Infer and print the type of each top-level binding of a Monkey program",
+				ArgsUsage: "<file|->",
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() != 1 {
+						return fmt.Errorf("expected exactly one file to infer, got %d", ctx.NArg())
+					}
+
+					return cmd.Infer(os.Stdin, os.Stdout, ctx.Args().First())
+				},
+			},
+			{
+				Name:  "lsp",
+				Usage: "Serve the Language Server Protocol over stdin and stdout",
+				Action: func(ctx *cli.Context) error {
+					return cmd.Lsp(os.Stdin, os.Stdout)
+				},
+			},
+			{
+				Name:      "eval",
+				Usage:     "Evaluate a Monkey program or expression and print its value",
+				ArgsUsage: "<file|->",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "expression",
+						Aliases: []string{"e"},
+						Usage:   "source to evaluate instead of a file",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					if ctx.IsSet("expression") {
+						if ctx.NArg() != 0 {
+							return fmt.Errorf("expected no file with -e, got %d", ctx.NArg())
+						}
+					} else if ctx.NArg() != 1 {
+						return fmt.Errorf("expected exactly one file to evaluate, got %d", ctx.NArg())
+					}
+
+					return cmd.Eval(os.Stdin, os.Stdout, ctx.Args().First(), ctx.String("expression"), cmd.SearchPath(ctx.StringSlice("path")))
+				},
+			},
 		},
 	}
 
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/cmd"
+)
+
+func TestMetaCommands(t *testing.T) {
+	dir := t.TempDir()
+
+	lib := filepath.Join(dir, "lib.mk")
+	require.NoError(t, os.WriteFile(lib, []byte("let triple = fn(n) { n * 3 };"), 0o644))
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "helper.mk"), []byte("export let double = fn(n) { n * 2 };"), 0o644))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	main := filepath.Join(dir, "sub", "main.mk")
+	require.NoError(t, os.WriteFile(main, []byte(`import "helper" as h; let quad = fn(n) { h.double(h.double(n)) };`), 0o644))
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "should show tokens",
+			input:    ":tokens let x = 5;\n",
+			expected: ">> 1:1 LET \"let\"\n1:5 IDENT \"x\"\n1:7 = \"=\"\n1:9 INT \"5\"\n1:10 ; \";\"\n>> ",
+		},
+		{
+			name:     "should show what the lexer could not read after the tokens",
+			input:    ":tokens x @ 1\n",
+			expected: ">> 1:1 IDENT \"x\"\n1:3 ILLEGAL \"@\"\n1:5 INT \"1\"\nunexpected character '@' at 1:3\n>> ",
+		},
+		{
+			name:     "should show the syntax tree",
+			input:    ":ast -a\n",
+			expected: ">> Program\n  Statements[0]: ExpressionStatement\n    Expression: PrefixOperator -\n      Right: Identifier a\n>> ",
+		},
+		{
+			name:     "should report parser errors from :ast",
+			input:    ":ast let = 1;\n",
+			expected: ">> Whoops! We ran into some monkey business here!\n parser errors:\n\texpected next token to be IDENT, got =\n\tno parse function for = found\n>> ",
+		},
+		{
+			name:     "should keep declared operators for the session",
+			input:    "infixl 6 <+> = fn(a, b) { a * 10 + b };\n1 <+> 2\n:tokens 3<+>4\n:reset\n:tokens 3<+>4\n",
+			expected: ">> >> 12\n>> 1:1 INT \"3\"\n1:2 OPERATOR \"<+>\"\n1:5 INT \"4\"\n>> >> 1:1 INT \"3\"\n1:2 < \"<\"\n1:3 + \"+\"\n1:4 > \">\"\n1:5 INT \"4\"\n>> ",
+		},
+		{
+			name:     "should list session bindings",
+			input:    "let x = 1;\nlet s = \"a\";\n:env\n",
+			expected: ">> >> >> s: STRING\nx: INTEGER\n>> ",
+		},
+		{
+			name:     "should show the type of an expression",
+			input:    ":type [1, 2]\n:type fn(x) { x }\n:type nope\n",
+			expected: ">> ARRAY\n>> FUNCTION\n>> Uh oh! The monkey tripped while running that!\n runtime error:\n\tidentifier not found: nope\n>> ",
+		},
+		{
+			name:     "should load a file into the session",
+			input:    ":load " + lib + "\ntriple(3)\n",
+			expected: ">> >> 9\n>> ",
+		},
+		{
+			name:     "should resolve the imports of a loaded file next to it",
+			input:    ":load " + main + "\nquad(3)\nimport \"helper\" as h;\n",
+			expected: ">> >> 12\n>> Uh oh! The monkey tripped while running that!\n runtime error:\n\tcannot find module \"helper\" (looked in " + filepath.Join(cwd, "helper.mk") + ")\n>> ",
+		},
+		{
+			name:     "should report a missing file",
+			input:    ":load " + filepath.Join(dir, "missing.mk") + "\n",
+			expected: ">> Uh oh! The monkey tripped while running that!\n runtime error:\n\topen " + filepath.Join(dir, "missing.mk") + ": no such file or directory\n>> ",
+		},
+		{
+			name:     "should reset the session",
+			input:    "let x = 1;\n:reset\n:env\nx\n",
+			expected: ">> >> >> >> Uh oh! The monkey tripped while running that!\n runtime error:\n\tidentifier not found: x\n>> ",
+		},
+		{
+			name:     "should reject unknown commands",
+			input:    ":nope\n",
+			expected: ">> unknown command :nope, try :help\n>> ",
+		},
+		{
+			name:     "should not treat a continuation line as a command",
+			input:    "{\"a\"\n: 1}[\"a\"]\n",
+			expected: ">> .. 1\n>> ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := cmd.StartRepl(strings.NewReader(tc.input), &out)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestMetaTime(t *testing.T) {
+	var out bytes.Buffer
+
+	err := cmd.StartRepl(strings.NewReader(":time 2 * 21\n"), &out)
+
+	require.NoError(t, err)
+	require.Regexp(t, `^>> 42\nelapsed: \S+\n>> $`, out.String())
+}
+
+func TestMetaFull(t *testing.T) {
+	elements := []string{}
+	for i := 0; i < 105; i++ {
+		elements = append(elements, "0")
+	}
+
+	var out bytes.Buffer
+
+	err := cmd.StartRepl(strings.NewReader("["+strings.Join(elements, ", ")+"]\n:full\n"), &out)
+
+	require.NoError(t, err)
+
+	prompts := strings.Split(out.String(), ">> ")
+	require.Len(t, prompts, 4)
+	require.Contains(t, prompts[1], "... 5 more\n]\n(truncated, :full shows all of _)\n")
+	require.Equal(t, 105, strings.Count(prompts[2], "0"))
+	require.NotContains(t, prompts[2], "more")
+}
+
+func TestMetaHelp(t *testing.T) {
+	var out bytes.Buffer
+
+	err := cmd.StartRepl(strings.NewReader(":help\n"), &out)
+
+	require.NoError(t, err)
+
+	for _, usage := range []string{":tokens <src>", ":ast <src>", ":env", ":type <expr>", ":load <file>", ":reset", ":time <expr>", ":full", ":help"} {
+		require.Contains(t, out.String(), usage)
+	}
+}
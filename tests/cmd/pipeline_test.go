@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/cmd"
+	"github.com/w-h-a/interpreter/internal/ast/codec"
+)
+
+func TestTokens(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.mk")
+	require.NoError(t, os.WriteFile(file, []byte("let x =\n  \"hi\";"), 0o644))
+
+	expected := "1:1 LET \"let\"\n1:5 IDENT \"x\"\n1:7 = \"=\"\n2:4 STRING \"hi\"\n2:7 ; \";\"\n"
+
+	for _, tc := range []struct {
+		name string
+		in   string
+		file string
+	}{
+		{name: "file", file: file},
+		{name: "stdin", in: "let x =\n  \"hi\";", file: "-"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+
+			err := cmd.Tokens(strings.NewReader(tc.in), &out, &errOut, tc.file)
+
+			require.NoError(t, err)
+			require.Equal(t, expected, out.String())
+			require.Empty(t, errOut.String())
+		})
+	}
+
+	// what the lexer cannot read is reported with its position, and fails
+	// the command
+	var out, errOut bytes.Buffer
+
+	err := cmd.Tokens(strings.NewReader("x @ 1;\n\"ab"), &out, &errOut, "-")
+
+	require.EqualError(t, err, "tokens found 2 problem(s) in <stdin>")
+	require.Equal(t, "1:1 IDENT \"x\"\n1:3 ILLEGAL \"@\"\n1:5 INT \"1\"\n1:6 ; \";\"\n2:1 ILLEGAL \"\\\"ab\"\n", out.String())
+	require.Equal(t, "<stdin>:1:3: unexpected character '@'\n<stdin>:2:1: unterminated string\n", errOut.String())
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		format   string
+		expected string
+		err      string
+	}{
+		{
+			name:     "should print a tree",
+			input:    "-x",
+			format:   cmd.FORMAT_TREE,
+			expected: "Program\n  Statements[0]: ExpressionStatement\n    Expression: PrefixOperator -\n      Right: Identifier x\n",
+		},
+		{
+			name:     "should print a concrete tree",
+			input:    "(x) ;",
+			format:   cmd.FORMAT_CST,
+			expected: "Program\n  ExpressionStatement\n    Group\n      ( \"(\"\n      Identifier\n        IDENT \"x\"\n      ) \")\"\n    ; \";\" after \" \"\n  EOF \"\"\n",
+		},
+		{
+			name:   "should report parse errors with the source name",
+			input:  "let = 1;",
+			format: cmd.FORMAT_TREE,
+			err:    "parse errors in <stdin>: expected next token to be IDENT, got =; no parse function for = found",
+		},
+		{
+			name:   "should reject unknown formats",
+			input:  "1",
+			format: "yaml",
+			err:    `unknown format "yaml", want tree, json, dot or cst`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := cmd.Parse(strings.NewReader(tc.input), &out, "-", tc.format)
+
+			if len(tc.err) > 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	var out bytes.Buffer
+
+	err := cmd.Parse(strings.NewReader("f(1)"), &out, "-", cmd.FORMAT_JSON)
+	require.NoError(t, err)
+
+	program, err := codec.Decode(out.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, "f(1)", program.String())
+}
+
+func TestEval(t *testing.T) {
+	dir := t.TempDir()
+
+	main := filepath.Join(dir, "main.mk")
+	require.NoError(t, os.WriteFile(main, []byte(`import "lib" as lib; lib.x * 2`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.mk"), []byte(`export let x = 21;`), 0o644))
+
+	testCases := []struct {
+		name       string
+		in         string
+		file       string
+		expression string
+		expected   string
+		err        string
+	}{
+		{
+			name:       "should evaluate an inline expression",
+			expression: "1 + 2",
+			expected:   "3\n",
+		},
+		{
+			name:     "should evaluate a file",
+			file:     main,
+			expected: "42\n",
+		},
+		{
+			name:     "should evaluate stdin",
+			in:       `let xs = [1, 2]; push(xs, 3)`,
+			file:     "-",
+			expected: "[1, 2, 3]\n",
+		},
+		{
+			name:       "should print puts to the writer it is given",
+			expression: `let say = fn(x) { puts(x) }; [1, 2] |> say; "done"`,
+			expected:   "[1, 2]\ndone\n",
+		},
+		{
+			name: "should evaluate an empty expression",
+		},
+		{
+			name:       "should print nothing for statements without a value",
+			expression: "let x = 1;",
+		},
+		{
+			name:       "should return runtime errors",
+			expression: "nope",
+			err:        "identifier not found: nope",
+		},
+		{
+			name:       "should return parse errors",
+			expression: "(1",
+			err:        "parse errors in <expression>: failed to parse expression literal \"1\": expected next token to be ), got EOF",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := cmd.Eval(strings.NewReader(tc.in), &out, tc.file, tc.expression, nil)
+
+			if len(tc.err) > 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "should run the whole input as one program",
+			input: "let add = fn(a, b) {\n  a + b\n};\n\nadd(1, 2)\n",
+		},
+		{
+			name:  "should fail on runtime errors",
+			input: "let x = 1;\nx + y\n",
+			err:   "identifier not found: y",
+		},
+		{
+			name:  "should fail on parse errors",
+			input: "let = 1;",
+			err:   "parse errors in <stdin>: expected next token to be IDENT, got =; no parse function for = found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := cmd.Batch(strings.NewReader(tc.input), nil)
+
+			if len(tc.err) > 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestIsInteractive(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.False(t, cmd.IsInteractive(f))
+}
+
+func TestSearchPath(t *testing.T) {
+	dir := t.TempDir()
+	lib, other := filepath.Join(dir, "lib,v2"), filepath.Join(dir, "other")
+	for _, d := range []string{lib, other} {
+		require.NoError(t, os.Mkdir(d, 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(other, "m.mk"), []byte("export let x = 1;"), 0o644))
+
+	t.Setenv(cmd.SEARCH_PATH_ENV, lib+string(filepath.ListSeparator)+other)
+
+	require.Equal(t, []string{lib, other}, cmd.SearchPath(nil))
+	require.Equal(t, []string{"flag"}, cmd.SearchPath([]string{"flag"}))
+
+	require.NoError(t, cmd.Batch(strings.NewReader(`import "m" as m; m.x`), cmd.SearchPath(nil)))
+}
+
+func TestVet(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		format   string
+		expected string
+		err      string
+	}{
+		{
+			name:   "should print nothing for a clean program",
+			input:  "let x = 1; x",
+			format: cmd.FORMAT_TEXT,
+		},
+		{
+			name:     "should print problems as text",
+			input:    "let x = 1;\nputs(y);",
+			format:   cmd.FORMAT_TEXT,
+			expected: "<stdin>:1:5: let x is declared but never used (unused)\n<stdin>:2:6: undefined: y (undefined)\n",
+			err:      "vet found 2 problem(s) in <stdin>",
+		},
+		{
+			name:     "should print problems as json",
+			input:    "if (true) { 1 }",
+			format:   cmd.FORMAT_JSON,
+			expected: "[\n  {\n    \"file\": \"<stdin>\",\n    \"line\": 1,\n    \"column\": 5,\n    \"offset\": 4,\n    \"check\": \"constant-condition\",\n    \"message\": \"if condition true is always true\"\n  }\n]\n",
+			err:      "vet found 1 problem(s) in <stdin>",
+		},
+		{
+			name:     "should print an empty json list for a clean program",
+			input:    "1",
+			format:   cmd.FORMAT_JSON,
+			expected: "[]\n",
+		},
+		{
+			name:   "should report parse errors",
+			input:  "let = 1;",
+			format: cmd.FORMAT_TEXT,
+			err:    "parse errors in <stdin>: expected next token to be IDENT, got =; no parse function for = found",
+		},
+		{
+			name:   "should reject unknown formats",
+			input:  "1",
+			format: "yaml",
+			err:    `unknown format "yaml", want text or json`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := cmd.Vet(strings.NewReader(tc.input), &out, "-", tc.format)
+
+			if len(tc.err) > 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestTypecheck(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		err      string
+	}{
+		{
+			name:  "should print nothing for a well-typed program",
+			input: "let x: int = 1; fn(a) { a }(x)",
+		},
+		{
+			name:     "should print mismatches",
+			input:    "let x: string = 1;\nlet f = fn(a: int) -> int { a };\nf(x);",
+			expected: "<stdin>:1:17: cannot use int as string in let x\n<stdin>:3:3: cannot use string as int in argument 1 to f\n",
+			err:      "typecheck found 2 problem(s) in <stdin>",
+		},
+		{
+			name:  "should report parse errors",
+			input: "let x: float = 1;",
+			err:   "parse errors in <stdin>: unknown type float; no parse function for = found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := cmd.Typecheck(strings.NewReader(tc.input), &out, "-")
+
+			if len(tc.err) > 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestInfer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		err      string
+	}{
+		{
+			name:     "should print the type of each top-level binding",
+			input:    "let adder = fn(a) { fn(b) { a + b } };\nlet id = fn(x) { x };\nid(1)",
+			expected: "adder : int -> int -> int\nid : a -> a\n",
+		},
+		{
+			name:  "should report type errors with the file",
+			input: "let f = fn(x) { x + 1 };\nf(true)",
+			err:   "<stdin>:2:3: type mismatch: int from 1:19 conflicts with bool from 2:3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := cmd.Infer(strings.NewReader(tc.input), &out, "-")
+
+			if len(tc.err) > 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
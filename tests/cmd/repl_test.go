@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/cmd"
+)
+
+func TestStartRepl(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "should evaluate and inspect each line",
+			input:    "1 + 2\n\"a\" + \"b\"\n",
+			expected: ">> 3\n>> ab\n>> ",
+		},
+		{
+			name:     "should print puts to the session",
+			input:    "puts(\"hi\", 1)\n",
+			expected: ">> hi\n1\nnull\n>> ",
+		},
+		{
+			name:     "should keep bindings for the session",
+			input:    "let x = 5;\nlet double = fn(n) { n * 2 };\ndouble(x)\n",
+			expected: ">> >> >> 10\n>> ",
+		},
+		{
+			name:     "should bind the last result to _",
+			input:    "6 * 7\n_ + 1\nlet y = 1;\n_\n",
+			expected: ">> 42\n>> 43\n>> >> 43\n>> ",
+		},
+		{
+			name:  "should report parser errors",
+			input: "let = 1;\n",
+			expected: ">> Whoops! We ran into some monkey business here!\n" +
+				" parser errors:\n" +
+				"\texpected next token to be IDENT, got =\n" +
+				"\tno parse function for = found\n" +
+				">> ",
+		},
+		{
+			name:  "should report runtime errors distinctly and keep going",
+			input: "let x = 1;\nx + y\nx\n",
+			expected: ">> >> Uh oh! The monkey tripped while running that!\n" +
+				" runtime error:\n" +
+				"\tidentifier not found: y\n" +
+				">> 1\n>> ",
+		},
+		{
+			name:     "should not bind errors to _",
+			input:    "1\nfoo\n_\n",
+			expected: ">> 1\n>> Uh oh! The monkey tripped while running that!\n runtime error:\n\tidentifier not found: foo\n>> 1\n>> ",
+		},
+		{
+			name:     "should continue an unclosed brace",
+			input:    "let add = fn(a, b) {\n  a + b\n};\nadd(1, 2)\n",
+			expected: ">> .. .. >> 3\n>> ",
+		},
+		{
+			name:     "should continue unclosed parens and brackets",
+			input:    "len([1,\n2,\n3]\n)\n",
+			expected: ">> .. .. .. 3\n>> ",
+		},
+		{
+			name:     "should continue an unterminated string",
+			input:    "\"a\nb\"\n",
+			expected: ">> .. a\nb\n>> ",
+		},
+		{
+			name:     "should continue an unterminated interpolation",
+			input:    "\"${1 +\n2}\"\n",
+			expected: ">> .. 3\n>> ",
+		},
+		{
+			name:     "should continue a trailing operator",
+			input:    "1 +\n2\n",
+			expected: ">> .. 3\n>> ",
+		},
+		{
+			name:     "should continue an incomplete if",
+			input:    "if (true)\n{ 1 }\n",
+			expected: ">> .. 1\n>> ",
+		},
+		{
+			name:  "should force submission on a blank line",
+			input: "fn() {\n\n1\n",
+			expected: ">> .. Whoops! We ran into some monkey business here!\n" +
+				" parser errors:\n" +
+				"\tfailed to parse expression literal \"\": expected next token to be }, got EOF\n" +
+				">> 1\n>> ",
+		},
+		{
+			name:     "should force submission at the end of input",
+			input:    "[1, 2",
+			expected: ">> .. \nWhoops! We ran into some monkey business here!\n parser errors:\n\tfailed to parse expression literal \"2\": expected next token to be ], got EOF\n>> ",
+		},
+		{
+			name:     "should not continue a complete statement with errors",
+			input:    "5 )\n",
+			expected: ">> Whoops! We ran into some monkey business here!\n parser errors:\n\tno parse function for ) found\n>> ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := cmd.StartRepl(strings.NewReader(tc.input), &out)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
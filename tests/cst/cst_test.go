@@ -0,0 +1,118 @@
+package cst
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/ast"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/cst"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/parser"
+)
+
+var inputs = []string{
+	"",
+	"   \n\t ",
+	"let x = 5;",
+	"let  add = fn(a, b = 2, ..rest) {\n\treturn (a + b);\n};\r\nadd(1,2)  ",
+	`let h = {"a": [1, 2], true: null}; h["a"][1] ?? h?.b?.[0]`,
+	`"hi ${ name }, ${ "nested ${1 + 1}" } \"quoted\" \$"`,
+	"if ((x)) { ((1)) } else { [ ] }",
+	"import \"lib/strings\" as s;\nexport let up = s.upper;",
+	"infixr 5 <:> = fn(x, xs) { [x] + xs };\n1 <:> 2 <:> []",
+	"[1, 2] |> len",
+	"let f :fn( [int] ,{ string:bool } )->null = fn(a : int, b: any = 1) ->  int { a };",
+	"let x: float = 1; fn(a: [int) {}",
+	"let = 1; @ # \"unterminated",
+	"fn(a { a",
+	"\"a ${b",
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, input := range inputs {
+		root, _ := cst.Parse(input)
+		require.Equal(t, input, root.String())
+	}
+}
+
+func TestRandomRoundTrip(t *testing.T) {
+	fragments := []string{" ", "\n", "\t", ";", "{", "}", "(", ")", "[", "]", "x", "1", "\"", "${", "+", "fn", "let", "=", ",", "@", "\\", "|>", "?.", "a.b"}
+
+	rng := rand.New(rand.NewSource(44))
+
+	for range 2000 {
+		var input strings.Builder
+		for range rng.Intn(30) {
+			input.WriteString(fragments[rng.Intn(len(fragments))])
+		}
+
+		root, _ := cst.Parse(input.String())
+		require.Equal(t, input.String(), root.String())
+	}
+}
+
+func TestErrorsMatchTheParser(t *testing.T) {
+	for _, input := range inputs {
+		p := parser.New(lexer.New(input))
+		p.ParseProgram()
+
+		_, errors := cst.Parse(input)
+		require.Equal(t, p.Errors(), errors)
+	}
+}
+
+func TestAbstractNodes(t *testing.T) {
+	for _, input := range inputs {
+		root, errors := cst.Parse(input)
+		if len(errors) > 0 {
+			continue
+		}
+
+		require.Equal(t, "Program", root.Kind)
+
+		// every abstract node has a concrete node of the same kind, or a
+		// group of parentheses around it
+		var visit func(node ast.Node)
+		visit = func(node ast.Node) {
+			concrete := root.Find(node)
+			require.NotNil(t, concrete, tree.Label(node))
+			require.Contains(t, []string{tree.Kind(node), cst.GROUP}, concrete.Kind)
+
+			for _, child := range tree.Children(node) {
+				visit(child.Node)
+			}
+		}
+		visit(root.Node)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	input := "let total = (price + tax) * qty;\nputs(total)"
+
+	root, errors := cst.Parse(input)
+	require.Empty(t, errors)
+
+	program := root.Node
+
+	sum := root.Find(program).Children[0].(*cst.Node).Children[3].(*cst.Node).Children[0].(*cst.Node)
+	require.Equal(t, cst.GROUP, sum.Kind)
+	require.Equal(t, " (price + tax)", sum.String())
+	require.Equal(t, "(price + tax)", sum.Node.String())
+}
+
+func TestRewrite(t *testing.T) {
+	input := "let  total =price+tax ;  // keep\n"
+
+	root, _ := cst.Parse(input)
+
+	for _, tk := range root.Tokens() {
+		if tk.Text == "tax" {
+			tk.Text = "vat"
+		}
+	}
+
+	require.Equal(t, "let  total =price+vat ;  // keep\n", root.String())
+}
@@ -0,0 +1,227 @@
+package document
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/ast/codec"
+	"github.com/w-h-a/interpreter/internal/document"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+const source = `let add = fn(a, b) {
+  a + b
+};
+let xs = [1, 2, 3];
+
+let greet = fn(name) { "hello ${name}!" };
+puts(greet("monkey"));
+let total = add(xs[0], xs[1]) * 2;
+if (total > 3) { puts("big") } else { puts("small") }
+let h = {"a": 1, "b": [true, null]};
+h?.a ?? 0
+`
+
+func TestEditsMatchFullParse(t *testing.T) {
+	testCases := []struct {
+		name  string
+		edits []document.Edit
+	}{
+		{
+			name:  "insert inside a statement",
+			edits: []document.Edit{{Start: 26, End: 26, Text: " * 10"}},
+		},
+		{
+			name:  "delete a whole line",
+			edits: []document.Edit{{Start: 33, End: 53, Text: ""}},
+		},
+		{
+			name:  "join two statements by deleting a semicolon",
+			edits: []document.Edit{{Start: 31, End: 32, Text: ""}},
+		},
+		{
+			name:  "break a statement and fix it again",
+			edits: []document.Edit{{Start: 0, End: 3, Text: "le"}, {Start: 0, End: 2, Text: "let"}},
+		},
+		{
+			name:  "insert lines before everything",
+			edits: []document.Edit{{Start: 0, End: 0, Text: "let first = 0;\n\n"}},
+		},
+		{
+			name:  "append at the end",
+			edits: []document.Edit{{Start: len(source), End: len(source), Text: "let last = 1;"}},
+		},
+		{
+			name:  "replace a newline on the same line as later tokens",
+			edits: []document.Edit{{Start: 52, End: 54, Text: " \n"}},
+		},
+		{
+			name:  "open an unterminated interpolation",
+			edits: []document.Edit{{Start: 76, End: 77, Text: "${"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := document.New(source)
+
+			for _, edit := range tc.edits {
+				require.NoError(t, doc.Apply(edit))
+				requireMatchesFullParse(t, doc)
+			}
+		})
+	}
+}
+
+func TestRandomEdits(t *testing.T) {
+	fragments := []string{"", " ", "\n", ";", "}", "{", "(", ")", "x", "let y = 2;", "\"", "${", "+ 1", "fn(a) { a }", "[", "]", "infixl 5 <+> = f;", "<+>"}
+
+	for _, seed := range []int64{1, 41, 2024} {
+		rng := rand.New(rand.NewSource(seed))
+
+		doc := document.New(source)
+
+		for range 500 {
+			text := doc.Text()
+
+			start := rng.Intn(len(text) + 1)
+			end := min(len(text), start+rng.Intn(6))
+
+			require.NoError(t, doc.Apply(document.Edit{Start: start, End: end, Text: fragments[rng.Intn(len(fragments))]}))
+			requireMatchesFullParse(t, doc)
+		}
+	}
+}
+
+func TestReusesUnchangedStatements(t *testing.T) {
+	doc := document.New(source)
+
+	before := doc.Program().Statements
+
+	offset := 28
+	require.NoError(t, doc.Apply(document.Edit{Start: offset, End: offset, Text: " * 10"}))
+
+	after := doc.Program().Statements
+
+	require.Len(t, after, len(before))
+	require.NotSame(t, before[0], after[0])
+
+	for i := 1; i < len(before); i++ {
+		require.Same(t, before[i], after[i], "statement %d was re-parsed", i)
+	}
+
+	requireMatchesFullParse(t, doc)
+}
+
+func TestDeclaredOperators(t *testing.T) {
+	src := "infixr 5 <+> = fn(a, b) { a - b };\nlet x = 1 <+> 2 <+> 3;\nlet infixes = \"infix\";\nlet y = x <+> 1;\n"
+
+	// an edit also reparses the statement before it, which it may join
+	testCases := []struct {
+		name     string
+		edit     document.Edit
+		reparsed []int
+	}{
+		{
+			name:     "should reuse statements after a declaration the edit leaves alone",
+			edit:     document.Edit{Start: 43, End: 44, Text: "10"},
+			reparsed: []int{0, 1},
+		},
+		{
+			name:     "should not reparse for a name or string that contains infix",
+			edit:     document.Edit{Start: 73, End: 73, Text: "es"},
+			reparsed: []int{1, 2},
+		},
+		{
+			name:     "should reread everything after a changed declaration",
+			edit:     document.Edit{Start: 0, End: 6, Text: "infixl"},
+			reparsed: []int{0, 1, 2, 3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := document.New(src)
+
+			before := doc.Program().Statements
+
+			require.NoError(t, doc.Apply(tc.edit))
+
+			after := doc.Program().Statements
+			require.Len(t, after, len(before))
+
+			for i := range before {
+				if slices.Contains(tc.reparsed, i) {
+					require.NotSame(t, before[i], after[i], "statement %d was reused", i)
+				} else {
+					require.Same(t, before[i], after[i], "statement %d was re-parsed", i)
+				}
+			}
+
+			requireMatchesFullParse(t, doc)
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	doc := document.New("let x = 1;\nlet = 2;\nx")
+
+	diagnostics := doc.Diagnostics()
+
+	require.Equal(t, []document.Diagnostic{
+		{
+			Start:   token.Position{Offset: 11, Line: 2, Column: 1},
+			End:     token.Position{Offset: 14, Line: 2, Column: 4},
+			Message: "expected next token to be IDENT, got =",
+		},
+		{
+			Start:   token.Position{Offset: 15, Line: 2, Column: 5},
+			End:     token.Position{Offset: 16, Line: 2, Column: 6},
+			Message: "no parse function for = found",
+		},
+	}, diagnostics)
+
+	require.NoError(t, doc.Apply(document.Edit{Start: 15, End: 15, Text: "y "}))
+	require.Empty(t, doc.Diagnostics())
+	require.Equal(t, "let x = 1;let y = 2;x", doc.Program().String())
+}
+
+func TestOffsets(t *testing.T) {
+	doc := document.New("ab\ncd\n")
+
+	offset, err := doc.Offset(2, 2)
+	require.NoError(t, err)
+	require.Equal(t, 4, offset)
+	require.Equal(t, token.Position{Offset: 4, Line: 2, Column: 2}, doc.Position(4))
+
+	offset, err = doc.Offset(3, 1)
+	require.NoError(t, err)
+	require.Equal(t, 6, offset)
+
+	_, err = doc.Offset(2, 4)
+	require.EqualError(t, err, "column 4 is outside line 2")
+
+	_, err = doc.Offset(4, 1)
+	require.EqualError(t, err, "line 4 is past the end of the document")
+
+	require.EqualError(t, doc.Apply(document.Edit{Start: 4, End: 10}), "edit range 4-10 is outside the document (length 6)")
+}
+
+func requireMatchesFullParse(t *testing.T, doc *document.Document) {
+	t.Helper()
+
+	p := parser.New(lexer.New(doc.Text()))
+
+	expected, err := codec.Encode(p.ParseProgram())
+	require.NoError(t, err)
+
+	actual, err := codec.Encode(doc.Program())
+	require.NoError(t, err)
+
+	require.JSONEq(t, string(expected), string(actual), "text: %q", doc.Text())
+	require.Equal(t, p.Errors(), doc.Errors(), "text: %q", doc.Text())
+}
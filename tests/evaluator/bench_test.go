@@ -0,0 +1,178 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/resolver"
+	"github.com/w-h-a/interpreter/internal/symbol"
+)
+
+// generated programs repeat a small set of names many times, as code
+// generators tend to
+func generatedProgram(functions int) string {
+	var src strings.Builder
+
+	src.WriteString("let accumulate = fn(total, value, weight) { total + value * weight };\n")
+
+	for i := range functions {
+		fmt.Fprintf(&src, "let step%s = fn(total, value) {\n", name(i))
+		src.WriteString("  let weight = value - 1;\n")
+		src.WriteString("  let scaled = accumulate(total, value, weight);\n")
+		src.WriteString("  if (scaled > total) { scaled - total } else { total - scaled }\n")
+		src.WriteString("};\n")
+	}
+
+	src.WriteString("let total = 0;\n")
+
+	for i := range functions {
+		fmt.Fprintf(&src, "let total = step%s(total, %d);\n", name(i), i%7)
+	}
+
+	src.WriteString("total;\n")
+
+	return src.String()
+}
+
+// identifiers are letters only
+func name(i int) string {
+	var out []byte
+	for {
+		out = append(out, byte('a'+i%26))
+		i /= 26
+		if i == 0 {
+			return string(out)
+		}
+	}
+}
+
+// each benchmark here runs in both builds: go test -bench . compares
+// against go test -tags nosymbols -bench ., where symbols are the names
+// themselves, as before interning
+
+func BenchmarkLexAndParse(b *testing.B) {
+	src := generatedProgram(2000)
+
+	b.Run("lex", func(b *testing.B) {
+		b.SetBytes(int64(len(src)))
+		b.ReportAllocs()
+
+		for range b.N {
+			for range lexer.New(src).All() {
+			}
+		}
+	})
+
+	b.Run("parse", func(b *testing.B) {
+		b.SetBytes(int64(len(src)))
+		b.ReportAllocs()
+
+		for range b.N {
+			p := parser.New(lexer.New(src))
+			p.ParseProgram()
+			if len(p.Errors()) > 0 {
+				b.Fatal(p.Errors())
+			}
+		}
+	})
+}
+
+func BenchmarkParseAndEval(b *testing.B) {
+	src := generatedProgram(2000)
+
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+
+	for range b.N {
+		p := parser.New(lexer.New(src))
+		program := p.ParseProgram()
+		if len(p.Errors()) > 0 {
+			b.Fatal(p.Errors())
+		}
+
+		resolver.Resolve(program)
+
+		evaluator.Eval(program, environment.New())
+	}
+}
+
+const fib = `
+let fib = fn(n) {
+  if (n < 2) { return n; }
+  let a = fib(n - 1);
+  let b = fib(n - 2);
+  a + b
+};
+fib(20);
+`
+
+func BenchmarkEval(b *testing.B) {
+	for _, program := range []struct {
+		name string
+		src  string
+	}{
+		{name: "generated", src: generatedProgram(2000)},
+		{name: "fib", src: fib},
+	} {
+		for _, resolved := range []bool{false, true} {
+			name := program.name + "/by name"
+			if resolved {
+				name = program.name + "/resolved"
+			}
+
+			b.Run(name, func(b *testing.B) {
+				p := parser.New(lexer.New(program.src))
+				parsed := p.ParseProgram()
+
+				if resolved {
+					resolver.Resolve(parsed)
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for range b.N {
+					evaluator.Eval(parsed, environment.New())
+				}
+			})
+		}
+	}
+}
+
+// a lookup from deep inside nested calls walks every enclosing scope
+func BenchmarkEnvironmentLookup(b *testing.B) {
+	global := environment.New()
+	global.Set("accumulate", evaluator.NULL)
+
+	env := global
+	for range 16 {
+		env = environment.NewEnclosed(env)
+		env.Set("total", evaluator.NULL)
+		env.Set("value", evaluator.NULL)
+	}
+
+	b.Run("by name", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, ok := env.Get("accumulate"); !ok {
+				b.Fatal("accumulate not found")
+			}
+		}
+	})
+
+	b.Run("by symbol", func(b *testing.B) {
+		s := symbol.Intern("accumulate")
+
+		b.ReportAllocs()
+		for range b.N {
+			if _, ok := env.GetSymbol(s); !ok {
+				b.Fatal("accumulate not found")
+			}
+		}
+	})
+}
@@ -7,8 +7,14 @@ import (
 	"github.com/w-h-a/interpreter/internal/evaluator"
 	"github.com/w-h-a/interpreter/internal/lexer"
 	"github.com/w-h-a/interpreter/internal/object"
+	"github.com/w-h-a/interpreter/internal/object/array"
+	"github.com/w-h-a/interpreter/internal/object/boolean"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	errorobject "github.com/w-h-a/interpreter/internal/object/error"
 	"github.com/w-h-a/interpreter/internal/object/integer"
+	stringobject "github.com/w-h-a/interpreter/internal/object/string"
 	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/resolver"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -19,6 +25,10 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}{
 		{"should evaluate '5' as 5", "5", 5},
 		{"should evaluate '10' as 10", "10", 10},
+		{"should evaluate '-5' as -5", "-5", -5},
+		{"should evaluate '5 + 5 + 5 + 5 - 10' as 10", "5 + 5 + 5 + 5 - 10", 10},
+		{"should evaluate '2 * (5 + 10)' as 30", "2 * (5 + 10)", 30},
+		{"should evaluate '(5 + 10 * 2 + 15 / 3) * 2 + -10' as 50", "(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
 	}
 
 	for _, test := range tests {
@@ -29,15 +39,348 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output bool
+	}{
+		{"should evaluate 'true' as true", "true", true},
+		{"should evaluate '1 < 2' as true", "1 < 2", true},
+		{"should evaluate '1 == 2' as false", "1 == 2", false},
+		{"should evaluate '(1 < 2) == true' as true", "(1 < 2) == true", true},
+		{"should evaluate 'true != false' as true", "true != false", true},
+		{"should evaluate '!5' as false", "!5", false},
+		{"should evaluate '!!true' as true", "!!true", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			testBooleanObject(t, test.output, evaluated)
+		})
+	}
+}
+
+func TestEvalIfExpression(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output any
+	}{
+		{"should evaluate the consequence of a truthy condition", "if (1 < 2) { 10 }", 10},
+		{"should evaluate the alternative of a falsy condition", "if (1 > 2) { 10 } else { 20 }", 20},
+		{"should evaluate to null without an alternative", "if (false) { 10 }", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			if test.output == nil {
+				require.Equal(t, evaluator.NULL, evaluated)
+				return
+			}
+			testIntegerObject(t, int64(test.output.(int)), evaluated)
+		})
+	}
+}
+
+func TestEvalReturnStatement(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output int64
+	}{
+		{"should stop at a top level return", "9; return 2 * 5; 9;", 10},
+		{"should stop at a nested return", "if (10 > 1) { if (10 > 1) { return 10; } return 1; }", 10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			testIntegerObject(t, test.output, evaluated)
+		})
+	}
+}
+
+func TestEvalLetStatement(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output int64
+	}{
+		{"should bind an integer", "let a = 5; a;", 5},
+		{"should bind an expression", "let a = 5 * 5; a;", 25},
+		{"should bind other bindings", "let a = 5; let b = a; let c = a + b + 5; c;", 15},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			testIntegerObject(t, test.output, evaluated)
+		})
+	}
+}
+
+func TestEvalFunctionApplication(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output int64
+	}{
+		{"should apply an identity function", "let identity = fn(x) { x; }; identity(5);", 5},
+		{"should apply an explicit return", "let identity = fn(x) { return x; }; identity(5);", 5},
+		{"should apply nested calls", "let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"should apply an immediately invoked function", "fn(x) { x; }(5)", 5},
+		{"should apply a closure", "let newAdder = fn(x) { fn(y) { x + y }; }; let addTwo = newAdder(2); addTwo(2);", 4},
+		{"should use a default when an argument is missing", "let add = fn(a, b = 10) { a + b }; add(1);", 11},
+		{"should prefer a given argument over a default", "let add = fn(a, b = 10) { a + b }; add(1, 2);", 3},
+		{"should evaluate a default against earlier parameters", "let f = fn(a, b = a * 2) { b }; f(4);", 8},
+		{"should evaluate a default in the closure", "let n = 3; let f = fn(a = n) { a }; let n = 4; f();", 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			testIntegerObject(t, test.output, evaluated)
+		})
+	}
+}
+
+func TestEvalRestParameter(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output []int64
+	}{
+		{"should collect extra arguments", "let f = fn(a, ..rest) { rest }; f(1, 2, 3);", []int64{2, 3}},
+		{"should collect no arguments", "let f = fn(a, ..rest) { rest }; f(1);", []int64{}},
+		{"should collect after defaults", "let f = fn(a, b = 10, ..rest) { rest }; f(1, 2, 3, 4);", []int64{3, 4}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			result, ok := evaluated.(*array.Array)
+			require.True(t, ok)
+			require.Equal(t, len(test.output), len(result.Elements))
+			for i, want := range test.output {
+				testIntegerObject(t, want, result.Elements[i])
+			}
+		})
+	}
+}
+
+func TestEvalPipelineExpression(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output int64
+	}{
+		{"should pipe into a bare function", "let double = fn(x) { x * 2 }; 5 |> double;", 10},
+		{"should pipe as the first argument of a call", "let sub = fn(a, b) { a - b }; 10 |> sub(3);", 7},
+		{"should pipe left to right", "let add = fn(a, b) { a + b }; let double = fn(x) { x * 2 }; 1 |> add(2) |> double;", 6},
+		{"should pipe into a function literal", "3 |> fn(x) { x + 1 };", 4},
+		{"should pipe into a returned function", "let adder = fn(n) { fn(x) { x + n } }; 1 |> adder(2)();", 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			testIntegerObject(t, test.output, evaluated)
+		})
+	}
+}
+
+func TestEvalDeclaredOperators(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output int64
+	}{
+		{"should call the bound function", "infixl 6 <+> = fn(a, b) { a * 10 + b }; 1 <+> 2;", 12},
+		{"should associate to the left", "infixl 5 <-> = fn(a, b) { a - b }; 10 <-> 3 <-> 2;", 5},
+		{"should associate to the right", "infixr 5 <-> = fn(a, b) { a - b }; 10 <-> 3 <-> 2;", 9},
+		{"should bind by precedence", "infixl 6 <*> = fn(a, b) { a * b }; 1 + 2 <*> 3;", 7},
+		{"should allow recursion", "infixl 6 ** = fn(a, n) { if (n == 0) { 1 } else { a * (a ** (n - 1)) } }; 2 ** 10;", 1024},
+		{"should not need spaces", "infixl 6 <+> = fn(a, b) { a + b }; 1<+>-2;", -1},
+		{"should declare the arrow of result types", "infixr 5 -> = fn(a: int, b: int) -> int { a - b }; let f = fn(x) -> int { x -> 1 }; 10 -> f(3) -> 1;", 9},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			testIntegerObject(t, test.output, evaluated)
+		})
+	}
+}
+
+func TestEvalNullSafeExpression(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output any
+	}{
+		{"should evaluate the null literal", "null", nil},
+		{"should evaluate a string literal", `"hello"`, "hello"},
+		{"should concatenate strings", `"hello" + " " + "world"`, "hello world"},
+		{"should index an array", "[1, 2 * 2, 3][1]", 4},
+		{"should index an array out of range as null", "[1, 2, 3][3]", nil},
+		{"should index an array with a negative index as null", "[1, 2, 3][-1]", nil},
+		{"should index a hash", `{"one": 1, "two": 2}["two"]`, 2},
+		{"should index a hash with non-string keys", `{true: 1, 5: 2}[5]`, 2},
+		{"should index a missing hash key as null", `{"one": 1}["two"]`, nil},
+		{"should access a hash field", `let h = {"name": "monkey"}; h.name`, "monkey"},
+		{"should access a missing hash field as null", `let h = {"name": "monkey"}; h.age`, nil},
+		{"should coalesce null", "null ?? 5", 5},
+		{"should not coalesce a value", "1 ?? 5", 1},
+		{"should not coalesce false", "false ?? 5", false},
+		{"should coalesce a missing field", `let config = {"port": 8080}; config.host ?? "localhost"`, "localhost"},
+		{"should coalesce an out of range index", "[1][4] ?? 0", 0},
+		{"should short circuit an optional field", "let config = null; config?.port", nil},
+		{"should short circuit an optional index", "let xs = null; xs?.[0]", nil},
+		{"should short circuit the whole chain", "let config = null; config?.server.ports[0]", nil},
+		{"should follow an optional chain with a value", `let config = {"server": {"ports": [80, 443]}}; config?.server?.ports?.[1]`, 443},
+		{"should coalesce an optional chain", `let config = {}; config.server?.port ?? 8080`, 8080},
+		{"should not evaluate the right of a coalesce", "let f = fn() { 1 / 0 }; 1 ?? f()", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			switch want := test.output.(type) {
+			case nil:
+				require.Equal(t, evaluator.NULL, evaluated)
+			case int:
+				testIntegerObject(t, int64(want), evaluated)
+			case bool:
+				testBooleanObject(t, want, evaluated)
+			case string:
+				result, ok := evaluated.(*stringobject.String)
+				require.True(t, ok)
+				require.Equal(t, want, result.Value)
+			}
+		})
+	}
+}
+
+func TestEvalInterpolation(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{"should interpolate fields and calls", `let user = {"name": "Ada"}; let items = [1, 2]; "Hello ${user.name}, you have ${len(items)} items"`, "Hello Ada, you have 2 items"},
+		{"should interpolate with inspect", `"${[1, true]} ${null} ${1 + 2}"`, "[1, true] null 3"},
+		{"should interpolate nested strings", `let n = "x"; "<${"[${n}]"}>"`, "<[x]>"},
+		{"should interpolate hash literals", `"${ {"a": 1}["a"] }"`, "1"},
+		{"should unescape literal parts", `"tab\tquote\"dollar\${x}"`, "tab\tquote\"dollar${x}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			result, ok := evaluated.(*stringobject.String)
+			require.True(t, ok)
+			require.Equal(t, test.output, result.Value)
+		})
+	}
+}
+
+func TestEvalBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output any
+	}{
+		{"should count string bytes", `len("four")`, 4},
+		{"should count array elements", `len([1, 2, 3])`, 3},
+		{"should count hash pairs", `len({"a": 1})`, 1},
+		{"should return the first element", `first([1, 2, 3])`, 1},
+		{"should return the last element", `last([1, 2, 3])`, 3},
+		{"should return the rest of an array", `len(rest([1, 2, 3]))`, 2},
+		{"should push onto a copy", `let a = [1]; let b = push(a, 2); len(a) + len(b)`, 3},
+		{"should pipe into a builtin", `[1, 2] |> len`, 2},
+		{"should reject unsupported arguments", `len(1)`, "argument to len not supported: INTEGER"},
+		{"should reject wrong arity", `len("a", "b")`, "wrong number of arguments to len: want 1, got 2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			switch want := test.output.(type) {
+			case int:
+				testIntegerObject(t, int64(want), evaluated)
+			case string:
+				result, ok := evaluated.(*errorobject.Error)
+				require.True(t, ok)
+				require.Equal(t, want, result.Message)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{"should report a type mismatch", "5 + true; 5;", "type mismatch: INTEGER + BOOLEAN"},
+		{"should report an unknown prefix operator", "-true", "unknown operator: -BOOLEAN"},
+		{"should report an unknown infix operator", "if (10 > 1) { true + false; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"should report an unbound identifier", "foobar", "identifier not found: foobar"},
+		{"should report division by zero", "1 / 0", "division by zero: 1 / 0"},
+		{"should report calling a non-function", "let x = 1; x(1);", "not a function: INTEGER"},
+		{"should report too many arguments", "let add = fn(a, b) { a + b }; add(1, 2, 3);", "wrong number of arguments to add: want 2, got 3"},
+		{"should report too few arguments", "let add = fn(a, b = 1) { a + b }; add();", "wrong number of arguments to add: want 1 to 2, got 0"},
+		{"should report too few arguments with rest", "let f = fn(a, ..rest) { a }; f();", "wrong number of arguments to f: want at least 1, got 0"},
+		{"should report an anonymous function", "fn(a) { a }();", "wrong number of arguments to <anonymous>: want 1, got 0"},
+		{"should report errors from defaults", "let f = fn(a = b) { a }; f();", "identifier not found: b"},
+		{"should report piping into a non-function", "let x = 1; 2 |> x;", "not a function: INTEGER in (2 |> x)"},
+		{"should report string subtraction", `"a" - "b"`, "unknown operator: STRING - STRING"},
+		{"should report an unusable hash key", `{fn(x) { x }: 1}`, "unusable as hash key: FUNCTION"},
+		{"should report indexing a non-collection", "1[0]", "index operator not supported: INTEGER[INTEGER]"},
+		{"should report field access on null", "let config = null; config.port", "field access not supported: NULL.port"},
+		{"should report field access inside an optional chain", "let config = {}; config?.server.port", "field access not supported: NULL.port"},
+		{"should report errors at the interpolated expression", "let x = 1;\n\"value: ${x + y}\"", "identifier not found: y at 2:11"},
+		{"should report imports without an importer", `import "lib" as l;`, `cannot import "lib": imports are not supported here`},
+		{"should report arity errors through a pipeline", "let f = fn(a) { a }; 1 |> f(2);", "wrong number of arguments to f: want 1, got 2"},
+		{"should report arity errors of a declared operator", "infixl 6 <+> = fn(a) { a }; 1 <+> 2;", "wrong number of arguments to <+>: want 1, got 2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := testEval(t, test.input)
+			result, ok := evaluated.(*errorobject.Error)
+			require.True(t, ok)
+			require.Equal(t, test.output, result.Message)
+		})
+	}
+}
+
 func testEval(t *testing.T, input string) object.Object {
-	tks := lexer.Lex(input)
+	tks := lexer.New(input)
 	p := parser.New(tks)
 	program := p.ParseProgram()
 	errors := p.Errors()
 
 	require.True(t, len(errors) == 0)
 
-	return evaluator.Eval(program)
+	evaluated := evaluator.Eval(program, environment.New())
+
+	// with frame slots from the resolver the program must do the same
+	resolver.Resolve(program)
+	require.Equal(t, inspect(evaluated), inspect(evaluator.Eval(program, environment.New())))
+
+	return evaluated
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return ""
+	}
+	return obj.Inspect()
 }
 
 func testIntegerObject(t *testing.T, expected int64, obj object.Object) {
@@ -45,3 +388,9 @@ func testIntegerObject(t *testing.T, expected int64, obj object.Object) {
 	require.True(t, ok)
 	require.Equal(t, expected, result.Value)
 }
+
+func testBooleanObject(t *testing.T, expected bool, obj object.Object) {
+	result, ok := obj.(*boolean.Boolean)
+	require.True(t, ok)
+	require.Equal(t, expected, result.Value)
+}
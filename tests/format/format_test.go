@@ -0,0 +1,117 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/format"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/parser"
+)
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "should put each statement on its own line",
+			input:    "let x=1;let y   =  x+2;puts(y)",
+			expected: "let x = 1;\nlet y = x + 2;\nputs(y);\n",
+		},
+		{
+			name:     "should keep a short block on one line",
+			input:    "let add = fn(a,b){a+b};",
+			expected: "let add = fn(a, b) { a + b };\n",
+		},
+		{
+			name:     "should indent longer blocks",
+			input:    "let f = fn(x) { let y = x * 2; if (y > 10) { return y; } else { y + 1 } };",
+			expected: "let f = fn(x) {\n  let y = x * 2;\n  if (y > 10) {\n    return y;\n  } else { y + 1 }\n};\n",
+		},
+		{
+			name:     "should print empty blocks",
+			input:    "let f = fn() {};",
+			expected: "let f = fn() {};\n",
+		},
+		{
+			name:     "should drop parentheses the operators do not need",
+			input:    "((1 + 2)) + (3 * 4); (1 + 2) * 3; 1 - (2 - 3); -(1 + 2); (-1)",
+			expected: "1 + 2 + 3 * 4;\n(1 + 2) * 3;\n1 - (2 - 3);\n-(1 + 2);\n-1;\n",
+		},
+		{
+			name:     "should follow declared fixities",
+			input:    "infixr 6 <+> = fn(a, b) { a * 10 + b }; (1 <+> 2) <+> 3; 1 <+> (2 <+> 3); (1 + 2) <+> 3",
+			expected: "infixr 6 <+> = fn(a, b) { a * 10 + b };\n(1 <+> 2) <+> 3;\n1 <+> 2 <+> 3;\n(1 + 2) <+> 3;\n",
+		},
+		{
+			name:     "should print calls, indexes, fields and pipelines",
+			input:    "[1,2,3] |> rest |> len; (fn(x){x})(1); {\"a\": 1}.a; a?.b?.[0]; (f |> g)(1)",
+			expected: "[1, 2, 3] |> rest |> len;\nfn(x) { x }(1);\n{\"a\": 1}.a;\na?.b?.[0];\n(f |> g)(1);\n",
+		},
+		{
+			name:     "should keep strings and interpolations as written",
+			input:    `let name = "a\tb"; "hi ${name  + "!"} there";`,
+			expected: "let name = \"a\\tb\";\n\"hi ${name + \"!\"} there\";\n",
+		},
+		{
+			name:     "should keep annotations, defaults and rest parameters",
+			input:    "let f: fn(int) -> int = fn(x: int, y = 2, ..zs) -> int { x + y };",
+			expected: "let f: fn(int) -> int = fn(x: int, y = 2, ..zs) -> int { x + y };\n",
+		},
+		{
+			name:     "should keep the annotation of a rest parameter",
+			input:    "let f = fn(x: int,..zs:[int]) { zs };",
+			expected: "let f = fn(x: int, ..zs: [int]) { zs };\n",
+		},
+		{
+			name:     "should print imports and exports",
+			input:    `import "math" as m; export let x = m.pi;`,
+			expected: "import \"math\" as m;\nexport let x = m.pi;\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.New(lexer.New(tc.input))
+			program := p.ParseProgram()
+			require.Empty(t, p.Errors())
+
+			formatted := format.Format(program)
+			require.Equal(t, tc.expected, formatted)
+
+			// formatting is stable
+			p = parser.New(lexer.New(formatted))
+			program = p.ParseProgram()
+			require.Empty(t, p.Errors())
+			require.Equal(t, formatted, format.Format(program))
+		})
+	}
+}
+
+func TestFormatKeepsMeaning(t *testing.T) {
+	inputs := []string{
+		`infixr 5 <+> = f; ([1, a] <+> (null - "s")) - true`,
+		`infixr 5 <+> = f; (a - (b <+> c)) <+> d`,
+		`infixr 5 <+> = f; (a <+> b) <+> c; a <+> b <+> c`,
+		`infix 4 <=> = f; (f(a, 2) <=> true) > x; x < (a <=> b)`,
+		`infix 4 <=> = f; (a <=> b) <=> c; a <=> (b <=> c)`,
+		`infixl 6 <*> = f; a * b <*> c; a <*> (b * c); a - b - c; a - (b - c)`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			p := parser.New(lexer.New(input))
+			program := p.ParseProgram()
+			require.Empty(t, p.Errors())
+
+			formatted := format.Format(program)
+
+			p = parser.New(lexer.New(formatted))
+			reparsed := p.ParseProgram()
+			require.Empty(t, p.Errors(), formatted)
+			require.Equal(t, program.String(), reparsed.String(), formatted)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package infer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/infer"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/parser"
+)
+
+func TestInfer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "should infer literals",
+			input:    `let a = 1; let b = "b"; let c = true; let d = null; let e = [1, 2]; let f = {"x": [true]}; let g = "${a}!"`,
+			expected: []string{"a : int", "b : string", "c : bool", "d : null", "e : [int]", "f : {string: [bool]}", "g : string"},
+		},
+		{
+			name:     "should infer curried functions",
+			input:    `let adder = fn(a) { fn(b) { a + b } };`,
+			expected: []string{"adder : int -> int -> int"},
+		},
+		{
+			name:     "should infer the most general type",
+			input:    `let id = fn(x) { x }; let compose = fn(f, g) { fn(x) { f(g(x)) } }; let const = fn(a, b) { a };`,
+			expected: []string{"id : a -> a", "compose : (a -> b, c -> a) -> c -> b", "const : (a, b) -> a"},
+		},
+		{
+			name:     "should use a let-bound function at different types",
+			input:    `let id = fn(x) { x }; let n = id(1); let s = id("s"); let both = fn() { let twice = fn(f, x) { f(f(x)) }; [twice(fn(n) { n * 2 }, 1), len(twice(fn(s) { s + "!" }, "a"))] };`,
+			expected: []string{"id : a -> a", "n : int", "s : string", "both : () -> [int]"},
+		},
+		{
+			name:     "should infer recursive functions and returns",
+			input:    `let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) };`,
+			expected: []string{"fib : int -> int"},
+		},
+		{
+			name:     "should infer through builtins, indexes and pipelines",
+			input:    `let map = fn(f, xs) { if (len(xs) == 0) { [] } else { [f(first(xs))] } }; let h = {"a": 1}; let a = h["a"] + h.a; let l = [1, 2] |> len;`,
+			expected: []string{"map : (a -> b, [a]) -> [b]", "h : {string: int}", "a : int", "l : int"},
+		},
+		{
+			name:     "should infer operators",
+			input:    `let not = fn(x) { !x }; let neg = fn(x) { -x }; let eq = fn(a, b) { a == b }; let join = fn(s) { s + "" }; infixl 5 <+> = fn(a, b) { [a, b] }; let p = 1 <+> 2;`,
+			expected: []string{"not : a -> bool", "neg : int -> int", "eq : (a, a) -> bool", "join : string -> string", "<+> : (a, a) -> [a]", "p : [int]"},
+		},
+		{
+			name:     "should take annotations as given",
+			input:    `let f: fn(int) -> int = fn(x) { x }; let g = fn(x: string, y) -> [any] { [y] };`,
+			expected: []string{"f : int -> int", "g : (string, a) -> [a]"},
+		},
+		{
+			name:     "should infer default and rest parameters",
+			input:    `let add = fn(a, b = 2, ..rest: [int]) { a + b + len(rest) }; let x = add(1); let y = add(1, 2, 3, 4); let pair = fn(x, y = x) { [x, y] }; let all = fn(..xs) { xs }; let s = all("a", "b");`,
+			expected: []string{"add : (int, int?, ..[int]) -> int", "x : int", "y : int", "pair : (a, a?) -> [a]", "all : (..[a]) -> [a]", "s : [string]"},
+		},
+		{
+			name:     "should give way to the right of a null coalesced",
+			input:    `let x = null ?? 1; let n = null; let s = n ?? "s"; let f = fn(a) { a ?? 0 };`,
+			expected: []string{"x : int", "n : null", "s : string", "f : int -> int"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.New(lexer.New(tc.input))
+
+			program := p.ParseProgram()
+			require.Empty(t, p.Errors())
+
+			bindings, err := infer.Infer(program)
+			require.NoError(t, err)
+
+			actual := []string{}
+			for _, b := range bindings {
+				actual = append(actual, b.String())
+			}
+
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestInferErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "should point at both sides of a mismatch",
+			input: "let f = fn(x) { x + 1 };\nf(\"a\")",
+			err:   "2:4: type mismatch: int from 1:19 conflicts with string from 2:4",
+		},
+		{
+			name:  "should not generalize parameters",
+			input: `let f = fn(g) { [g(1), g("a")] };`,
+			err:   "1:27: type mismatch: int from 1:20 conflicts with string from 1:27",
+		},
+		{
+			name:  "should report branches of different types",
+			input: `let f = fn(a) { if (a) { 1 } else { "no" } };`,
+			err:   "1:35: type mismatch: int from 1:26 conflicts with string from 1:38",
+		},
+		{
+			name:  "should report an if without an alternative that is not null",
+			input: `let f = fn(a) { if (a) { 1 } };`,
+			err:   "1:17: type mismatch: int from 1:26 conflicts with null from 1:17",
+		},
+		{
+			name:  "should report infinite types",
+			input: `let g = fn(x) { x(x) };`,
+			err:   "1:19: infinite type: a occurs in a -> b",
+		},
+		{
+			name:  "should report calls of non-functions",
+			input: `let x = 1; x(2)`,
+			err:   "1:12: cannot call x of type int from 1:9",
+		},
+		{
+			name:  "should report the wrong number of arguments",
+			input: `let f = fn(a, b) { a }; f(1)`,
+			err:   "1:25: wrong number of arguments to f: want 2, got 1",
+		},
+		{
+			name:  "should report undefined names",
+			input: `let f = fn() { g() };`,
+			err:   "1:16: undefined: g",
+		},
+		{
+			name:  "should report a mismatch with an annotation",
+			input: `let s: string = 1 + 2;`,
+			err:   "1:19: type mismatch: string from 1:8 conflicts with int from 1:19",
+		},
+		{
+			name:  "should report a default of the wrong type",
+			input: `let f = fn(a = "x") { a - 1 };`,
+			err:   "1:23: type mismatch: int from 1:25 conflicts with string from 1:17",
+		},
+		{
+			name:  "should report rest arguments of the wrong type",
+			input: `let g = fn(..r: [int]) { r }; g(1, "2")`,
+			err:   "1:37: type mismatch: int from 1:18 conflicts with string from 1:37",
+		},
+		{
+			name:  "should report the wrong number of arguments with defaults",
+			input: `let f = fn(a, b = 1) { a + b }; f(1, 2, 3)`,
+			err:   "1:33: wrong number of arguments to f: want 1 to 2, got 3",
+		},
+		{
+			name:  "should report what it does not infer",
+			input: `import "m" as m;`,
+			err:   "1:1: cannot infer the type of Import",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.New(lexer.New(tc.input))
+
+			program := p.ParseProgram()
+			require.Empty(t, p.Errors())
+
+			_, err := infer.Infer(program)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -38,6 +40,89 @@ var (
 				{token.EOF, ""},
 			},
 		},
+		{
+			input: `fn(a, ..rest)`,
+			wants: []want{
+				{token.Function, "fn"},
+				{token.ParenLeft, "("},
+				{token.Ident, "a"},
+				{token.Comma, ","},
+				{token.Spread, ".."},
+				{token.Ident, "rest"},
+				{token.ParenRight, ")"},
+				{token.EOF, ""},
+			},
+		},
+		{
+			input: `x |> f |`,
+			wants: []want{
+				{token.Ident, "x"},
+				{token.Pipe, "|>"},
+				{token.Ident, "f"},
+				{token.Illegal, "|"},
+				{token.EOF, ""},
+			},
+		},
+		{
+			input: `"foo bar" [1, 2]; {"a": null}; a?.b ?? c?.[0].d; "`,
+			wants: []want{
+				{token.String, "foo bar"},
+				{token.BracketLeft, "["},
+				{token.Int, "1"},
+				{token.Comma, ","},
+				{token.Int, "2"},
+				{token.BracketRight, "]"},
+				{token.Semicolon, ";"},
+				{token.BraceLeft, "{"},
+				{token.String, "a"},
+				{token.Colon, ":"},
+				{token.Null, "null"},
+				{token.BraceRight, "}"},
+				{token.Semicolon, ";"},
+				{token.Ident, "a"},
+				{token.OptionalChain, "?."},
+				{token.Ident, "b"},
+				{token.Coalesce, "??"},
+				{token.Ident, "c"},
+				{token.OptionalChain, "?."},
+				{token.BracketLeft, "["},
+				{token.Int, "0"},
+				{token.BracketRight, "]"},
+				{token.Dot, "."},
+				{token.Ident, "d"},
+				{token.Semicolon, ";"},
+				{token.Illegal, "\""},
+				{token.EOF, ""},
+			},
+		},
+		{
+			input: `"Hello ${user.name}, you have ${len(items)} items" "a\"${ {"b": "${c}"}["b"] }\$"`,
+			wants: []want{
+				{token.StringStart, "Hello "},
+				{token.Ident, "user"},
+				{token.Dot, "."},
+				{token.Ident, "name"},
+				{token.StringMiddle, ", you have "},
+				{token.Ident, "len"},
+				{token.ParenLeft, "("},
+				{token.Ident, "items"},
+				{token.ParenRight, ")"},
+				{token.StringEnd, " items"},
+				{token.StringStart, `a\"`},
+				{token.BraceLeft, "{"},
+				{token.String, "b"},
+				{token.Colon, ":"},
+				{token.StringStart, ""},
+				{token.Ident, "c"},
+				{token.StringEnd, ""},
+				{token.BraceRight, "}"},
+				{token.BracketLeft, "["},
+				{token.String, "b"},
+				{token.BracketRight, "]"},
+				{token.StringEnd, `\$`},
+				{token.EOF, ""},
+			},
+		},
 		{
 			input: `10 == 10;
 10 != 9;`,
@@ -133,6 +218,136 @@ func TestLexer(t *testing.T) {
 	}
 }
 
+func TestNextToken(t *testing.T) {
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+
+		for _, want := range tc.wants {
+			tk := l.NextToken()
+			require.Equal(t, want.expectedType, tk.Type)
+			require.Equal(t, want.expectedLiteral, tk.Literal())
+		}
+
+		// the lexer keeps answering EOF once the input is exhausted
+		for range 3 {
+			require.Equal(t, token.EOF, l.NextToken().Type)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	for _, tc := range testCases {
+		i := 0
+
+		for tk := range lexer.Tokens(tc.input) {
+			require.Less(t, i, len(tc.wants))
+			require.Equal(t, tc.wants[i].expectedType, tk.Type)
+			require.Equal(t, tc.wants[i].expectedLiteral, tk.Literal())
+			i++
+		}
+
+		require.Equal(t, len(tc.wants), i)
+	}
+}
+
+func TestTokensStopEarly(t *testing.T) {
+	l := lexer.New("let x = 5; let y = 6;")
+
+	for tk := range l.All() {
+		if tk.Type == token.Semicolon {
+			break
+		}
+	}
+
+	// stopping early leaves the rest of the input for the next pull
+	require.Equal(t, token.Let, l.NextToken().Type)
+	require.Equal(t, "y", l.NextToken().Literal())
+}
+
+func TestAbandonedLexerDoesNotLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for range 100 {
+		l := lexer.New(strings.Repeat("x + ", 1000))
+		l.NextToken()
+	}
+
+	require.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func TestChannel(t *testing.T) {
+	source := lexer.Channel(lexer.Lex("1 + 2"))
+
+	require.Equal(t, token.Int, source.NextToken().Type)
+	require.Equal(t, token.Plus, source.NextToken().Type)
+	require.Equal(t, token.Int, source.NextToken().Type)
+	require.Equal(t, token.EOF, source.NextToken().Type)
+	require.Equal(t, token.EOF, source.NextToken().Type)
+}
+
+func TestDiagnostics(t *testing.T) {
+	input := "let a = 1 @ 2;\nlet b = a | \"x\\qy\" ? é;\n\"tail ${b} and"
+
+	l := lexer.New(input)
+
+	illegal := 0
+	for tk := range l.All() {
+		if tk.Type == token.Illegal {
+			illegal++
+		}
+	}
+
+	// every problem is reported and lexing carries on after each one
+	require.Equal(t, 5, illegal)
+	require.Equal(t, []string{
+		"unexpected character '@' at 1:11",
+		"unexpected character '|' at 2:11",
+		"invalid escape \\q at 2:15",
+		"unexpected character '?' at 2:20",
+		"unexpected character 'é' at 2:22",
+		"unterminated string at 3:10",
+	}, diagnostics(l))
+}
+
+func diagnostics(l *lexer.Lexer) []string {
+	out := []string{}
+	for _, d := range l.Diagnostics() {
+		out = append(out, d.String())
+	}
+	return out
+}
+
+func TestLexerPositions(t *testing.T) {
+	input := `let x = 5;
+  "a ${x}"
+`
+
+	wants := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.Let, 1, 1},
+		{token.Ident, 1, 5},
+		{token.Assign, 1, 7},
+		{token.Int, 1, 9},
+		{token.Semicolon, 1, 10},
+		{token.StringStart, 2, 4},
+		{token.Ident, 2, 8},
+		{token.StringEnd, 2, 10},
+		{token.EOF, 3, 1},
+	}
+
+	tks := lexer.Lex(input)
+
+	for _, want := range wants {
+		tk := <-tks
+		require.Equal(t, want.expectedType, tk.Type)
+		require.Equal(t, want.expectedLine, tk.Position().Line)
+		require.Equal(t, want.expectedColumn, tk.Position().Column)
+	}
+}
+
 func runLexerTest(t *testing.T, wants []want, tks chan token.Token) {
 	for _, want := range wants {
 		tk := <-tks
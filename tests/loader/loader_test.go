@@ -0,0 +1,188 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/loader"
+	"github.com/w-h-a/interpreter/internal/object"
+	errorobject "github.com/w-h-a/interpreter/internal/object/error"
+	"github.com/w-h-a/interpreter/internal/object/integer"
+	"github.com/w-h-a/interpreter/internal/parser"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name       string
+		files      map[string]string
+		searchPath []string
+		output     any
+	}{
+		{
+			name: "should import exported bindings",
+			files: map[string]string{
+				"main.mk":     `import "lib/math" as m; m.double(m.base);`,
+				"lib/math.mk": `export let base = 20; export let double = fn(x) { x * 2 + one }; let one = 1;`,
+			},
+			output: 41,
+		},
+		{
+			name: "should resolve relative to the importing file",
+			files: map[string]string{
+				"main.mk":  `import "lib/a" as a; a.value;`,
+				"lib/a.mk": `import "b" as b; export let value = b.value + 1;`,
+				"lib/b.mk": `export let value = 1;`,
+				"b.mk":     `export let value = 100;`,
+			},
+			output: 2,
+		},
+		{
+			name: "should resolve through the search path",
+			files: map[string]string{
+				"main.mk":           `import "strings" as s; s.size;`,
+				"vendor/strings.mk": `export let size = len("four");`,
+			},
+			searchPath: []string{"vendor"},
+			output:     4,
+		},
+		{
+			name: "should accept an explicit extension",
+			files: map[string]string{
+				"main.mk": `import "lib.mk" as l; l.x;`,
+				"lib.mk":  `export let x = 3;`,
+			},
+			output: 3,
+		},
+		{
+			name: "should evaluate each module once",
+			files: map[string]string{
+				"main.mk":  `import "lib/a" as a; import "lib/b" as b; a.shared == b.shared;`,
+				"lib/a.mk": `import "c" as c; export let shared = c;`,
+				"lib/b.mk": `import "c" as c; export let shared = c;`,
+				"lib/c.mk": `export let f = fn() { 1 };`,
+			},
+			output: true,
+		},
+		{
+			name: "should keep module environments apart",
+			files: map[string]string{
+				"main.mk": `let x = 1; import "lib" as l; l.get() + x;`,
+				"lib.mk":  `let x = 10; export let get = fn() { x };`,
+			},
+			output: 11,
+		},
+		{
+			name: "should report an import cycle with its path",
+			files: map[string]string{
+				"main.mk":  `import "lib/a" as a;`,
+				"lib/a.mk": `import "b" as b;`,
+				"lib/b.mk": `import "../main" as m;`,
+			},
+			output: "in module lib/a: in module b: import cycle: main.mk -> lib/a.mk -> lib/b.mk -> main.mk",
+		},
+		{
+			name: "should report a missing module",
+			files: map[string]string{
+				"main.mk": `import "nope" as n;`,
+			},
+			output: `cannot find module "nope" (looked in {dir}/nope.mk)`,
+		},
+		{
+			name: "should report a missing export",
+			files: map[string]string{
+				"main.mk": `import "lib" as l; l.hidden;`,
+				"lib.mk":  `let hidden = 1;`,
+			},
+			output: "module lib does not export hidden",
+		},
+		{
+			name: "should report runtime errors in a module",
+			files: map[string]string{
+				"main.mk": `import "lib" as l;`,
+				"lib.mk":  `export let x = y;`,
+			},
+			output: "in module lib: identifier not found: y",
+		},
+		{
+			name: "should report parse errors in a module",
+			files: map[string]string{
+				"main.mk": `import "lib" as l;`,
+				"lib.mk":  `let = 1;`,
+			},
+			output: "parse errors in lib.mk: expected next token to be IDENT, got =; no parse function for = found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			for name, src := range tc.files {
+				path := filepath.Join(dir, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, []byte(src), 0o644))
+			}
+
+			searchPath := []string{}
+			for _, search := range tc.searchPath {
+				searchPath = append(searchPath, filepath.Join(dir, search))
+			}
+
+			result, err := loader.New(searchPath...).Run(filepath.Join(dir, "main.mk"))
+			require.NoError(t, err)
+
+			testObject(t, dir, tc.output, result)
+		})
+	}
+}
+
+func TestRunParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.mk")
+	require.NoError(t, os.WriteFile(file, []byte(`import 5 as x;`), 0o644))
+
+	_, err := loader.New().Run(file)
+
+	parseErr, ok := err.(*loader.ParseError)
+	require.True(t, ok)
+	require.Equal(t, "main.mk", parseErr.File)
+	require.Equal(t, []string{"expected next token to be STRING, got INT", "no parse function for AS found"}, parseErr.Errors)
+}
+
+func TestEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.mk"), []byte(`export let x = 7;`), 0o644))
+
+	env := loader.New().Environment(dir)
+
+	for _, input := range []string{`import "lib" as l;`, `l.x`} {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		require.Equal(t, 0, len(p.Errors()))
+
+		result := evaluator.Eval(program, env)
+		if input == `l.x` {
+			testObject(t, dir, 7, result)
+		}
+	}
+}
+
+func testObject(t *testing.T, dir string, expected any, obj object.Object) {
+	switch want := expected.(type) {
+	case int:
+		result, ok := obj.(*integer.Integer)
+		require.True(t, ok, "got %v", obj)
+		require.Equal(t, int64(want), result.Value)
+	case bool:
+		require.Equal(t, evaluator.TRUE, obj)
+	case string:
+		result, ok := obj.(*errorobject.Error)
+		require.True(t, ok, "got %v", obj)
+		require.Equal(t, strings.ReplaceAll(want, "{dir}", dir), result.Message)
+	}
+}
@@ -0,0 +1,512 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/lsp"
+)
+
+const URI = "file:///main.mk"
+
+// client drives a server in the same process the way an editor would
+type client struct {
+	t           *testing.T
+	in          *io.PipeWriter
+	messages    chan lsp.Message
+	done        chan error
+	id          int
+	diagnostics map[string][]lsp.Diagnostic
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{
+		t:           t,
+		in:          clientOut,
+		messages:    make(chan lsp.Message, 100),
+		done:        make(chan error, 1),
+		diagnostics: map[string][]lsp.Diagnostic{},
+	}
+
+	go func() {
+		c.done <- lsp.Serve(serverIn, serverOut)
+		serverOut.Close()
+	}()
+
+	go func() {
+		defer close(c.messages)
+		reader := bufio.NewReader(clientIn)
+		for {
+			body, err := lsp.Read(reader)
+			if err != nil {
+				return
+			}
+			var msg lsp.Message
+			if json.Unmarshal(body, &msg) == nil {
+				c.messages <- msg
+			}
+		}
+	}()
+
+	t.Cleanup(func() { clientOut.Close() })
+
+	return c
+}
+
+func (c *client) send(msg any) {
+	body, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	require.NoError(c.t, lsp.Write(c.in, body))
+}
+
+func (c *client) notify(method string, params any) {
+	c.send(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// next is the next message from the server; diagnostics are kept as they
+// are published
+func (c *client) next() lsp.Message {
+	select {
+	case msg, ok := <-c.messages:
+		require.True(c.t, ok, "server closed its output")
+		if msg.Method == "textDocument/publishDiagnostics" {
+			var params lsp.PublishDiagnosticsParams
+			require.NoError(c.t, json.Unmarshal(msg.Params, &params))
+			c.diagnostics[params.URI] = params.Diagnostics
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+		return lsp.Message{}
+	}
+}
+
+// request sends a request and decodes its result into result
+func (c *client) request(method string, params, result any) *lsp.ResponseError {
+	c.id++
+	c.send(map[string]any{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params})
+
+	for {
+		msg := c.next()
+		if msg.ID == nil {
+			continue
+		}
+
+		var id int
+		require.NoError(c.t, json.Unmarshal(*msg.ID, &id))
+		require.Equal(c.t, c.id, id)
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			require.NoError(c.t, json.Unmarshal(msg.Result, result))
+		}
+		return nil
+	}
+}
+
+// published waits for the diagnostics of the next publish
+func (c *client) published() []lsp.Diagnostic {
+	for {
+		if msg := c.next(); msg.Method == "textDocument/publishDiagnostics" {
+			return c.diagnostics[URI]
+		}
+	}
+}
+
+func (c *client) initialize() {
+	var result lsp.InitializeResult
+	require.Nil(c.t, c.request("initialize", map[string]any{"capabilities": map[string]any{}}, &result))
+	c.notify("initialized", map[string]any{})
+}
+
+func (c *client) open(text string) []lsp.Diagnostic {
+	c.notify("textDocument/didOpen", lsp.DidOpenTextDocumentParams{TextDocument: lsp.TextDocumentItem{URI: URI, LanguageID: "monkey", Version: 1, Text: text}})
+	return c.published()
+}
+
+func (c *client) change(changes ...lsp.TextDocumentContentChangeEvent) []lsp.Diagnostic {
+	c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{TextDocument: lsp.TextDocumentIdentifier{URI: URI}, ContentChanges: changes})
+	return c.published()
+}
+
+func at(line, character int) lsp.TextDocumentPositionParams {
+	return lsp.TextDocumentPositionParams{TextDocument: lsp.TextDocumentIdentifier{URI: URI}, Position: lsp.Position{Line: line, Character: character}}
+}
+
+func span(line, start, end int) lsp.Range {
+	return lsp.Range{Start: lsp.Position{Line: line, Character: start}, End: lsp.Position{Line: line, Character: end}}
+}
+
+func TestLifecycle(t *testing.T) {
+	c := newClient(t)
+
+	require.Equal(t, lsp.SERVER_NOT_INITIALIZED, c.request("textDocument/hover", at(0, 0), nil).Code)
+
+	var result lsp.InitializeResult
+	require.Nil(t, c.request("initialize", map[string]any{"capabilities": map[string]any{}}, &result))
+	require.Equal(t, lsp.SYNC_INCREMENTAL, result.Capabilities.TextDocumentSync)
+	require.True(t, result.Capabilities.HoverProvider)
+	require.True(t, result.Capabilities.RenameProvider)
+
+	require.Equal(t, lsp.METHOD_NOT_FOUND, c.request("workspace/symbol", map[string]any{}, nil).Code)
+	require.Equal(t, lsp.INVALID_PARAMS, c.request("textDocument/hover", at(0, 0), nil).Code)
+
+	require.Nil(t, c.request("shutdown", nil, nil))
+	require.Equal(t, lsp.INVALID_REQUEST, c.request("textDocument/hover", at(0, 0), nil).Code)
+
+	c.notify("exit", nil)
+	require.NoError(t, <-c.done)
+}
+
+func TestExitBeforeShutdown(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+
+	c.notify("exit", nil)
+	require.ErrorIs(t, <-c.done, lsp.ErrNoShutdown)
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+
+	diagnostics := c.open("let x = 1;\nlet y = ;\nputs(x);\n")
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, "parse", diagnostics[0].Source)
+	require.Equal(t, lsp.SEVERITY_ERROR, diagnostics[0].Severity)
+	require.Equal(t, 1, diagnostics[0].Range.Start.Line)
+
+	// an incremental edit that fixes the error
+	diagnostics = c.change(lsp.TextDocumentContentChangeEvent{Range: &lsp.Range{Start: lsp.Position{Line: 1, Character: 8}, End: lsp.Position{Line: 1, Character: 8}}, Text: "x"})
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, "vet", diagnostics[0].Source)
+	require.Equal(t, lsp.SEVERITY_WARNING, diagnostics[0].Severity)
+	require.Equal(t, "let y is declared but never used (unused)", diagnostics[0].Message)
+	require.Equal(t, span(1, 4, 5), diagnostics[0].Range)
+
+	// a change without a range replaces the text
+	diagnostics = c.change(lsp.TextDocumentContentChangeEvent{Text: "let n: int = \"one\";\nputs(n);\n"})
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, "typecheck", diagnostics[0].Source)
+	require.Equal(t, "cannot use string as int in let n", diagnostics[0].Message)
+
+	diagnostics = c.change(lsp.TextDocumentContentChangeEvent{Text: "let n = 1;\nputs(n);\n"})
+	require.Empty(t, diagnostics)
+
+	c.notify("textDocument/didClose", lsp.DidCloseTextDocumentParams{TextDocument: lsp.TextDocumentIdentifier{URI: URI}})
+	require.Empty(t, c.published())
+}
+
+func TestHover(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		position lsp.TextDocumentPositionParams
+		expected string
+	}{
+		{
+			name:     "should show the inferred type of a top-level let",
+			text:     "let add = fn(a, b) { a + b };\nadd(1, 2);",
+			position: at(1, 1),
+			expected: "let add: (int, int) -> int",
+		},
+		{
+			name:     "should show an annotation",
+			text:     "let f = fn(x: int) { x };\nf(1);",
+			position: at(0, 21),
+			expected: "parameter x: int",
+		},
+		{
+			name:     "should show the kind of value when there is no type",
+			text:     "let xs = [1, \"a\"];\nlet f = fn(g) { g(xs) };\nf(len);",
+			position: at(1, 18),
+			expected: "let xs (ARRAY)",
+		},
+		{
+			name:     "should show an unannotated parameter",
+			text:     "let xs = [1, \"a\"];\nlet f = fn(g) { g(xs) };\nf(len);",
+			position: at(1, 16),
+			expected: "parameter g",
+		},
+		{
+			name:     "should show builtins",
+			text:     "len([1]);",
+			position: at(0, 2),
+			expected: "builtin len (BUILTIN)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newClient(t)
+			c.initialize()
+			c.open(tc.text)
+
+			var hover lsp.Hover
+			require.Nil(t, c.request("textDocument/hover", tc.position, &hover))
+			require.Equal(t, tc.expected, hover.Contents.Value)
+		})
+	}
+
+	c := newClient(t)
+	c.initialize()
+	c.open("let x = 1;\n\nx")
+
+	var hover *lsp.Hover
+	require.Nil(t, c.request("textDocument/hover", at(1, 0), &hover))
+	require.Nil(t, hover)
+}
+
+func TestDefinitionAndReferences(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open("let x = 1;\nlet f = fn(x) {\n  let g = fn() { x + y };\n  g() + x\n};\nlet y = x;\nf(y);")
+
+	testCases := []struct {
+		name       string
+		position   lsp.TextDocumentPositionParams
+		definition []lsp.Range
+		references []lsp.Range
+	}{
+		{
+			name:       "should follow a top-level let",
+			position:   at(5, 8),
+			definition: []lsp.Range{span(0, 4, 5)},
+			references: []lsp.Range{span(0, 4, 5), span(5, 8, 9)},
+		},
+		{
+			name:       "should follow a parameter into a nested function",
+			position:   at(2, 17),
+			definition: []lsp.Range{span(1, 11, 12)},
+			references: []lsp.Range{span(1, 11, 12), span(2, 17, 18), span(3, 8, 9)},
+		},
+		{
+			name:       "should follow a let bound after the function using it",
+			position:   at(2, 21),
+			definition: []lsp.Range{span(5, 4, 5)},
+			references: []lsp.Range{span(2, 21, 22), span(5, 4, 5), span(6, 2, 3)},
+		},
+		{
+			name:       "should start from a binding",
+			position:   at(2, 7),
+			definition: []lsp.Range{span(2, 6, 7)},
+			references: []lsp.Range{span(2, 6, 7), span(3, 2, 3)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var definition []lsp.Location
+			require.Nil(t, c.request("textDocument/definition", tc.position, &definition))
+
+			ranges := []lsp.Range{}
+			for _, location := range definition {
+				require.Equal(t, URI, location.URI)
+				ranges = append(ranges, location.Range)
+			}
+			require.Equal(t, tc.definition, ranges)
+
+			var references []lsp.Location
+			params := lsp.ReferenceParams{TextDocumentPositionParams: tc.position}
+			params.Context.IncludeDeclaration = true
+			require.Nil(t, c.request("textDocument/references", params, &references))
+
+			ranges = []lsp.Range{}
+			for _, location := range references {
+				ranges = append(ranges, location.Range)
+			}
+			require.Equal(t, tc.references, ranges)
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open("let top = 1;\nlet f = fn(param) {\n  let inner = param;\n  inner\n};\nlet g = fn() { top };\ng()")
+
+	labels := func(position lsp.TextDocumentPositionParams) map[string]int {
+		var items []lsp.CompletionItem
+		require.Nil(t, c.request("textDocument/completion", position, &items))
+
+		kinds := map[string]int{}
+		for _, item := range items {
+			kinds[item.Label] = item.Kind
+		}
+		return kinds
+	}
+
+	inside := labels(at(3, 2))
+	require.Equal(t, lsp.COMPLETION_VARIABLE, inside["inner"])
+	require.Equal(t, lsp.COMPLETION_VARIABLE, inside["param"])
+	require.Equal(t, lsp.COMPLETION_VARIABLE, inside["top"])
+	require.Equal(t, lsp.COMPLETION_FUNCTION, inside["f"])
+	require.Equal(t, lsp.COMPLETION_FUNCTION, inside["len"])
+	require.Equal(t, lsp.COMPLETION_KEYWORD, inside["let"])
+	require.Equal(t, lsp.COMPLETION_KEYWORD, inside["fn"])
+
+	outside := labels(at(6, 0))
+	require.Contains(t, outside, "top")
+	require.Contains(t, outside, "g")
+	require.NotContains(t, outside, "inner")
+	require.NotContains(t, outside, "param")
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open("let one = 1;\nexport let double = fn(x) {\n  x * 2\n};\ninfixl 6 <+> = fn(a, b) { a + b };\ndouble(one)\n")
+
+	var symbols []lsp.DocumentSymbol
+	require.Nil(t, c.request("textDocument/documentSymbol", lsp.DocumentParams{TextDocument: lsp.TextDocumentIdentifier{URI: URI}}, &symbols))
+
+	require.Equal(t, []lsp.DocumentSymbol{
+		{Name: "one", Detail: "let: int", Kind: lsp.SYMBOL_VARIABLE, Range: span(0, 0, 12), SelectionRange: span(0, 4, 7)},
+		{Name: "double", Detail: "export let: int -> int", Kind: lsp.SYMBOL_FUNCTION, Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 0}, End: lsp.Position{Line: 3, Character: 2}}, SelectionRange: span(1, 11, 17)},
+		{Name: "<+>", Detail: "infixl 6", Kind: lsp.SYMBOL_OPERATOR, Range: span(4, 0, 34), SelectionRange: span(4, 9, 12)},
+	}, symbols)
+}
+
+func TestFormatting(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+
+	params := lsp.DocumentParams{TextDocument: lsp.TextDocumentIdentifier{URI: URI}}
+
+	c.open("let add=fn(a,b){a+b};\nputs( add(1,2) )")
+
+	var edits []lsp.TextEdit
+	require.Nil(t, c.request("textDocument/formatting", params, &edits))
+	require.Equal(t, []lsp.TextEdit{{
+		Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 1, Character: 16}},
+		NewText: "let add = fn(a, b) { a + b };\nputs(add(1, 2));\n",
+	}}, edits)
+
+	c.change(lsp.TextDocumentContentChangeEvent{Text: edits[0].NewText})
+	require.Nil(t, c.request("textDocument/formatting", params, &edits))
+	require.Empty(t, edits)
+
+	c.change(lsp.TextDocumentContentChangeEvent{Text: "let x = ;"})
+	require.Nil(t, c.request("textDocument/formatting", params, &edits))
+	require.Empty(t, edits)
+}
+
+func TestRename(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	c.open("let count = 1;\nlet f = fn(count) { count + 1 };\nf(count) + count;\nlen([]);\ninfixl 6 <+> = fn(a, b) { a };\n1 <+> 2")
+
+	rename := func(position lsp.TextDocumentPositionParams, name string) (lsp.WorkspaceEdit, *lsp.ResponseError) {
+		var edit lsp.WorkspaceEdit
+		err := c.request("textDocument/rename", lsp.RenameParams{TextDocumentPositionParams: position, NewName: name}, &edit)
+		return edit, err
+	}
+
+	edit, err := rename(at(2, 12), "total")
+	require.Nil(t, err)
+	require.Equal(t, map[string][]lsp.TextEdit{URI: {
+		{Range: span(0, 4, 9), NewText: "total"},
+		{Range: span(2, 2, 7), NewText: "total"},
+		{Range: span(2, 11, 16), NewText: "total"},
+	}}, edit.Changes)
+
+	edit, err = rename(at(1, 22), "n")
+	require.Nil(t, err)
+	require.Equal(t, map[string][]lsp.TextEdit{URI: {
+		{Range: span(1, 11, 16), NewText: "n"},
+		{Range: span(1, 20, 25), NewText: "n"},
+	}}, edit.Changes)
+
+	_, err = rename(at(2, 12), "let")
+	require.Equal(t, lsp.INVALID_PARAMS, err.Code)
+
+	_, err = rename(at(2, 12), "two words")
+	require.Equal(t, lsp.INVALID_PARAMS, err.Code)
+
+	_, err = rename(at(3, 1), "size")
+	require.Equal(t, "cannot rename the builtin len", err.Message)
+
+	_, err = rename(at(4, 10), "plus")
+	require.Equal(t, "cannot rename the operator <+>", err.Message)
+
+	conflicts := []struct {
+		name     string
+		text     string
+		position lsp.TextDocumentPositionParams
+		newName  string
+		expected string
+	}{
+		{
+			name:     "should reject a name already bound in the same scope",
+			text:     "let a = 1; let b = 2; let f = fn(x) { a + x }; b + f(1) + a",
+			position: at(0, 4),
+			newName:  "b",
+			expected: "cannot rename a to b: b is already bound in the scope of a",
+		},
+		{
+			name:     "should reject a parameter name that would capture an outer binding",
+			text:     "let a = 1; let b = 2; let f = fn(x) { a + x }; b + f(1) + a",
+			position: at(0, 33),
+			newName:  "a",
+			expected: "cannot rename x to a: the use of a at 1:39 would refer to x instead",
+		},
+		{
+			name:     "should reject a name an inner scope binds where the variable is used",
+			text:     "let a = 1; let f = fn(y) { a + y }; f(a)",
+			position: at(0, 4),
+			newName:  "y",
+			expected: "cannot rename a to y: the use of a at 1:28 would refer to the y bound in an inner scope",
+		},
+		{
+			name:     "should reject a name that would shadow a builtin in use",
+			text:     "let f = fn(xs) { len(xs) }; f([])",
+			position: at(0, 11),
+			newName:  "len",
+			expected: "cannot rename xs to len: the use of len at 1:18 would refer to xs instead",
+		},
+	}
+
+	for _, tc := range conflicts {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newClient(t)
+			c.initialize()
+			c.open(tc.text)
+
+			err := c.request("textDocument/rename", lsp.RenameParams{TextDocumentPositionParams: tc.position, NewName: tc.newName}, nil)
+			require.NotNil(t, err)
+			require.Equal(t, lsp.REQUEST_FAILED, err.Code)
+			require.Equal(t, tc.expected, err.Message)
+		})
+	}
+
+	c.change(lsp.TextDocumentContentChangeEvent{Text: "let a = 1; let f = fn(y) { a + y }; f(a)"})
+
+	// a name nothing sees is fine, even one used elsewhere in the program
+	edit, err = rename(at(0, 22), "f")
+	require.Nil(t, err)
+	require.Len(t, edit.Changes[URI], 2)
+}
+
+func TestUTF16Positions(t *testing.T) {
+	c := newClient(t)
+	c.initialize()
+	// é is one UTF-16 unit in two bytes, 😀 two units in four
+	c.open("let s = \"é😀\"; let n = 1;\nn")
+
+	var definition []lsp.Location
+	require.Nil(t, c.request("textDocument/definition", at(1, 0), &definition))
+	require.Equal(t, []lsp.Location{{URI: URI, Range: span(0, 19, 20)}}, definition)
+
+	var hover lsp.Hover
+	require.Nil(t, c.request("textDocument/hover", at(0, 19), &hover))
+	require.Equal(t, "let n: int", hover.Contents.Value)
+}
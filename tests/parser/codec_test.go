@@ -0,0 +1,350 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/ast/codec"
+	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
+	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/token"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	inputs := []string{
+		`let x = 5 * (2 + -3); x`,
+		`let add = fn(a, b = 2, ..rest) { return a + b + len(rest); }; add(1, 2, 3)`,
+		`if (1 < 2) { "yes" } else { "no" }`,
+		`if (true) { 1 }`,
+		`let h = {"a": [1, 2], true: null}; h["a"][1]`,
+		`let h = {"inner": {"x": 1}}; h.inner.x + (h?.missing?.x ?? 10)`,
+		`let xs = [1, 2, 3]; xs?.[0]`,
+		`let name = "monkey"; "hi ${name}, ${1 + 1} bananas"`,
+		`[1, 2] |> len`,
+		`!true == false`,
+		`infixl 6 <+> = fn(a, b) { a * 10 + b }; 1 <+> 2 <+> 3`,
+		`let f: fn(int, [string]) -> {string: bool} = fn(a: int, b = ["x"]) -> any { {"a": true} }; f(1)`,
+		`let sum = fn(a: int, ..xs: [int]) -> int { a + len(xs) }; sum(1, 2, 3)`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			p := parser.New(lexer.New(input))
+
+			program := p.ParseProgram()
+			require.Empty(t, p.Errors())
+
+			encoded, err := codec.Encode(program)
+			require.NoError(t, err)
+
+			decoded, err := codec.Decode(encoded)
+			require.NoError(t, err)
+
+			require.Equal(t, program.String(), decoded.String())
+
+			reencoded, err := codec.Encode(decoded)
+			require.NoError(t, err)
+			require.JSONEq(t, string(encoded), string(reencoded))
+
+			expected := evaluator.Eval(program, environment.New())
+			actual := evaluator.Eval(decoded, environment.New())
+			require.Equal(t, expected.Inspect(), actual.Inspect())
+		})
+	}
+}
+
+func TestCodecVersion1(t *testing.T) {
+	// a version 1 document, as written before operator declarations
+	input := `{"version": 1, "program": {"type": "Program", "statements": [{"type": "ExpressionStatement", "token": {"type": "INT", "literal": "1", "position": {"offset": 0, "line": 1, "column": 1}}, "expression": {"type": "Integer", "value": 1, "token": {"type": "INT", "literal": "1", "position": {"offset": 0, "line": 1, "column": 1}}}}]}}`
+
+	decoded, err := codec.Decode([]byte(input))
+	require.NoError(t, err)
+	require.Equal(t, "1", decoded.String())
+
+	encoded, err := codec.Encode(decoded)
+	require.NoError(t, err)
+
+	var doc codec.Document
+	require.NoError(t, json.Unmarshal(encoded, &doc))
+	require.Equal(t, codec.VERSION, doc.Version)
+}
+
+func TestCodecModules(t *testing.T) {
+	input := `import "lib/math" as m; export let y = m.x;`
+
+	p := parser.New(lexer.New(input))
+
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	encoded, err := codec.Encode(program)
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode(encoded)
+	require.NoError(t, err)
+	require.Equal(t, program.String(), decoded.String())
+}
+
+func TestCodecPositions(t *testing.T) {
+	p := parser.New(lexer.New("1 +\n  2"))
+
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	encoded, err := codec.Encode(program)
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode(encoded)
+	require.NoError(t, err)
+
+	infix := decoded.Statements[0].(*expressionstatement.Expression).Expression.(*infixoperator.InfixOperator)
+	require.Equal(t, token.Position{Offset: 2, Line: 1, Column: 3}, infix.Token.Position())
+	require.Equal(t, token.Position{Offset: 6, Line: 2, Column: 3}, infix.Right.(*integer.Integer).Token.Position())
+}
+
+func TestCodecSchema(t *testing.T) {
+	var schema struct {
+		ID         string                     `json:"$id"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]json.RawMessage `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(codec.Schema), &schema))
+	require.Contains(t, schema.ID, fmt.Sprintf("v%d", codec.VERSION))
+	require.JSONEq(t, fmt.Sprintf(`{"type": "integer", "minimum": %d, "maximum": %d}`, codec.MIN_VERSION, codec.VERSION), string(schema.Properties["version"]))
+
+	p := parser.New(lexer.New(`import "m" as m; export let f = fn(a, b = 1, ..c) { if (a) { return [a, {b: c}][0]?.x } }; "${f(1)?.[0]}" |> m.g; !null ?? -1; let t: fn([int], {string: null}) -> bool = fn(x: int) -> any { x }`))
+
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	encoded, err := codec.Encode(program)
+	require.NoError(t, err)
+
+	var document any
+	require.NoError(t, json.Unmarshal(encoded, &document))
+
+	// every node the encoder emits must be described by the schema
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			kind, isNode := v["type"].(string)
+			if _, isToken := v["literal"]; isNode && !isToken {
+				require.Contains(t, schema.Defs, kind)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(document)
+
+	var root any
+	require.NoError(t, json.Unmarshal([]byte(codec.Schema), &root))
+	require.NoError(t, validate(root.(map[string]any), root.(map[string]any), document, "$"))
+
+	// fields added in version 2 are left out when a program does not use them
+	p = parser.New(lexer.New(`let f = fn(a, b = 1, ..c) { a + b }; f(2)`))
+
+	program = p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	encoded, err = codec.Encode(program)
+	require.NoError(t, err)
+
+	require.NotContains(t, string(encoded), `"annotation"`)
+	require.NotContains(t, string(encoded), `"annotations"`)
+	require.NotContains(t, string(encoded), `"restAnnotation"`)
+	require.NotContains(t, string(encoded), `"result"`)
+}
+
+// a version 1 document as the version 1 encoder wrote it
+func TestCodecSchemaVersion1(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "v1.json"))
+	require.NoError(t, err)
+
+	program, err := codec.Decode(fixture)
+	require.NoError(t, err)
+	require.Len(t, program.Statements, 6)
+
+	var schema, document any
+	require.NoError(t, json.Unmarshal([]byte(codec.Schema), &schema))
+	require.NoError(t, json.Unmarshal(fixture, &document))
+
+	require.NoError(t, validate(schema.(map[string]any), schema.(map[string]any), document, "$"))
+
+	// and the schema still rejects versions Decode does not read
+	document.(map[string]any)["version"] = float64(codec.VERSION + 1)
+	require.EqualError(t, validate(schema.(map[string]any), schema.(map[string]any), document, "$"), "$.version: 3 is above the maximum 2")
+}
+
+// validate checks value against the part of JSON Schema the codec's schema
+// uses
+func validate(root, schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := root["$defs"].(map[string]any)[strings.TrimPrefix(ref, "#/$defs/")]
+		return validate(root, def.(map[string]any), value, path)
+	}
+
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		return fmt.Errorf("%s: %v is not %v", path, value, c)
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return reflect.DeepEqual(e, value) }) {
+		return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+	}
+
+	if kind, ok := schema["type"]; ok {
+		kinds, ok := kind.([]any)
+		if !ok {
+			kinds = []any{kind}
+		}
+		if !slices.ContainsFunc(kinds, func(k any) bool { return isKind(k.(string), value) }) {
+			return fmt.Errorf("%s: %v is not of type %v", path, value, kind)
+		}
+	}
+
+	if n, ok := value.(float64); ok {
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			return fmt.Errorf("%s: %v is below the minimum %v", path, n, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			return fmt.Errorf("%s: %v is above the maximum %v", path, n, max)
+		}
+	}
+
+	if object, ok := value.(map[string]any); ok {
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing %s", path, name)
+			}
+		}
+
+		for name, field := range object {
+			property, ok := properties[name]
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s: unexpected %s", path, name)
+				}
+				continue
+			}
+			if err := validate(root, property.(map[string]any), field, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if items, ok := schema["items"].(map[string]any); ok {
+		if array, ok := value.([]any); ok {
+			for i, item := range array {
+				if err := validate(root, items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matched := 0
+		for _, option := range oneOf {
+			if validate(root, option.(map[string]any), value, path) == nil {
+				matched += 1
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d of the oneOf schemas, want 1", path, matched)
+		}
+	}
+
+	return nil
+}
+
+func isKind(kind string, value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return kind == "null"
+	case bool:
+		return kind == "boolean"
+	case string:
+		return kind == "string"
+	case float64:
+		return kind == "number" || kind == "integer" && value == math.Trunc(value)
+	case []any:
+		return kind == "array"
+	case map[string]any:
+		return kind == "object"
+	default:
+		return false
+	}
+}
+
+func TestCodecErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "unsupported version",
+			input: `{"version": 3, "program": {"type": "Program", "statements": []}}`,
+			err:   "unsupported AST schema version 3, want 1 to 2",
+		},
+		{
+			name:  "node newer than the version",
+			input: `{"version": 1, "program": {"type": "Program", "statements": [{"type": "Operator", "token": {"type": "INFIXL", "literal": "infixl", "position": {"offset": 0, "line": 1, "column": 1}}, "precedence": {"type": "Integer", "value": 6, "token": {"type": "INT", "literal": "6", "position": {"offset": 7, "line": 1, "column": 8}}}, "name": {"type": "Identifier", "value": "<+>", "token": {"type": "OPERATOR", "literal": "<+>", "position": {"offset": 9, "line": 1, "column": 10}}}, "value": null}]}}`,
+			err:   "Operator needs AST schema version 2, document is version 1",
+		},
+		{
+			name:  "annotation newer than the version",
+			input: `{"version": 1, "program": {"type": "Program", "statements": [{"type": "Let", "token": {"type": "LET", "literal": "let", "position": {"offset": 0, "line": 1, "column": 1}}, "name": {"type": "Identifier", "value": "x", "token": {"type": "IDENT", "literal": "x", "position": {"offset": 4, "line": 1, "column": 5}}}, "annotation": {"type": "NamedType", "name": "int", "token": {"type": "IDENT", "literal": "int", "position": {"offset": 7, "line": 1, "column": 8}}}, "value": null}]}}`,
+			err:   "NamedType needs AST schema version 2, document is version 1",
+		},
+		{
+			name:  "unknown node type",
+			input: `{"version": 1, "program": {"type": "Program", "statements": [{"type": "Loop", "token": {"type": "", "literal": "", "position": {"offset": 0, "line": 1, "column": 1}}}]}}`,
+			err:   `in Program: in Loop: unknown node type "Loop"`,
+		},
+		{
+			name:  "wrong node kind",
+			input: `{"version": 1, "program": {"type": "Program", "statements": [{"type": "Integer", "value": 1, "token": {"type": "INT", "literal": "1", "position": {"offset": 0, "line": 1, "column": 1}}}]}}`,
+			err:   "in Program: unexpected *integer.Integer",
+		},
+		{
+			name:  "missing token",
+			input: `{"version": 1, "program": {"type": "Program", "statements": [{"type": "Return", "value": null}]}}`,
+			err:   `in Program: in Return: missing field "token"`,
+		},
+		{
+			name:  "root is not a program",
+			input: `{"version": 1, "program": {"type": "Null", "token": {"type": "NULL", "literal": "null", "position": {"offset": 0, "line": 1, "column": 1}}}}`,
+			err:   "expected Program at the root, got *null.Null",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := codec.Decode([]byte(tc.input))
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/parser"
+)
+
+func TestDot(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:  "precedence is visible in the edges",
+			input: "(a + b) * c",
+			expected: []string{
+				`n2 [label="InfixOperator\n*"];`,
+				`n3 [label="InfixOperator\n+"];`,
+				`n2 -> n3 [label="Left"];`,
+				`n2 -> n6 [label="Right"];`,
+				`n6 [label="Identifier\nc"];`,
+			},
+		},
+		{
+			name:  "if branches are labelled by field",
+			input: "if (x) { 1 } else { 2 }",
+			expected: []string{
+				`[label="Condition"];`,
+				`[label="Consequence"];`,
+				`[label="Alternative"];`,
+				`[label="Integer\n2"];`,
+			},
+		},
+		{
+			name:  "labels are escaped",
+			input: `"say \"hi\""`,
+			expected: []string{
+				`n2 [label="String\n\"say \\\"hi\\\"\""];`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.New(lexer.New(tc.input))
+
+			program := p.ParseProgram()
+			require.Empty(t, p.Errors())
+
+			var out strings.Builder
+			require.NoError(t, tree.Dot(&out, program))
+
+			graph := out.String()
+			require.True(t, strings.HasPrefix(graph, "digraph AST {\n"))
+			require.True(t, strings.HasSuffix(graph, "}\n"))
+
+			for _, line := range tc.expected {
+				require.Contains(t, graph, line)
+			}
+		})
+	}
+}
@@ -6,16 +6,25 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/w-h-a/interpreter/internal/ast/expression"
+	"github.com/w-h-a/interpreter/internal/ast/expression/array"
 	"github.com/w-h-a/interpreter/internal/ast/expression/boolean"
 	"github.com/w-h-a/interpreter/internal/ast/expression/call"
+	"github.com/w-h-a/interpreter/internal/ast/expression/field"
 	"github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/hash"
 	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
 	ifexpression "github.com/w-h-a/interpreter/internal/ast/expression/if"
+	"github.com/w-h-a/interpreter/internal/ast/expression/index"
 	infixoperator "github.com/w-h-a/interpreter/internal/ast/expression/infix_operator"
 	"github.com/w-h-a/interpreter/internal/ast/expression/integer"
+	"github.com/w-h-a/interpreter/internal/ast/expression/interpolation"
+	"github.com/w-h-a/interpreter/internal/ast/expression/null"
 	prefixoperator "github.com/w-h-a/interpreter/internal/ast/expression/prefix_operator"
+	stringexpression "github.com/w-h-a/interpreter/internal/ast/expression/string"
 	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/statement/export"
 	expressionstatement "github.com/w-h-a/interpreter/internal/ast/statement/expression"
+	importstatement "github.com/w-h-a/interpreter/internal/ast/statement/import"
 	"github.com/w-h-a/interpreter/internal/ast/statement/let"
 	returnstatement "github.com/w-h-a/interpreter/internal/ast/statement/return"
 	"github.com/w-h-a/interpreter/internal/lexer"
@@ -255,11 +264,56 @@ fn(5) {};
 `,
 			expectErr: true,
 			testErrsFn: func(t *testing.T, errors []string) {
-				require.Equal(t, 4, len(errors))
+				require.Equal(t, 2, len(errors))
 				testParseErrors(t, `failed to parse expression literal "5": expected identifier as function parameter, got INT`, errors[0])
 				testParseErrors(t, "no parse function for ) found", errors[1])
-				testParseErrors(t, "no parse function for { found", errors[2])
-				testParseErrors(t, "no parse function for } found", errors[3])
+			},
+		},
+		{
+			name: "function expressions with defaults and rest",
+			input: `
+fn(a, b = 10, ..rest) { a };
+fn(..rest) {};
+let add = fn(x, y = x * 2) { x + y };
+`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				require.Equal(t, 3, len(program.Statements))
+				testExpressionStatement(t, program.Statements[0], expectedFunctionExpression{
+					params:  []string{"a", "b"},
+					bodyLen: 1,
+				})
+				testExpressionStatement(t, program.Statements[1], expectedFunctionExpression{
+					params:  []string{},
+					bodyLen: 0,
+				})
+
+				fn := program.Statements[0].(*expressionstatement.Expression).Expression.(*function.Function)
+				require.Nil(t, fn.Defaults[0])
+				testExpression(t, fn.Defaults[1], 10)
+				testExpression(t, fn.Rest, "rest")
+
+				letStmt := program.Statements[2].(*let.Let)
+				add := letStmt.Value.(*function.Function)
+				require.Equal(t, "add", add.Name)
+				testExpression(t, add.Defaults[1], expectedInfixOperatorExpression{operator: "*", left: "x", right: 2})
+
+				require.Equal(t, "fn(a, b = 10, ..rest)afn(..rest)let add = fn(x, y = (x * 2))(x + y);", program.String())
+			},
+			expectErr: false,
+		},
+		{
+			name: "malformed function parameters",
+			input: `
+fn(..rest, a) {};
+fn(a = 1, b) {};
+fn(..5) {};
+`,
+			expectErr: true,
+			testErrsFn: func(t *testing.T, errors []string) {
+				require.Equal(t, 6, len(errors))
+				testParseErrors(t, `failed to parse expression literal "a": rest parameter rest must be the last function parameter`, errors[0])
+				testParseErrors(t, `failed to parse expression literal "b": parameter b without default follows parameter with default`, errors[2])
+				testParseErrors(t, `failed to parse expression literal "..": expected identifier as rest parameter, got INT`, errors[4])
 			},
 		},
 		{
@@ -460,11 +514,215 @@ fn(5) {};
 			},
 			expectErr: false,
 		},
+		{
+			name:  "pipeline string 1",
+			input: `x |> f(a) |> g`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				got := program.String()
+				require.Equal(t, "((x |> f(a)) |> g)", got)
+			},
+			expectErr: false,
+		},
+		{
+			name:  "pipeline string 2",
+			input: `a + b * c |> f == d`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				got := program.String()
+				require.Equal(t, "((a + (b * c)) |> (f == d))", got)
+			},
+			expectErr: false,
+		},
+		{
+			name: "literal expressions",
+			input: `
+"hello world";
+null;
+[1, 2 * 2];
+{"one": 1, true: 2, 3: x};
+{};
+`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				require.Equal(t, 5, len(program.Statements))
+
+				str := program.Statements[0].(*expressionstatement.Expression).Expression.(*stringexpression.String)
+				require.Equal(t, "hello world", str.Value)
+
+				_, ok := program.Statements[1].(*expressionstatement.Expression).Expression.(*null.Null)
+				require.True(t, ok)
+
+				arr := program.Statements[2].(*expressionstatement.Expression).Expression.(*array.Array)
+				require.Equal(t, 2, len(arr.Elements))
+				testExpression(t, arr.Elements[0], 1)
+				testExpression(t, arr.Elements[1], expectedInfixOperatorExpression{operator: "*", left: 2, right: 2})
+
+				h := program.Statements[3].(*expressionstatement.Expression).Expression.(*hash.Hash)
+				require.Equal(t, 3, len(h.Pairs))
+				testExpression(t, h.Pairs[1].Key, true)
+				testExpression(t, h.Pairs[2].Value, "x")
+
+				empty := program.Statements[4].(*expressionstatement.Expression).Expression.(*hash.Hash)
+				require.Equal(t, 0, len(empty.Pairs))
+			},
+			expectErr: false,
+		},
+		{
+			name: "malformed hash literal",
+			input: `
+{"a" 1};
+`,
+			expectErr: true,
+			testErrsFn: func(t *testing.T, errors []string) {
+				testParseErrors(t, `failed to parse expression literal "a": expected next token to be :, got INT`, errors[0])
+			},
+		},
+		{
+			name:  "index string 1",
+			input: `a * [1, 2, 3][b * c] * d`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				got := program.String()
+				require.Equal(t, "((a * ([1, 2, 3][(b * c)])) * d)", got)
+			},
+			expectErr: false,
+		},
+		{
+			name:  "index string 2",
+			input: `add(a * b[2], b[1], 2 * [1, 2][1])`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				got := program.String()
+				require.Equal(t, "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))", got)
+			},
+			expectErr: false,
+		},
+		{
+			name:  "optional chain string",
+			input: `config?.server.ports?.[0] ?? 80 + 1`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				got := program.String()
+				require.Equal(t, "((((config?.server).ports)?.[0]) ?? (80 + 1))", got)
+
+				exp := program.Statements[0].(*expressionstatement.Expression).Expression.(*infixoperator.InfixOperator)
+				idx := exp.Left.(*index.Index)
+				require.True(t, idx.Optional)
+				ports := idx.Left.(*field.Field)
+				require.False(t, ports.Optional)
+				require.Equal(t, "ports", ports.Field.Value)
+				server := ports.Left.(*field.Field)
+				require.True(t, server.Optional)
+			},
+			expectErr: false,
+		},
+		{
+			name:  "coalesce string",
+			input: `a ?? b == c |> f`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				got := program.String()
+				require.Equal(t, "((a ?? (b == c)) |> f)", got)
+			},
+			expectErr: false,
+		},
+		{
+			name: "malformed optional chain",
+			input: `
+a?.(1);
+`,
+			expectErr: true,
+			testErrsFn: func(t *testing.T, errors []string) {
+				testParseErrors(t, `failed to parse infix expression literal "?.": expected next token to be [ or IDENT, got (`, errors[0])
+			},
+		},
+		{
+			name:  "interpolation expression",
+			input: `"Hello ${user.name}, you have ${len(items) + 1} items\n"`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				require.Equal(t, 1, len(program.Statements))
+
+				exp := program.Statements[0].(*expressionstatement.Expression).Expression.(*interpolation.Interpolation)
+				require.Equal(t, 5, len(exp.Parts))
+				require.Equal(t, "Hello ", exp.Parts[0].(*stringexpression.String).Value)
+				require.Equal(t, "(user.name)", exp.Parts[1].(*interpolation.Embedded).Expression.String())
+				require.Equal(t, ", you have ", exp.Parts[2].(*stringexpression.String).Value)
+				testExpression(t, exp.Parts[3].(*interpolation.Embedded).Expression, expectedInfixOperatorExpression{
+					operator: "+",
+					left:     expectedCallExpression{function: "len", args: []any{"items"}},
+					right:    1,
+				})
+				require.Equal(t, " items\n", exp.Parts[4].(*stringexpression.String).Value)
+
+				require.Equal(t, `"Hello ${(user.name)}, you have ${(len(items) + 1)} items\n"`, program.String())
+			},
+			expectErr: false,
+		},
+		{
+			name: "malformed interpolation expression",
+			input: `
+"a ${}";
+"b ${x y}";
+"\q";
+`,
+			expectErr: true,
+			testErrsFn: func(t *testing.T, errors []string) {
+				testParseErrors(t, "no parse function for STRING_END found", errors[0])
+				testParseErrors(t, `failed to parse expression literal "": in interpolation at 2:7: no parse function for STRING_END found`, errors[1])
+				testParseErrors(t, `failed to parse expression literal "x": in interpolation at 3:6: expected next token to be }, got IDENT`, errors[2])
+				testParseErrors(t, "invalid escape \\q at 4:2", errors[len(errors)-1])
+			},
+		},
+		{
+			name: "import and export statements",
+			input: `
+import "lib/strings" as s;
+export let x = s.upper("a");
+`,
+			testFn: func(t *testing.T, program *statement.Program) {
+				require.Equal(t, 2, len(program.Statements))
+
+				imp, ok := program.Statements[0].(*importstatement.Import)
+				require.True(t, ok)
+				require.Equal(t, "lib/strings", imp.Path.Value)
+				testExpression(t, imp.Alias, "s")
+
+				exp, ok := program.Statements[1].(*export.Export)
+				require.True(t, ok)
+				require.Equal(t, "x", exp.Let.Name.Value)
+				_, ok = exp.Let.Value.(*call.Call)
+				require.True(t, ok)
+
+				require.Equal(t, `import "lib/strings" as s;export let x = (s.upper)("a");`, program.String())
+			},
+			expectErr: false,
+		},
+		{
+			name: "malformed import and export statements",
+			input: `
+import lib as l;
+import "lib" l;
+import "lib" as 5;
+export x;
+`,
+			expectErr: true,
+			testErrsFn: func(t *testing.T, errors []string) {
+				testParseErrors(t, "expected next token to be STRING, got IDENT", errors[0])
+				testParseErrors(t, "expected next token to be AS, got IDENT", errors[2])
+				testParseErrors(t, "expected next token to be IDENT, got INT", errors[3])
+				testParseErrors(t, "expected next token to be LET, got IDENT", errors[4])
+			},
+		},
+		{
+			name: "unclosed blocks",
+			input: `
+fn(x) { x
+`,
+			expectErr: true,
+			testErrsFn: func(t *testing.T, errors []string) {
+				require.Equal(t, 1, len(errors))
+				testParseErrors(t, `failed to parse expression literal "": expected next token to be }, got EOF`, errors[0])
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			tks := lexer.Lex(tc.input)
+			tks := lexer.New(tc.input)
 			p := parser.New(tks)
 
 			program := p.ParseProgram()
@@ -550,6 +808,7 @@ func testExpression(t *testing.T, e expression.Expression, expected any) {
 		callExpression, ok := e.(*call.Call)
 		require.True(t, ok)
 		testExpression(t, callExpression.Function, v.function)
+		require.Equal(t, len(v.args), len(callExpression.Arguments))
 		for i, arg := range v.args {
 			testExpression(t, callExpression.Arguments[i], arg)
 		}
@@ -569,3 +828,217 @@ func testExpression(t *testing.T, e expression.Expression, expected any) {
 func testParseErrors(t *testing.T, want string, got string) {
 	require.Equal(t, want, got)
 }
+
+func TestTokenSources(t *testing.T) {
+	input := "let x = 1 + 2 * 3;"
+
+	fromLexer := parser.New(lexer.New(input))
+	fromChannel := parser.New(lexer.Channel(lexer.Lex(input)))
+
+	expected := fromLexer.ParseProgram()
+	actual := fromChannel.ParseProgram()
+
+	require.Empty(t, fromLexer.Errors())
+	require.Empty(t, fromChannel.Errors())
+	require.Equal(t, expected.String(), actual.String())
+}
+
+func TestLexerDiagnostics(t *testing.T) {
+	input := `let x = 1;
+let y = x @ 2;
+let s = "a\q";
+"open`
+
+	p := parser.New(lexer.New(input))
+	p.ParseProgram()
+
+	require.Equal(t, []string{
+		"unexpected character '@' at 2:11",
+		"invalid escape \\q at 3:11",
+		"unterminated string at 4:1",
+	}, p.Errors())
+
+	// an invalid escape is reported once, by the lexer, and stands for the
+	// character after the slash
+	p = parser.New(lexer.New(`"a\qb"`))
+	program := p.ParseProgram()
+
+	require.Equal(t, []string{"invalid escape \\q at 1:3"}, p.Errors())
+	require.Equal(t, "aqb", program.Statements[0].(*expressionstatement.Expression).Expression.(*stringexpression.String).Value)
+
+	// a channel cannot explain its ILLEGAL tokens
+	p = parser.New(lexer.Channel(lexer.Lex("x @ 2")))
+	p.ParseProgram()
+
+	require.Equal(t, []string{"no parse function for ILLEGAL found"}, p.Errors())
+}
+
+func TestOperatorDeclarations(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		errors   []string
+	}{
+		{
+			name:     "should parse a declaration",
+			input:    "infixl 6 <+> = fn(a, b) { a };",
+			expected: "infixl 6 <+> = fn(a, b)a;",
+		},
+		{
+			name:     "should keep reading result types after declaring ->",
+			input:    "infixr 5 -> = f; let g: fn(int) -> int = fn(x: int) -> int { x -> x }",
+			expected: "infixr 5 -> = f;let g: fn(int) -> int = fn(x: int) -> int (x -> x);",
+		},
+		{
+			name:     "should group left-associative operators to the left",
+			input:    "infixl 5 <+> = f; a <+> b <+> c + d",
+			expected: "infixl 5 <+> = f;(((a <+> b) <+> c) + d)",
+		},
+		{
+			name:     "should group right-associative operators to the right",
+			input:    "infixr 5 <:> = f; a <:> b <:> c",
+			expected: "infixr 5 <:> = f;(a <:> (b <:> c))",
+		},
+		{
+			name:     "should bind tighter at a higher precedence",
+			input:    "infixr 1 $ = f; infixl 6 <*> = g; a $ b + c <*> d",
+			expected: "infixr 1 $ = f;infixl 6 <*> = g;(a $ (b + (c <*> d)))",
+		},
+		{
+			name:     "should read the longest declared operator",
+			input:    "infixl 5 <+ = f; infixl 5 <+> = g; a <+> b <+ c <+-d",
+			expected: "infixl 5 <+ = f;infixl 5 <+> = g;(((a <+> b) <+ c) <+ (-d))",
+		},
+		{
+			name:   "should leave undeclared symbols to the built-in operators",
+			input:  "a <+> b",
+			errors: []string{"no parse function for + found", `failed to parse infix expression literal "+": no parse function for + found`, "no parse function for > found"},
+		},
+		{
+			name:   "should reject chained non-associative operators",
+			input:  "infix 4 <=> = f; a <=> b <=> c",
+			errors: []string{`failed to parse infix expression literal "b": operator <=> is non-associative, use parentheses`, "no parse function for OPERATOR found"},
+		},
+		{
+			name:     "should accept parenthesized non-associative operators",
+			input:    "infix 4 <=> = f; (a <=> b) <=> c",
+			expected: "infix 4 <=> = f;((a <=> b) <=> c)",
+		},
+		{
+			name:   "should reject mixed associativity at one precedence",
+			input:  "infixl 5 <+ = f; infixr 5 +> = g; a <+ b +> c",
+			errors: []string{`failed to parse infix expression literal "b": cannot mix <+ and +> at precedence 5 without parentheses`, "no parse function for OPERATOR found"},
+		},
+		{
+			name:  "should reject a built-in operator in the right operand of a right-associative one",
+			input: "infixr 5 <+> = f; 1 <+> 2 - 3",
+			errors: []string{
+				`failed to parse infix expression literal "2": cannot mix <+> and - at precedence 5 without parentheses`,
+				`failed to parse infix expression literal "2": failed to parse infix expression literal "2": cannot mix <+> and - at precedence 5 without parentheses`,
+			},
+		},
+		{
+			name:   "should reject a right-associative operator after a built-in one",
+			input:  "infixr 5 <+> = f; 10 - 2 <+> 3",
+			errors: []string{`failed to parse infix expression literal "2": cannot mix - and <+> at precedence 5 without parentheses`, "no parse function for OPERATOR found"},
+		},
+		{
+			name:   "should reject a built-in operator after a non-associative one",
+			input:  "infix 4 <=> = f; 1 <=> 2 > 3",
+			errors: []string{`failed to parse infix expression literal "2": operator <=> is non-associative, use parentheses`, "no parse function for > found"},
+		},
+		{
+			name:   "should reject a non-associative operator after a built-in one",
+			input:  "infix 4 <=> = f; 1 > 2 <=> 3",
+			errors: []string{`failed to parse infix expression literal "2": operator <=> is non-associative, use parentheses`, "no parse function for OPERATOR found"},
+		},
+		{
+			name:     "should accept parenthesized built-in and declared operators at one precedence",
+			input:    "infixr 5 <+> = f; infix 4 <=> = g; (1 <+> 2) - 3; 10 - (2 <+> 3); (1 <=> 2) > 3; 1 > (2 <=> 3)",
+			expected: "infixr 5 <+> = f;infix 4 <=> = g;((1 <+> 2) - 3)(10 - (2 <+> 3))((1 <=> 2) > 3)(1 > (2 <=> 3))",
+		},
+		{
+			name:   "should reject a precedence outside the built-in levels",
+			input:  "infixl 9 <+> = f;",
+			errors: []string{"operator precedence must be between 1 and 6, got 9", "no parse function for OPERATOR found", "no parse function for = found"},
+		},
+		{
+			name:   "should reject redeclaring a built-in operator",
+			input:  "infixl 6 + = f;",
+			errors: []string{"expected next token to be OPERATOR, got +", "no parse function for + found", "no parse function for = found"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.New(lexer.New(tc.input))
+
+			program := p.ParseProgram()
+
+			if tc.errors != nil {
+				require.Equal(t, tc.errors, p.Errors())
+				return
+			}
+
+			require.Empty(t, p.Errors())
+			require.Equal(t, tc.expected, program.String())
+		})
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		errors   []string
+	}{
+		{
+			name:     "should parse an annotated let",
+			input:    "let x: int = 5;",
+			expected: "let x: int = 5;",
+		},
+		{
+			name:     "should parse annotated parameters and a result",
+			input:    "let f = fn(a: int, b, c: string = \"x\") -> bool { true };",
+			expected: "let f = fn(a: int, b, c: string = \"x\") -> bool true;",
+		},
+		{
+			name:     "should parse an annotated rest parameter",
+			input:    "let f = fn(a: int, ..r: [int]) { r };",
+			expected: "let f = fn(a: int, ..r: [int])r;",
+		},
+		{
+			name:     "should parse array, hash and function types",
+			input:    "let g: fn([int], {string: bool}) -> fn() -> null = h;",
+			expected: "let g: fn([int], {string: bool}) -> fn() -> null = h;",
+		},
+		{
+			name:   "should reject an unknown type",
+			input:  "let x: float = 1;",
+			errors: []string{"unknown type float", "no parse function for = found"},
+		},
+		{
+			name:   "should reject a function type without a result",
+			input:  "let f: fn(int) = g;",
+			errors: []string{"expected next token to be ->, got =", "no parse function for = found"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.New(lexer.New(tc.input))
+
+			program := p.ParseProgram()
+
+			if tc.errors != nil {
+				require.Equal(t, tc.errors, p.Errors())
+				return
+			}
+
+			require.Empty(t, p.Errors())
+			require.Equal(t, tc.expected, program.String())
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/parser"
+)
+
+func TestTree(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:  "let with infix",
+			input: "let x = 1 + y;",
+			expected: "Program\n" +
+				"  Statements[0]: Let\n" +
+				"    Name: Identifier x\n" +
+				"    Value: InfixOperator +\n" +
+				"      Left: Integer 1\n" +
+				"      Right: Identifier y\n",
+		},
+		{
+			name:  "named function and call",
+			input: "let f = fn(a, b = 2) { a }; f(1)",
+			expected: "Program\n" +
+				"  Statements[0]: Let\n" +
+				"    Name: Identifier f\n" +
+				"    Value: Function f\n" +
+				"      Parameters[0]: Identifier a\n" +
+				"      Parameters[1]: Identifier b\n" +
+				"      Defaults[1]: Integer 2\n" +
+				"      Body: Block\n" +
+				"        Statements[0]: ExpressionStatement\n" +
+				"          Expression: Identifier a\n" +
+				"  Statements[1]: ExpressionStatement\n" +
+				"    Expression: Call\n" +
+				"      Function: Identifier f\n" +
+				"      Arguments[0]: Integer 1\n",
+		},
+		{
+			name:  "if without alternative",
+			input: "if (x) { \"a\" }",
+			expected: "Program\n" +
+				"  Statements[0]: ExpressionStatement\n" +
+				"    Expression: If\n" +
+				"      Condition: Identifier x\n" +
+				"      Consequence: Block\n" +
+				"        Statements[0]: ExpressionStatement\n" +
+				"          Expression: String \"a\"\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.New(lexer.New(tc.input))
+
+			program := p.ParseProgram()
+
+			require.Empty(t, p.Errors())
+			require.Equal(t, tc.expected, tree.String(program))
+		})
+	}
+}
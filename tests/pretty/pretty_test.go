@@ -0,0 +1,161 @@
+package pretty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/object"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/pretty"
+)
+
+func TestHighlight(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "keywords, operators and literals",
+			input:    "let x = 5 + y;",
+			expected: pretty.MAGENTA + "let" + pretty.RESET + " x " + pretty.CYAN + "=" + pretty.RESET + " " + pretty.YELLOW + "5" + pretty.RESET + " " + pretty.CYAN + "+" + pretty.RESET + " y;",
+		},
+		{
+			name:     "strings keep their quotes",
+			input:    "puts(\"hi\")",
+			expected: "puts(" + pretty.GREEN + "\"hi\"" + pretty.RESET + ")",
+		},
+		{
+			name:     "interpolations colour the embedded expression",
+			input:    "\"a${b}c\"",
+			expected: pretty.GREEN + "\"a${" + pretty.RESET + "b" + pretty.GREEN + "}c\"" + pretty.RESET,
+		},
+		{
+			name:     "unterminated strings are illegal",
+			input:    "\"abc",
+			expected: pretty.RED + "\"abc" + pretty.RESET,
+		},
+		{
+			name:     "illegal characters",
+			input:    "1 @ 2",
+			expected: pretty.YELLOW + "1" + pretty.RESET + " " + pretty.RED + "@" + pretty.RESET + " " + pretty.YELLOW + "2" + pretty.RESET,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			highlighted := pretty.Highlight(tc.input)
+
+			require.Equal(t, tc.expected, highlighted)
+			require.Equal(t, tc.input, pretty.Strip(highlighted))
+		})
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     string
+		printer   pretty.Printer
+		expected  string
+		truncated bool
+	}{
+		{
+			name:     "short values stay on one line",
+			input:    `[1, "a", {"k": [true, null]}]`,
+			printer:  pretty.New(false),
+			expected: `[1, a, {k: [true, null]}]`,
+		},
+		{
+			name:    "long arrays break across lines",
+			input:   `[1, 2, 3]`,
+			printer: pretty.Printer{Width: 6},
+			expected: "[\n" +
+				"  1,\n" +
+				"  2,\n" +
+				"  3\n" +
+				"]",
+		},
+		{
+			name:    "nested values are indented",
+			input:   `{"name": "monkey", "tags": ["a", "b"]}`,
+			printer: pretty.Printer{Width: 16},
+			expected: "{\n" +
+				"  name: monkey,\n" +
+				"  tags: [a, b]\n" +
+				"}",
+		},
+		{
+			name:    "nested values break when they do not fit",
+			input:   `[[1, 2, 3], [4]]`,
+			printer: pretty.Printer{Width: 8},
+			expected: "[\n" +
+				"  [\n" +
+				"    1,\n" +
+				"    2,\n" +
+				"    3\n" +
+				"  ],\n" +
+				"  [4]\n" +
+				"]",
+		},
+		{
+			name:      "large arrays are summarised",
+			input:     `[1, 2, 3, 4, 5]`,
+			printer:   pretty.Printer{MaxItems: 2},
+			expected:  `[1, 2, ... 3 more]`,
+			truncated: true,
+		},
+		{
+			name:      "large hashes are summarised",
+			input:     `{1: 1, 2: 2, 3: 3}`,
+			printer:   pretty.Printer{MaxItems: 1},
+			expected:  `{1: 1, ... 2 more}`,
+			truncated: true,
+		},
+		{
+			name:      "long strings are cut",
+			input:     `"abcdef"`,
+			printer:   pretty.Printer{MaxString: 3},
+			expected:  `abc... 3 more chars`,
+			truncated: true,
+		},
+		{
+			name:     "colour by type",
+			input:    `[1, "a", null]`,
+			printer:  pretty.New(true),
+			expected: "[" + pretty.YELLOW + "1" + pretty.RESET + ", " + pretty.GREEN + "a" + pretty.RESET + ", " + pretty.DIM + "null" + pretty.RESET + "]",
+		},
+		{
+			name:     "colour does not count towards the width",
+			input:    `[1, 2]`,
+			printer:  pretty.Printer{Color: true, Width: 6},
+			expected: "[" + pretty.YELLOW + "1" + pretty.RESET + ", " + pretty.YELLOW + "2" + pretty.RESET + "]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatted, truncated := tc.printer.Format(testEval(t, tc.input))
+
+			require.Equal(t, tc.expected, formatted)
+			require.Equal(t, tc.truncated, truncated)
+		})
+	}
+}
+
+func testEval(t *testing.T, input string) object.Object {
+	p := parser.New(lexer.New(input))
+
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	evaluated := evaluator.Eval(program, environment.New())
+	require.NotNil(t, evaluated)
+	require.False(t, strings.HasPrefix(evaluated.Inspect(), "ERROR"))
+
+	return evaluated
+}
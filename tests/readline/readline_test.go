@@ -0,0 +1,265 @@
+package readline
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/readline"
+)
+
+const (
+	up    = "\x1b[A"
+	down  = "\x1b[B"
+	right = "\x1b[C"
+	left  = "\x1b[D"
+)
+
+func TestReadLine(t *testing.T) {
+	testCases := []struct {
+		name     string
+		history  []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "should return typed text on enter",
+			input:    "let x = 1;\r",
+			expected: "let x = 1;",
+		},
+		{
+			name:     "should insert at the cursor",
+			input:    "ac" + left + "b\r",
+			expected: "abc",
+		},
+		{
+			name:     "should jump home and end",
+			input:    "bc\x01a\x05d\r",
+			expected: "abcd",
+		},
+		{
+			name:     "should delete backwards and forwards",
+			input:    "abcd\x7f" + left + left + "\x1b[3~\r",
+			expected: "ac",
+		},
+		{
+			name:     "should kill to the end and to the start",
+			input:    "hello world" + left + left + left + left + left + "\x0b\x02\x15\r",
+			expected: " ",
+		},
+		{
+			name:     "should delete the previous word",
+			input:    "let value\x17x\r",
+			expected: "let x",
+		},
+		{
+			name:     "should ignore unknown escape sequences",
+			input:    "a\x1b[15~b\r",
+			expected: "ab",
+		},
+		{
+			name:     "should recall history with the arrow keys",
+			history:  []string{"first", "second"},
+			input:    up + up + down + "!\r",
+			expected: "second!",
+		},
+		{
+			name:     "should restore the draft below the newest entry",
+			history:  []string{"first"},
+			input:    "dra" + up + down + "ft\r",
+			expected: "draft",
+		},
+		{
+			name:     "should stop at the oldest entry",
+			history:  []string{"first"},
+			input:    up + up + up + "\r",
+			expected: "first",
+		},
+		{
+			name:     "should submit the reverse search match",
+			history:  []string{"let a = 1;", "puts(a)", "let b = 2;"},
+			input:    "\x12let\r",
+			expected: "let b = 2;",
+		},
+		{
+			name:     "should search older matches with repeated ctrl-r",
+			history:  []string{"let a = 1;", "puts(a)", "let b = 2;"},
+			input:    "\x12let\x12\r",
+			expected: "let a = 1;",
+		},
+		{
+			name:     "should keep editing the match after leaving the search",
+			history:  []string{"puts(a)"},
+			input:    "\x12puts" + right + "\x7f\x7fb)\r",
+			expected: "puts(b)",
+		},
+		{
+			name:     "should drop the match when the query no longer matches",
+			history:  []string{"puts(a)"},
+			input:    "\x12putz\r",
+			expected: "",
+		},
+		{
+			name:     "should match again when the query is shortened",
+			history:  []string{"puts(a)"},
+			input:    "\x12putz\x7f\r",
+			expected: "puts(a)",
+		},
+		{
+			name:     "should restore the line when the search is cancelled",
+			history:  []string{"puts(a)"},
+			input:    "x\x12puts\x07\r",
+			expected: "x",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			history := readline.NewHistory()
+			for _, entry := range tc.history {
+				require.NoError(t, history.Add(entry))
+			}
+
+			editor := readline.New(strings.NewReader(tc.input), io.Discard, history, nil)
+
+			line, err := editor.ReadLine(">> ")
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, line)
+		})
+	}
+}
+
+// chunks reads as a terminal does, one write at a time
+type chunks []string
+
+func (c *chunks) Read(p []byte) (int, error) {
+	if len(*c) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, (*c)[0])
+	if (*c)[0] = (*c)[0][n:]; len((*c)[0]) == 0 {
+		*c = (*c)[1:]
+	}
+
+	return n, nil
+}
+
+func TestReadLineEscape(t *testing.T) {
+	// a lone escape must not wait for, or swallow, the key after it
+	editor := readline.New(&chunks{"a", "\x1b", "b", left, "c\r"}, io.Discard, readline.NewHistory(), nil)
+
+	line, err := editor.ReadLine(">> ")
+	require.NoError(t, err)
+	require.Equal(t, "acb", line)
+}
+
+func TestReadLineControl(t *testing.T) {
+	editor := readline.New(strings.NewReader("abc\x03\x04"), io.Discard, readline.NewHistory(), nil)
+
+	_, err := editor.ReadLine(">> ")
+	require.ErrorIs(t, err, readline.ErrInterrupted)
+
+	_, err = editor.ReadLine(">> ")
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestComplete(t *testing.T) {
+	words := func() []string {
+		return []string{"let", "len", "length", "last", "puts", "len"}
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		listed   string
+	}{
+		{
+			name:     "should complete a unique prefix",
+			input:    "pu\t(1)\r",
+			expected: "puts(1)",
+		},
+		{
+			name:     "should extend to the common prefix",
+			input:    "x + le\t\r",
+			expected: "x + le",
+			listed:   "len  length  let",
+		},
+		{
+			name:     "should extend an ambiguous prefix as far as it can",
+			input:    "leng\t\r",
+			expected: "length",
+		},
+		{
+			name:     "should complete in the middle of a line",
+			input:    "(la)" + left + "\t[0]\r",
+			expected: "(last[0])",
+		},
+		{
+			name:     "should leave unknown prefixes alone",
+			input:    "zz\t\r",
+			expected: "zz",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			editor := readline.New(strings.NewReader(tc.input), &out, readline.NewHistory(), words)
+
+			line, err := editor.ReadLine(">> ")
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, line)
+
+			if len(tc.listed) > 0 {
+				require.Contains(t, out.String(), "\r\n"+tc.listed+"\r\n")
+			}
+		})
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".monkey_history")
+
+	history, err := readline.LoadHistory(path)
+	require.NoError(t, err)
+	require.Empty(t, history.Entries())
+
+	editor := readline.New(strings.NewReader("1 + 1\r1 + 1\r\r  \rlen(\"a\")\r"), io.Discard, history, nil)
+
+	for range 5 {
+		_, err := editor.ReadLine(">> ")
+		require.NoError(t, err)
+	}
+
+	reloaded, err := readline.LoadHistory(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1 + 1", "len(\"a\")"}, reloaded.Entries())
+}
+
+func TestHistoryLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".monkey_history")
+
+	lines := []string{}
+	for i := 0; i < readline.MAX_HISTORY+5; i++ {
+		lines = append(lines, strings.Repeat("x", i+1))
+	}
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+
+	history, err := readline.LoadHistory(path)
+	require.NoError(t, err)
+	require.Len(t, history.Entries(), readline.MAX_HISTORY)
+	require.Equal(t, lines[5], history.Entries()[0])
+
+	reloaded, err := readline.LoadHistory(path)
+	require.NoError(t, err)
+	require.Equal(t, history.Entries(), reloaded.Entries())
+}
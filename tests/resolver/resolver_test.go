@@ -0,0 +1,162 @@
+package resolver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/ast"
+	fnexp "github.com/w-h-a/interpreter/internal/ast/expression/function"
+	"github.com/w-h-a/interpreter/internal/ast/expression/identifier"
+	"github.com/w-h-a/interpreter/internal/ast/statement"
+	"github.com/w-h-a/interpreter/internal/ast/tree"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/resolver"
+)
+
+func parse(t *testing.T, input string) *statement.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+	return program
+}
+
+func collect(node ast.Node, visit func(ast.Node)) {
+	visit(node)
+	for _, child := range tree.Children(node) {
+		collect(child.Node, visit)
+	}
+}
+
+func coordinates(program *statement.Program) []string {
+	out := []string{}
+
+	collect(program, func(node ast.Node) {
+		if ident, ok := node.(*identifier.Identifier); ok && ident.Resolved {
+			out = append(out, fmt.Sprintf("%s@%d:%d", ident.Value, ident.Depth, ident.Slot))
+		}
+	})
+
+	return out
+}
+
+func TestCoordinates(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "should leave top-level names global",
+			input:    "let x = 1; x + len([])",
+			expected: []string{"x@0:-1", "x@0:-1", "len@0:-1"},
+		},
+		{
+			name:     "should give parameters and lets slots in order",
+			input:    "fn(a, b) { let c = a; if (b) { let d = c; d } }",
+			expected: []string{"a@0:0", "b@0:1", "c@0:2", "a@0:0", "b@0:1", "d@0:3", "c@0:2", "d@0:3"},
+		},
+		{
+			name:     "should reuse the slot of a name bound again",
+			input:    "fn(a) { let a = a + 1; let b = a; let b = b; b }",
+			expected: []string{"a@0:0", "a@0:0", "a@0:0", "b@0:1", "a@0:0", "b@0:1", "b@0:1", "b@0:1"},
+		},
+		{
+			name:     "should count frames out to enclosing functions and globals",
+			input:    "let g = 1; fn(a) { fn(b) { a + b + g } }",
+			expected: []string{"g@0:-1", "a@0:0", "b@0:0", "a@1:0", "b@0:0", "g@2:-1"},
+		},
+		{
+			name:     "should find a binding made later in an enclosing function",
+			input:    "fn() { let f = fn() { x }; let x = 1; f() }",
+			expected: []string{"f@0:0", "x@1:1", "x@0:1", "f@0:0"},
+		},
+		{
+			name:     "should resolve defaults and rest in the new frame",
+			input:    "fn(a, b = a, ..rest) { rest }",
+			expected: []string{"a@0:0", "b@0:1", "a@0:0", "rest@0:2", "rest@0:2"},
+		},
+		{
+			name:     "should not resolve field names",
+			input:    "fn(m) { m.name }",
+			expected: []string{"m@0:0", "m@0:0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			program := parse(t, tc.input)
+
+			resolver.Resolve(program)
+
+			require.Equal(t, tc.expected, coordinates(program))
+		})
+	}
+}
+
+func TestFramesAndCaptures(t *testing.T) {
+	program := parse(t, "let make = fn(start) { let count = start; let unused = 0; fn(step) { fn() { count + step } } };")
+
+	resolver.Resolve(program)
+
+	functions := []*fnexp.Function{}
+	collect(program, func(node ast.Node) {
+		if fn, ok := node.(*fnexp.Function); ok {
+			functions = append(functions, fn)
+		}
+	})
+	require.Len(t, functions, 3)
+
+	frames := []string{}
+	captures := []string{}
+
+	for _, fn := range functions {
+		names := []string{}
+		for _, s := range fn.Frame {
+			names = append(names, s.String())
+		}
+		frames = append(frames, fmt.Sprint(names))
+
+		captured := []string{}
+		for _, c := range fn.Captures {
+			captured = append(captured, fmt.Sprintf("%s@%d:%d", c.Value, c.Depth, c.Slot))
+		}
+		captures = append(captures, fmt.Sprint(captured))
+	}
+
+	require.Equal(t, []string{"[start count unused]", "[step]", "[]"}, frames)
+	require.Equal(t, []string{"[]", "[count@0:1]", "[count@1:1 step@0:0]"}, captures)
+}
+
+func TestSameBehaviour(t *testing.T) {
+	inputs := []string{
+		"let f = fn(c) { if (c) { let x = 1; }; x }; let x = 2; [f(true), f(false)]",
+		"let f = fn() { let g = fn() { x }; let x = 5; g() }; f()",
+		"let f = fn() { let y = x; let x = 3; [y, x] }; let x = 1; f()",
+		"let counter = fn() { let n = 0; fn() { let n = n + 1; n } }; let c = counter(); [c(), c()]",
+		"let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(15)",
+		"let f = fn(a, b = a * 2, ..rest) { [a, b, rest] }; [f(1), f(1, 5, 6, 7)]",
+		"let len = fn(x) { 42 }; let g = fn(len) { len }; [len([]), g(7)]",
+		"let f = fn() { infixl 5 <+> = fn(a, b) { a * 10 + b }; 1 <+> 2 }; f()",
+		"let adder = fn(a) { fn(b) { fn(c) { a + b + c } } }; adder(1)(2)(3)",
+		"let f = fn(x) { let x = x + 1; x }; f(1)",
+		"let apply = fn(f, x) { f(x) }; apply(fn(y) { y * y }, 9)",
+		"let f = fn(h) { h.a ?? h?.b }; f({\"b\": 3})",
+		"let f = fn(n) { \"n is ${n + 1}\" }; f(1)",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			program := parse(t, input)
+			expected := evaluator.Eval(program, environment.New())
+
+			resolver.Resolve(program)
+			actual := evaluator.Eval(program, environment.New())
+
+			require.Equal(t, expected.Inspect(), actual.Inspect())
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package typecheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/evaluator"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/object/environment"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/typecheck"
+)
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:  "should accept a well-typed program",
+			input: `let add = fn(a: int, b: int = 2) -> int { a + b }; let xs: [int] = [add(1), add(1, 2)]; let h: {string: [int]} = {"xs": xs}; len(h["xs"])`,
+		},
+		{
+			name:  "should treat unannotated code as dynamic",
+			input: `let f = fn(a, b) { a + b }; let x: int = f("a", "b"); f(1, true)`,
+		},
+		{
+			name:     "should report a let whose value does not match its annotation",
+			input:    `let x: int = "five"; let y: [string] = [1, 2];`,
+			expected: []string{`1:15: cannot use string as int in let x`, `1:41: cannot use int as string in let y`, `1:44: cannot use int as string in let y`},
+		},
+		{
+			name:     "should check each element and branch against the annotation",
+			input:    `let x: [int] = [1, "a"]; let y: int = if (true) { 1 } else { "a" }; let z: int = if (true) { 1 }; let w: [[int]] = [[1], [if (true) { 2 } else { true }]];`,
+			expected: []string{"1:21: cannot use string as int in let x", "1:63: cannot use string as int in let y", "1:82: cannot use null as int in let z", "1:146: cannot use bool as int in let w"},
+		},
+		{
+			name:  "should accept branches and elements that fit the annotation",
+			input: `let x: [int] = [1, if (true) { 2 } else { 3 }]; let y: any = if (true) { 1 } else { "a" }; let z: null = if (false) { null }; let f = fn(a: [string] = ["a", "b"]) -> int { if (true) { return 1; } else { 2 } };`,
+		},
+		{
+			name:     "should check defaults and returns element by element",
+			input:    `let f = fn(a: [int] = [1, "b"]) -> int { if (true) { 1 } }; let g = fn() -> [string] { return ["a", 2]; };`,
+			expected: []string{"1:28: cannot use string as int in default of parameter a", "1:42: cannot return null from a function returning int", "1:101: cannot return int from a function returning string"},
+		},
+		{
+			name:     "should follow the types of unannotated lets",
+			input:    `let x = 1; let s: string = x;`,
+			expected: []string{"1:28: cannot use int as string in let s"},
+		},
+		{
+			name:  "should make a name bound twice dynamic",
+			input: `let x = 1; let f = fn() { let s: string = x; s }; let x = "one"; f()`,
+		},
+		{
+			name:     "should report arguments of the wrong type",
+			input:    `let greet = fn(name: string) -> string { "hi " + name }; greet(1); 2 |> greet`,
+			expected: []string{"1:64: cannot use int as string in argument 1 to greet", "1:68: cannot use int as string in argument 1 to greet"},
+		},
+		{
+			name:     "should report the wrong number of arguments",
+			input:    `let f: fn(int) -> int = fn(a) { a }; f(1, 2)`,
+			expected: []string{"1:38: wrong number of arguments to f: want 1, got 2"},
+		},
+		{
+			name:     "should report returns and results against the result type",
+			input:    `let f = fn(x: int) -> string { if (x > 0) { return x; } "none" }; let g = fn() -> bool { 1 };`,
+			expected: []string{"1:45: cannot return int from a function returning string", "1:90: cannot return int from a function returning bool"},
+		},
+		{
+			name:     "should report a body that falls off the end against the result type",
+			input:    `let f = fn() -> int { }; f(); let g = fn() -> string { let s = "x"; }; let h = fn() -> null { let n = 1; }; let k = fn() -> any { }`,
+			expected: []string{"1:21: cannot return null from a function returning int", "1:56: cannot return null from a function returning string"},
+		},
+		{
+			name:     "should report functions whose parameters do not match",
+			input:    `let apply = fn(f: fn(int) -> int, x: int) -> int { f(x) }; apply(fn(s: string) { s }, 1)`,
+			expected: []string{"1:66: cannot use fn(string) -> any as fn(int) -> int in argument 1 to apply"},
+		},
+		{
+			name:     "should report operators on the wrong types",
+			input:    `let n: int = 1; let s: string = "a"; n + s; -s; n < "b"; s + "b"`,
+			expected: []string{"1:40: invalid operation: int + string", "1:45: invalid operation: -string", "1:51: invalid operation: int < string"},
+		},
+		{
+			name:     "should report bad indexes and calls",
+			input:    `let xs: [int] = [1]; let h: {string: int} = {"a": 1}; xs["a"]; h[1]; h.a + 1; 5()`,
+			expected: []string{"1:57: cannot index [int] with string", "1:65: cannot index {string: int} with int", "1:79: cannot call 5 of type int"},
+		},
+		{
+			name:     "should check defaults against their parameter",
+			input:    `let f = fn(a: int = "x") { a }; f()`,
+			expected: []string{`1:22: cannot use string as int in default of parameter a`},
+		},
+		{
+			name:     "should check rest arguments against the rest annotation",
+			input:    `let sum = fn(a: int, ..xs: [int]) -> int { a + len(xs) }; sum(1, 2, "3"); let s: string = fn(..ys: [string]) { ys }()[0]; fn(..r: int) { r }`,
+			expected: []string{"1:70: cannot use string as int in argument 3 to sum", "1:131: rest parameter r must be an array, not int"},
+		},
+		{
+			name:     "should check calls of declared operators",
+			input:    `infixl 6 <+> = fn(a: int, b: int) -> int { a + b }; 1 <+> "2"`,
+			expected: []string{"1:60: cannot use string as int in argument 2 to <+>"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.New(lexer.New(tc.input))
+
+			program := p.ParseProgram()
+			require.Empty(t, p.Errors())
+
+			actual := []string{}
+			for _, d := range typecheck.Check(program) {
+				actual = append(actual, d.String())
+			}
+
+			if tc.expected == nil {
+				tc.expected = []string{}
+			}
+
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestAnnotationsDoNotChangeEvaluation(t *testing.T) {
+	annotated := `let add = fn(a: int, b: int = 2, ..rest) -> int { a + b + len(rest) }; let xs: [int] = [add(1), add(1, 5, 9)]; let h: {string: fn(int) -> int} = {"f": fn(x: int) -> int { x * 2 }}; h["f"](xs[1])`
+	plain := `let add = fn(a, b = 2, ..rest) { a + b + len(rest) }; let xs = [add(1), add(1, 5, 9)]; let h = {"f": fn(x) { x * 2 }}; h["f"](xs[1])`
+
+	eval := func(input string) string {
+		p := parser.New(lexer.New(input))
+
+		program := p.ParseProgram()
+		require.Empty(t, p.Errors())
+
+		return evaluator.Eval(program, environment.New()).Inspect()
+	}
+
+	require.Equal(t, "14", eval(annotated))
+	require.Equal(t, eval(plain), eval(annotated))
+}
@@ -0,0 +1,110 @@
+package vet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/w-h-a/interpreter/internal/lexer"
+	"github.com/w-h-a/interpreter/internal/parser"
+	"github.com/w-h-a/interpreter/internal/vet"
+)
+
+func TestVet(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:  "should accept a clean program",
+			input: "let add = fn(a, b) { a + b }; puts(add(1, 2));",
+		},
+		{
+			name:     "should report undefined identifiers",
+			input:    "puts(x); let y = 1; y + z",
+			expected: []string{"1:6: undefined: x (undefined)", "1:25: undefined: z (undefined)"},
+		},
+		{
+			name:  "should let functions refer to bindings made after them",
+			input: "let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(4)",
+		},
+		{
+			name:     "should report unused lets and parameters",
+			input:    "let x = 1; let f = fn(a, b, _c) { a }; f(1, 2, 3)",
+			expected: []string{"1:5: let x is declared but never used (unused)", "1:26: parameter b is declared but never used (unused)"},
+		},
+		{
+			name:  "should not report exported lets as unused",
+			input: "export let x = 1;",
+		},
+		{
+			name:     "should report a let that is bound again before use",
+			input:    "let x = 1; let x = 2; x",
+			expected: []string{"1:5: let x is declared but never used (unused)"},
+		},
+		{
+			name:     "should report shadowed names",
+			input:    "let x = 1; let f = fn(x) { let len = x; len }; f(x)",
+			expected: []string{"1:23: parameter x shadows a binding in an enclosing scope (shadow)", "1:32: let len shadows the builtin len (shadow)"},
+		},
+		{
+			name:     "should report code after a return",
+			input:    "let f = fn() { return 1; puts(2); puts(3) }; f()",
+			expected: []string{"1:26: unreachable code after return (unreachable)"},
+		},
+		{
+			name:     "should report code after an if whose branches both return",
+			input:    "let f = fn(x) { if (x) { return 1; } else { return 2; }; x }; f(1)",
+			expected: []string{"1:58: unreachable code after return (unreachable)"},
+		},
+		{
+			name:     "should report constant if conditions",
+			input:    "if (0) { 1 }; if (null) { 2 }; let x = true; if (x) { 3 }",
+			expected: []string{"1:5: if condition 0 is always true (constant-condition)", "1:19: if condition null is always false (constant-condition)"},
+		},
+		{
+			name:  "should report calls with the wrong number of arguments",
+			input: "let f = fn(a, b = 2) { a + b }; f(); f(1, 2, 3); fn(x) { x }(); 1 |> f(2, 3); let g = fn(..xs) { xs }; g()",
+			expected: []string{
+				"1:33: wrong number of arguments to f: want 1 to 2, got 0 (arity)",
+				"1:38: wrong number of arguments to f: want 1 to 2, got 3 (arity)",
+				"1:50: wrong number of arguments to <anonymous>: want 1, got 0 (arity)",
+				"1:67: wrong number of arguments to f: want 1 to 2, got 3 (arity)",
+			},
+		},
+		{
+			name:  "should report comparisons that can never be equal",
+			input: `1 == "1"; true != null; [] == []; {} == x; "a" == "b"; 1 == x`,
+			expected: []string{
+				"1:3: comparison of INTEGER and STRING with == is always false (comparison)",
+				"1:16: comparison of BOOLEAN and NULL with != is always true (comparison)",
+				"1:28: comparison of ARRAY and ARRAY with == is always false (comparison)",
+				"1:41: undefined: x (undefined)",
+				"1:61: undefined: x (undefined)",
+			},
+		},
+		{
+			name:  "should resolve declared operators and imports",
+			input: `import "lib" as lib; infixl 5 <+> = fn(a, b) { lib.add(a, b) }; 1 <+> 2`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := parser.New(lexer.New(tc.input))
+			program := p.ParseProgram()
+			require.Empty(t, p.Errors())
+
+			diagnostics := []string{}
+			for _, d := range vet.Vet(program) {
+				diagnostics = append(diagnostics, d.String())
+			}
+
+			if tc.expected == nil {
+				tc.expected = []string{}
+			}
+
+			require.Equal(t, tc.expected, diagnostics)
+		})
+	}
+}